@@ -0,0 +1,83 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// asyncSubmit makes create and bulk-create return as soon as the orderer has accepted the transaction, instead of
+// waiting for it to commit. The commits are only checked when the session or command ends, so until then a reported
+// success merely means the transaction was endorsed and ordered: it may still fail validation, for example on an MVCC
+// read conflict, and its writes are not yet visible to queries. A client that stops abruptly never learns the outcome.
+var asyncSubmit = flag.Bool("async", false, "do not wait for create and bulk-create transactions to commit, check them all at the end")
+
+// pendingCommit is a transaction submitted asynchronously whose commit status has not been checked yet.
+type pendingCommit struct {
+	description string
+	commit      *client.Commit
+}
+
+// pendingCommits holds every asynchronous submit of the session in submission order.
+var pendingCommits []pendingCommit
+
+// submitAsync submits a transaction through the submit middleware chain and returns without waiting for the commit,
+// which is checked later by awaitPendingCommits.
+func submitAsync(contract *client.Contract, description string, name string, args ...string) ([]byte, error) {
+	var commit *client.Commit
+	submit := func(name string, args ...string) ([]byte, error) {
+		result, submitted, err := contract.SubmitAsync(name, client.WithArguments(args...))
+		commit = submitted
+		return result, err
+	}
+
+	result, err := chain(submitMiddleware, submit)(name, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	trackCommit(description, commit)
+	return result, nil
+}
+
+// trackCommit records an asynchronously submitted transaction for awaitPendingCommits.
+func trackCommit(description string, commit *client.Commit) {
+	pendingCommits = append(pendingCommits, pendingCommit{description: description, commit: commit})
+	fmt.Printf("*** Transaction %s submitted, its commit is checked at the end\n", commit.TransactionID())
+}
+
+// awaitPendingCommits waits for the commit status of every asynchronous submit, reports how many committed and how
+// many failed, and returns an error if any failed.
+func awaitPendingCommits() error {
+	if len(pendingCommits) == 0 {
+		return nil
+	}
+
+	fmt.Printf("Waiting for %d submitted transactions to commit...\n", len(pendingCommits))
+	failed := 0
+	for _, pending := range pendingCommits {
+		status, err := pending.commit.Status()
+		if err != nil {
+			failed++
+			fmt.Printf("%s (tx %s): commit status unknown\n", pending.description, pending.commit.TransactionID())
+			printGatewayError(err)
+			continue
+		}
+		if !status.Successful {
+			failed++
+			fmt.Printf("%s (tx %s): failed to commit with status code %d (%s)\n", pending.description, status.TransactionID, int32(status.Code), status.Code)
+		}
+	}
+
+	fmt.Printf("*** %d committed, %d failed\n", len(pendingCommits)-failed, failed)
+	pendingCommits = nil
+	if failed > 0 {
+		return fmt.Errorf("%d asynchronously submitted transactions did not commit", failed)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// benchReadSample is how many existing persons a read benchmark cycles through.
+const benchReadSample = 100
+
+// benchCleanupBatch is how many benchmark persons a single cleanup transaction deletes.
+const benchCleanupBatch = 100
+
+// benchCommand measures throughput and latency of creates or reads:
+// bench [-op create|read] [-n ops] [-concurrency workers] [-city name] [-cleanup]
+//
+// Every operation goes through the regular middleware chains, so -max-tps and -max-eval-tps cap the load and
+// -retries makes failures look rarer than they are.
+func benchCommand(contract *client.Contract, args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	op := flags.String("op", "read", "operation to benchmark, create or read")
+	count := flags.Int("n", 100, "total number of operations")
+	concurrency := flags.Int("concurrency", 10, "number of operations in flight at once")
+	city := flags.String("city", "Moscow", "city of the created persons, which must be allowed when the ledger restricts cities")
+	cleanup := flags.Bool("cleanup", false, "delete the persons created by a create benchmark once it is done")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 || *count <= 0 || *concurrency <= 0 {
+		return errors.New("usage: bench [-op create|read] [-n ops] [-concurrency workers] [-city name] [-cleanup]")
+	}
+
+	var operation func(i int) error
+	var created []string
+	var createdMutex sync.Mutex
+	switch *op {
+	case "create":
+		prefix := fmt.Sprintf("bench-%d-", time.Now().UnixNano())
+		operation = func(i int) error {
+			id := prefix + strconv.Itoa(i)
+			_, err := submitTransaction(contract, "CreatePerson", id, "0000 000000", "Bench", "Load", *city, "bench", "70000000000", "false")
+			if err == nil {
+				createdMutex.Lock()
+				created = append(created, id)
+				createdMutex.Unlock()
+			}
+			return err
+		}
+	case "read":
+		ids, err := benchReadIds(contract)
+		if err != nil {
+			return err
+		}
+		operation = func(i int) error {
+			_, err := evaluateTransaction(contract, "ReadPerson", ids[i%len(ids)])
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown bench operation %q, expected create or read", *op)
+	}
+
+	fmt.Printf("Running %d %s operations with concurrency %d\n", *count, *op, *concurrency)
+	result := runBench(*count, *concurrency, operation)
+	result.print()
+
+	if *cleanup && len(created) > 0 {
+		return benchCleanup(contract, created)
+	}
+	return nil
+}
+
+// benchReadIds returns the ids of a sample of existing persons for a read benchmark to cycle through.
+func benchReadIds(contract *client.Contract) ([]string, error) {
+	result, err := evaluateTransaction(contract, "GetPersonsLimited", strconv.Itoa(benchReadSample))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read persons to benchmark: %w", err)
+	}
+	var persons []Person
+	if err := json.Unmarshal(result, &persons); err != nil {
+		return nil, fmt.Errorf("failed to parse persons: %w", err)
+	}
+	if len(persons) == 0 {
+		return nil, errors.New("the ledger holds no persons to read, run a create benchmark first")
+	}
+
+	ids := make([]string, len(persons))
+	for i, person := range persons {
+		ids[i] = person.ID
+	}
+	return ids, nil
+}
+
+// benchResult holds the outcome of a benchmark run.
+type benchResult struct {
+	elapsed   time.Duration
+	latencies []time.Duration
+	errors    int
+	firstErr  error
+}
+
+// runBench calls operation count times, with at most concurrency calls in flight, timing each one.
+func runBench(count int, concurrency int, operation func(i int) error) *benchResult {
+	result := &benchResult{latencies: make([]time.Duration, 0, count)}
+	var mutex sync.Mutex
+	var workers sync.WaitGroup
+
+	indexes := make(chan int)
+	start := time.Now()
+	for w := 0; w < concurrency; w++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for i := range indexes {
+				opStart := time.Now()
+				err := operation(i)
+				latency := time.Since(opStart)
+
+				mutex.Lock()
+				result.latencies = append(result.latencies, latency)
+				if err != nil {
+					result.errors++
+					if result.firstErr == nil {
+						result.firstErr = err
+					}
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < count; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	workers.Wait()
+	result.elapsed = time.Since(start)
+
+	sort.Slice(result.latencies, func(i, j int) bool { return result.latencies[i] < result.latencies[j] })
+	return result
+}
+
+// percentile returns the latency below which the given percentage of operations completed.
+func (result *benchResult) percentile(percent int) time.Duration {
+	index := (len(result.latencies)*percent+99)/100 - 1
+	if index < 0 {
+		index = 0
+	}
+	return result.latencies[index]
+}
+
+func (result *benchResult) print() {
+	ops := len(result.latencies)
+	fmt.Printf("Operations: %d in %s\n", ops, result.elapsed.Round(time.Millisecond))
+	fmt.Printf("Throughput: %.1f ops/sec\n", float64(ops)/result.elapsed.Seconds())
+	fmt.Printf("Latency:    p50 %s, p95 %s, p99 %s\n",
+		result.percentile(50).Round(time.Millisecond),
+		result.percentile(95).Round(time.Millisecond),
+		result.percentile(99).Round(time.Millisecond))
+	fmt.Printf("Errors:     %d (%.1f%%)\n", result.errors, 100*float64(result.errors)/float64(ops))
+	if result.firstErr != nil {
+		fmt.Println("First error:")
+		printGatewayError(result.firstErr)
+	}
+}
+
+// benchCleanup deletes the persons created by a benchmark, a batch per transaction.
+func benchCleanup(contract *client.Contract, ids []string) error {
+	fmt.Printf("Deleting %d benchmark persons\n", len(ids))
+	for start := 0; start < len(ids); start += benchCleanupBatch {
+		end := start + benchCleanupBatch
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		idsJSON, err := json.Marshal(ids[start:end])
+		if err != nil {
+			return err
+		}
+		if _, err := submitWithEndorseTimeout(contract, *bulkEndorseTimeout, "DeletePersonsBulk", string(idsJSON), "true"); err != nil {
+			return fmt.Errorf("failed to delete benchmark persons: %w", err)
+		}
+	}
+
+	fmt.Println("*** Benchmark persons deleted")
+	return nil
+}
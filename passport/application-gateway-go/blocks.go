@@ -0,0 +1,226 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// fabric-gateway v1.0 has no block events API, so blocks are read by evaluating the query system chaincode.
+const qsccName = "qscc"
+
+// personChange is the current state of a person written within a block range. Deleted persons are reported as
+// tombstones without data.
+type personChange struct {
+	ID      string  `json:"id"`
+	Deleted bool    `json:"deleted,omitempty"`
+	Person  *Person `json:"person,omitempty"`
+}
+
+// getBlockHeight returns the number of blocks in the channel ledger.
+func getBlockHeight(network *client.Network) (uint64, error) {
+	infoBytes, err := evaluateTransaction(network.GetContract(qsccName), "GetChainInfo", network.Name())
+	if err != nil {
+		return 0, fmt.Errorf("failed to query chain info: %w", err)
+	}
+
+	info := &common.BlockchainInfo{}
+	if err := proto.Unmarshal(infoBytes, info); err != nil {
+		return 0, fmt.Errorf("failed to parse chain info: %w", err)
+	}
+	return info.GetHeight(), nil
+}
+
+// getBlock returns the block with the given number from the channel ledger.
+func getBlock(network *client.Network, number uint64) (*common.Block, error) {
+	blockBytes, err := evaluateTransaction(network.GetContract(qsccName), "GetBlockByNumber", network.Name(), strconv.FormatUint(number, 10))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query block %d: %w", number, err)
+	}
+
+	block := &common.Block{}
+	if err := proto.Unmarshal(blockBytes, block); err != nil {
+		return nil, fmt.Errorf("failed to parse block %d: %w", number, err)
+	}
+	return block, nil
+}
+
+// writtenKeys adds to keys the simple keys of the chaincode namespace written by the valid transactions of a block.
+// Composite keys, used for secondary indexes, are skipped.
+func writtenKeys(block *common.Block, chaincode string, keys map[string]bool) error {
+	var validationCodes []byte
+	if metadata := block.GetMetadata().GetMetadata(); len(metadata) > int(common.BlockMetadataIndex_TRANSACTIONS_FILTER) {
+		validationCodes = metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER]
+	}
+
+	for i, envelopeBytes := range block.GetData().GetData() {
+		if i < len(validationCodes) && peer.TxValidationCode(validationCodes[i]) != peer.TxValidationCode_VALID {
+			continue
+		}
+
+		envelope := &common.Envelope{}
+		if err := proto.Unmarshal(envelopeBytes, envelope); err != nil {
+			return err
+		}
+		payload := &common.Payload{}
+		if err := proto.Unmarshal(envelope.GetPayload(), payload); err != nil {
+			return err
+		}
+		channelHeader := &common.ChannelHeader{}
+		if err := proto.Unmarshal(payload.GetHeader().GetChannelHeader(), channelHeader); err != nil {
+			return err
+		}
+		if common.HeaderType(channelHeader.GetType()) != common.HeaderType_ENDORSER_TRANSACTION {
+			continue
+		}
+
+		transaction := &peer.Transaction{}
+		if err := proto.Unmarshal(payload.GetData(), transaction); err != nil {
+			return err
+		}
+		for _, action := range transaction.GetActions() {
+			if err := actionWrittenKeys(action, chaincode, keys); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func actionWrittenKeys(action *peer.TransactionAction, chaincode string, keys map[string]bool) error {
+	actionPayload := &peer.ChaincodeActionPayload{}
+	if err := proto.Unmarshal(action.GetPayload(), actionPayload); err != nil {
+		return err
+	}
+	responsePayload := &peer.ProposalResponsePayload{}
+	if err := proto.Unmarshal(actionPayload.GetAction().GetProposalResponsePayload(), responsePayload); err != nil {
+		return err
+	}
+	chaincodeAction := &peer.ChaincodeAction{}
+	if err := proto.Unmarshal(responsePayload.GetExtension(), chaincodeAction); err != nil {
+		return err
+	}
+	readWriteSet := &rwset.TxReadWriteSet{}
+	if err := proto.Unmarshal(chaincodeAction.GetResults(), readWriteSet); err != nil {
+		return err
+	}
+
+	for _, namespaceSet := range readWriteSet.GetNsRwset() {
+		if namespaceSet.GetNamespace() != chaincode {
+			continue
+		}
+		kvSet := &kvrwset.KVRWSet{}
+		if err := proto.Unmarshal(namespaceSet.GetRwset(), kvSet); err != nil {
+			return err
+		}
+		for _, write := range kvSet.GetWrites() {
+			if !strings.HasPrefix(write.GetKey(), "\x00") {
+				keys[write.GetKey()] = true
+			}
+		}
+	}
+
+	return nil
+}
+
+// personsChangedSince collects the ids of persons written from startBlock up to the current ledger height and returns
+// their current state, with a tombstone for each person that no longer exists.
+func personsChangedSince(network *client.Network, contract *client.Contract, startBlock uint64) ([]personChange, error) {
+	height, err := getBlockHeight(network)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]bool)
+	for number := startBlock; number < height; number++ {
+		block, err := getBlock(network, number)
+		if err != nil {
+			return nil, err
+		}
+		if err := writtenKeys(block, contract.ChaincodeName(), ids); err != nil {
+			return nil, fmt.Errorf("failed to parse transactions of block %d: %w", number, err)
+		}
+	}
+
+	sortedIds := make([]string, 0, len(ids))
+	for id := range ids {
+		sortedIds = append(sortedIds, id)
+	}
+	sort.Strings(sortedIds)
+
+	changes := make([]personChange, 0, len(sortedIds))
+	for _, id := range sortedIds {
+		exists, err := checkPersonExists(contract, id)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			changes = append(changes, personChange{ID: id, Deleted: true})
+			continue
+		}
+
+		person, err := readPerson(contract, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read person %s: %w", id, err)
+		}
+		changes = append(changes, personChange{ID: id, Person: person})
+	}
+
+	return changes, nil
+}
+
+// heightCommand prints the current block height of the channel: height
+func heightCommand(network *client.Network, args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: height")
+	}
+
+	height, err := getBlockHeight(network)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Channel %s block height: %d\n", network.Name(), height)
+	if height > 0 {
+		fmt.Printf("Latest block number: %d\n", height-1)
+	}
+	return nil
+}
+
+// changedSinceCommand prints the persons written since a block as JSON: changed-since <block>
+func changedSinceCommand(network *client.Network, contract *client.Contract, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: changed-since <block>")
+	}
+	startBlock, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid block number %q: %w", args[0], err)
+	}
+
+	changes, err := personsChangedSince(network, contract, startBlock)
+	if err != nil {
+		return err
+	}
+
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	fmt.Println(formatJSON(changesJSON))
+	return nil
+}
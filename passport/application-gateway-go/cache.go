@@ -0,0 +1,95 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"flag"
+	"sync"
+	"time"
+)
+
+// readCacheTTL enables the read cache. Cached persons can be stale: a change submitted by any other client stays
+// invisible here until the entry expires, so the cache is only for UIs that read the same records over and over.
+var readCacheTTL = flag.Duration("read-cache-ttl", 0, "cache ReadPerson results for this long, possibly serving stale data (0 disables the cache)")
+
+// readCache holds ReadPerson results by person id until they expire.
+type readCache struct {
+	ttl     time.Duration
+	mutex   sync.Mutex
+	entries map[string]readCacheEntry
+}
+
+type readCacheEntry struct {
+	result  []byte
+	expires time.Time
+}
+
+func newReadCache(ttl time.Duration) *readCache {
+	return &readCache{ttl: ttl, entries: make(map[string]readCacheEntry)}
+}
+
+func (cache *readCache) get(id string) ([]byte, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	entry, ok := cache.entries[id]
+	if !ok {
+		return nil, false
+	}
+	if !time.Now().Before(entry.expires) {
+		delete(cache.entries, id)
+		return nil, false
+	}
+	return entry.result, true
+}
+
+func (cache *readCache) put(id string, result []byte) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.entries[id] = readCacheEntry{result: result, expires: time.Now().Add(cache.ttl)}
+}
+
+// clear drops every entry.
+func (cache *readCache) clear() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.entries = make(map[string]readCacheEntry)
+}
+
+// withReadCache serves ReadPerson calls from the cache while their entry is fresh, caching successful results.
+// Other transactions pass through.
+func withReadCache(cache *readCache) Middleware {
+	return func(next Invoker) Invoker {
+		return func(name string, args ...string) ([]byte, error) {
+			if name != "ReadPerson" || len(args) != 1 {
+				return next(name, args...)
+			}
+			if result, ok := cache.get(args[0]); ok {
+				return result, nil
+			}
+
+			result, err := next(name, args...)
+			if err == nil {
+				cache.put(args[0], result)
+			}
+			return result, err
+		}
+	}
+}
+
+// withCacheInvalidation clears the cache around every submit, whatever its outcome. Working out which persons a
+// transaction touches is not worth the risk of missing one: bulk, city-wide and spouse transactions name them in
+// ways the client cannot reliably parse.
+func withCacheInvalidation(cache *readCache) Middleware {
+	return func(next Invoker) Invoker {
+		return func(name string, args ...string) ([]byte, error) {
+			result, err := next(name, args...)
+			cache.clear()
+			return result, err
+		}
+	}
+}
@@ -0,0 +1,45 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReadCacheServesRepeatedReadsFromCache(t *testing.T) {
+	fake := &fakeInvoker{result: []byte(`{"id":"person1"}`)}
+	read := withReadCache(newReadCache(time.Minute))(fake.invoke)
+
+	for i := 0; i < 2; i++ {
+		result, err := read("ReadPerson", "person1")
+		if err != nil || string(result) != `{"id":"person1"}` {
+			t.Fatalf("read %d returned %s, %v", i, result, err)
+		}
+	}
+	read("GetAllPersons")
+	read("GetAllPersons")
+
+	if len(fake.calls) != 3 {
+		t.Errorf("calls %v reached the gateway, want the second ReadPerson served from the cache", fake.calls)
+	}
+}
+
+func TestReadCacheIsClearedBySubmit(t *testing.T) {
+	cache := newReadCache(time.Minute)
+	fake := &fakeInvoker{result: []byte(`{"id":"person1"}`)}
+	read := withReadCache(cache)(fake.invoke)
+	submit := withCacheInvalidation(cache)(fake.invoke)
+
+	read("ReadPerson", "person1")
+	submit("UpdatePersonWithDetails", `{"id":"person1"}`, "etag")
+	read("ReadPerson", "person1")
+
+	want := []string{"ReadPerson", "UpdatePersonWithDetails", "ReadPerson"}
+	if !reflect.DeepEqual(fake.calls, want) {
+		t.Errorf("calls %v reached the gateway, want %v", fake.calls, want)
+	}
+}
@@ -0,0 +1,151 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// configCheck is one item of the check-config checklist.
+type configCheck struct {
+	name  string
+	check func() error
+}
+
+// checkConfigCommand verifies the connection settings without dialing the network and prints a checklist of the
+// results: check-config
+func checkConfigCommand(config *Config, args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: check-config")
+	}
+
+	var certificate *x509.Certificate
+	checks := []configCheck{
+		{"MSP ID is set", func() error {
+			return requireSetting("MSP ID", config.MSPID)
+		}},
+		{"client certificate (" + credentialSource(certPEMEnv, config.CertPath) + ") parses", func() error {
+			var err error
+			certificate, err = loadCertificate(certPEMEnv, config.CertPath)
+			return err
+		}},
+		{"client certificate is within its validity period", func() error {
+			if certificate == nil {
+				return errors.New("no certificate to check")
+			}
+			return checkValidity(certificate, time.Now())
+		}},
+		{"private key (" + credentialSource(keyPEMEnv, config.KeyPath) + ") parses and matches the certificate", func() error {
+			privateKey, err := loadPrivateKey(config.KeyPath)
+			if err != nil {
+				return err
+			}
+			if certificate == nil {
+				return nil
+			}
+			return checkKeyPair(certificate, privateKey)
+		}},
+		{"TLS CA certificate (" + credentialSource(tlsCAPEMEnv, config.TLSCertPath) + ") parses and is valid", func() error {
+			tlsCertificate, err := loadCertificate(tlsCAPEMEnv, config.TLSCertPath)
+			if err != nil {
+				return err
+			}
+			return checkValidity(tlsCertificate, time.Now())
+		}},
+		{"TLS client certificate and key, if any, parse and match", func() error {
+			_, err := loadTLSClientCertificates(config)
+			return err
+		}},
+		{"peer endpoint " + config.PeerEndpoint + " is a host:port address", func() error {
+			return checkEndpoint(config.PeerEndpoint)
+		}},
+		{"gateway peer TLS host name is set", func() error {
+			return requireSetting("gateway peer", config.GatewayPeer)
+		}},
+		{"channel and chaincode names are set", func() error {
+			if err := requireSetting("channel", config.Channel); err != nil {
+				return err
+			}
+			return requireSetting("chaincode", config.Chaincode)
+		}},
+		{"encryption key, if any, is a valid AES key", initFieldEncryption},
+	}
+
+	failed := 0
+	for _, item := range checks {
+		if err := item.check(); err != nil {
+			failed++
+			fmt.Printf("[FAIL] %s: %s\n", item.name, err)
+		} else {
+			fmt.Printf("[ OK ] %s\n", item.name)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	fmt.Println("Configuration looks good")
+	return nil
+}
+
+// credentialSource names where a credential is loaded from: the environment variable when set, otherwise the path.
+func credentialSource(envVar string, path string) string {
+	if _, ok := os.LookupEnv(envVar); ok {
+		return "$" + envVar
+	}
+	return path
+}
+
+func requireSetting(name string, value string) error {
+	if value == "" {
+		return fmt.Errorf("%s is empty", name)
+	}
+	return nil
+}
+
+func checkValidity(certificate *x509.Certificate, now time.Time) error {
+	if now.Before(certificate.NotBefore) {
+		return fmt.Errorf("certificate %q is not valid before %s", certificate.Subject.CommonName, certificate.NotBefore.Format(time.RFC3339))
+	}
+	if now.After(certificate.NotAfter) {
+		return fmt.Errorf("certificate %q expired on %s", certificate.Subject.CommonName, certificate.NotAfter.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// checkKeyPair verifies that the private key belongs to the certificate.
+func checkKeyPair(certificate *x509.Certificate, privateKey crypto.PrivateKey) error {
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return fmt.Errorf("unsupported private key type %T", privateKey)
+	}
+	publicKey, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !publicKey.Equal(certificate.PublicKey) {
+		return errors.New("the private key does not match the certificate")
+	}
+	return nil
+}
+
+func checkEndpoint(endpoint string) error {
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return err
+	}
+	if host == "" {
+		return errors.New("host is empty")
+	}
+	number, err := strconv.Atoi(port)
+	if err != nil || number < 1 || number > 65535 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
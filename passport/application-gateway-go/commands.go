@@ -0,0 +1,1081 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
+	"passport/server"
+)
+
+// runCommand executes a non-interactive command given on the command line instead of starting the menu.
+func runCommand(config *Config, network *client.Network, contract *client.Contract, args []string) error {
+	switch args[0] {
+	case "changed-since":
+		return changedSinceCommand(network, contract, args[1:])
+	case "raw":
+		return rawCommand(contract, args[1:])
+	case "serve":
+		return serveCommand(contract, args[1:])
+	case "bulk-create":
+		return bulkCreateCommand(contract, args[1:])
+	case "retry-batch":
+		return retryBatchCommand(contract, args[1:])
+	case "bulk-update":
+		return bulkUpdateCommand(contract, args[1:])
+	case "bulk-delete":
+		return bulkDeleteCommand(contract, args[1:])
+	case "by-phone":
+		return byPhoneCommand(contract, args[1:])
+	case "redacted":
+		return redactedCommand(contract, args[1:])
+	case "get-many":
+		return getManyCommand(contract, args[1:])
+	case "search":
+		return searchCommand(contract, args[1:])
+	case "by-city":
+		return byCityCommand(contract, args[1:])
+	case "by-gender":
+		return byGenderCommand(contract, args[1:])
+	case "age":
+		return ageCommand(contract, args[1:])
+	case "by-reference":
+		return byReferenceCommand(contract, args[1:])
+	case "provisional":
+		return provisionalCommand(contract, args[1:])
+	case "expired-provisional":
+		return expiredProvisionalCommand(contract, args[1:])
+	case "purge-expired":
+		return purgeExpiredCommand(contract, args[1:])
+	case "expired":
+		return expiredCommand(contract, args[1:])
+	case "link-spouses":
+		return linkSpousesCommand(contract, args[1:])
+	case "unlink-spouse":
+		return unlinkSpouseCommand(contract, args[1:])
+	case "marry":
+		return marryCommand(contract, args[1:])
+	case "divorce":
+		return divorceCommand(contract, args[1:])
+	case "tail":
+		return tailCommand(network, contract, args[1:])
+	case "metadata":
+		return metadataCommand(contract, args[1:])
+	case "bench":
+		return benchCommand(contract, args[1:])
+	case "archive-city":
+		return archiveCityCommand(contract, args[1:])
+	case "archived":
+		return archivedCommand(contract, args[1:])
+	case "restore-archived":
+		return restoreArchivedCommand(contract, args[1:])
+	case "op-count":
+		return opCountCommand(contract, args[1:])
+	case "top-creators":
+		return topCreatorsCommand(contract, args[1:])
+	case "orphaned-audits":
+		return orphanedAuditsCommand(contract, args[1:])
+	case "validate-ledger":
+		return validateLedgerCommand(contract, args[1:])
+	case "incomplete":
+		return incompleteCommand(contract, args[1:])
+	case "created-between":
+		return createdBetweenCommand(contract, args[1:])
+	case "history-between":
+		return historyBetweenCommand(contract, args[1:])
+	case "export-ndjson":
+		return exportNDJSONCommand(contract, args[1:])
+	case "import-ndjson":
+		return importNDJSONCommand(contract, args[1:])
+	case "list":
+		return listCommand(contract, args[1:])
+	case "sample":
+		return sampleCommand(contract, args[1:])
+	case "plan":
+		return planCommand(contract, args[1:])
+	case "context-info":
+		return contextInfoCommand(config, network, contract, args[1:])
+	case "height":
+		return heightCommand(network, args[1:])
+	case "state-digest":
+		return stateDigestCommand(contract, args[1:])
+	case "verify":
+		return verifyCommand(contract, args[1:])
+	case "wait-for":
+		return waitForCommand(contract, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// runOfflineCommand executes the commands that need neither credentials nor a gateway connection. It reports false
+// when args do not name such a command.
+func runOfflineCommand(config *Config, args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+	switch args[0] {
+	case "check-config":
+		return true, checkConfigCommand(config, args[1:])
+	case "config":
+		return true, configCommand(config, args[1:])
+	case "gen-profile":
+		return true, genProfileCommand(config, args[1:])
+	case "tls-check":
+		return true, tlsCheckCommand(config, args[1:])
+	default:
+		return false, nil
+	}
+}
+
+// rawCommand evaluates, or with -submit submits, an arbitrary transaction: raw [-submit] <name> [args...]
+func rawCommand(contract *client.Contract, args []string) error {
+	flags := flag.NewFlagSet("raw", flag.ContinueOnError)
+	submit := flags.Bool("submit", false, "submit the transaction to the ledger instead of evaluating it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("usage: raw [-submit] <transaction> [args...]")
+	}
+
+	return invokeRaw(contract, *submit, flags.Arg(0), flags.Args()[1:])
+}
+
+// bulkCreateCommand creates all persons of a JSON file in one atomic transaction once the operator has confirmed the
+// batch digest: bulk-create <file>
+func bulkCreateCommand(contract *client.Contract, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: bulk-create <file>")
+	}
+
+	personsJSON, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read persons file: %w", err)
+	}
+	var persons []Person
+	if err := json.Unmarshal(personsJSON, &persons); err != nil {
+		return fmt.Errorf("failed to parse persons file: %w", err)
+	}
+	if err := validatePersons(persons); err != nil {
+		return err
+	}
+	confirmed, err := confirmDigest(batchDigest(persons))
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fmt.Println("Cancelled")
+		return nil
+	}
+	if fieldCipher != nil {
+		if err := encryptAddresses(persons); err != nil {
+			return err
+		}
+		if personsJSON, err = json.Marshal(persons); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Submit Transaction: CreatePersonsBulk, creating %d persons with a %s endorsement timeout\n", len(persons), *bulkEndorseTimeout)
+	if *asyncSubmit {
+		result, commit, err := submitAsyncWithEndorseTimeout(contract, *bulkEndorseTimeout, "CreatePersonsBulk", string(personsJSON))
+		if err != nil {
+			return fmt.Errorf("failed to submit transaction: %w", err)
+		}
+		trackCommit(fmt.Sprintf("bulk-create of %s persons", result), commit)
+		return nil
+	}
+
+	result, err := submitWithEndorseTimeout(contract, *bulkEndorseTimeout, "CreatePersonsBulk", string(personsJSON))
+	if err != nil {
+		return fmt.Errorf("failed to submit transaction: %w", err)
+	}
+
+	fmt.Printf("*** Transaction %s, %s persons created\n", result, result.Result)
+	return nil
+}
+
+// bulkResult is the outcome of UpdatePersonsBulk.
+type bulkResult struct {
+	Updated []string `json:"updated"`
+	Failed  []struct {
+		ID    string `json:"id"`
+		Error string `json:"error"`
+	} `json:"failed"`
+}
+
+// bulkUpdateCommand applies the updates of a JSON file of persons in one transaction that writes the valid ones and
+// skips the others, then lists what was skipped and why: bulk-update <file>
+func bulkUpdateCommand(contract *client.Contract, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: bulk-update <file>")
+	}
+
+	personsJSON, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read persons file: %w", err)
+	}
+	var persons []Person
+	if err := json.Unmarshal(personsJSON, &persons); err != nil {
+		return fmt.Errorf("failed to parse persons file: %w", err)
+	}
+	confirmed, err := confirmDigest(batchDigest(persons))
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fmt.Println("Cancelled")
+		return nil
+	}
+	if fieldCipher != nil {
+		if err := encryptAddresses(persons); err != nil {
+			return err
+		}
+		if personsJSON, err = json.Marshal(persons); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Submit Transaction: UpdatePersonsBulk, updating %d persons with a %s endorsement timeout\n", len(persons), *bulkEndorseTimeout)
+	submitted, err := submitWithEndorseTimeout(contract, *bulkEndorseTimeout, "UpdatePersonsBulk", string(personsJSON))
+	if err != nil {
+		return fmt.Errorf("failed to submit transaction: %w", err)
+	}
+	var result bulkResult
+	if err := json.Unmarshal(submitted.Result, &result); err != nil {
+		return fmt.Errorf("failed to parse result: %w", err)
+	}
+
+	fmt.Printf("*** Transaction %s, %d persons updated\n", submitted, len(result.Updated))
+	if len(result.Failed) == 0 {
+		return nil
+	}
+	fmt.Printf("%d updates were skipped:\n", len(result.Failed))
+	for _, failure := range result.Failed {
+		fmt.Printf("  %s: %s\n", failure.ID, failure.Error)
+	}
+	return fmt.Errorf("%d of %d updates were skipped", len(result.Failed), len(persons))
+}
+
+// validatePersons checks every person of a batch with the chaincode rules and prints all problems of all persons, so a
+// file can be fixed in one pass instead of one rejected submit at a time.
+func validatePersons(persons []Person) error {
+	invalid := 0
+	for i, person := range persons {
+		normalizePerson(&person)
+		if err := validation.PersonAll(validationFields(person)); err != nil {
+			invalid++
+			fmt.Printf("person %d (%s): %s\n", i, person.ID, err)
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d persons are invalid", invalid, len(persons))
+	}
+	return nil
+}
+
+// bulkDeleteCommand deletes the given persons in one atomic transaction after a typed confirmation, unless -yes is set:
+// bulk-delete [-skip-missing] [-file ids.json] [id...]
+func bulkDeleteCommand(contract *client.Contract, args []string) error {
+	flags := flag.NewFlagSet("bulk-delete", flag.ContinueOnError)
+	skipMissing := flags.Bool("skip-missing", false, "ignore ids that do not exist instead of failing the whole batch")
+	file := flags.String("file", "", "JSON file holding an array of ids to delete, in addition to those given as arguments")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	ids := flags.Args()
+	if *file != "" {
+		idsJSON, err := ioutil.ReadFile(*file)
+		if err != nil {
+			return fmt.Errorf("failed to read ids file: %w", err)
+		}
+		var fileIds []string
+		if err := json.Unmarshal(idsJSON, &fileIds); err != nil {
+			return fmt.Errorf("failed to parse ids file: %w", err)
+		}
+		ids = append(ids, fileIds...)
+	}
+	if len(ids) == 0 {
+		return errors.New("usage: bulk-delete [-skip-missing] [-file ids.json] [id...]")
+	}
+
+	// deleting is irreversible for the current state, so a plain yes is not enough
+	fmt.Printf("About to delete %d persons: %s\n", len(ids), strings.Join(ids, ", "))
+	expected := fmt.Sprintf("delete %d", len(ids))
+	if !*assumeYes {
+		answer, err := promptLine(fmt.Sprintf("Type %q to confirm: ", expected))
+		if err != nil {
+			return err
+		}
+		if answer != expected {
+			fmt.Println("Cancelled")
+			return nil
+		}
+	}
+
+	idsJSON, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Submit Transaction: DeletePersonsBulk, deleting %d persons with a %s endorsement timeout\n", len(ids), *bulkEndorseTimeout)
+	result, err := submitWithEndorseTimeout(contract, *bulkEndorseTimeout, "DeletePersonsBulk", string(idsJSON), strconv.FormatBool(*skipMissing))
+	if err != nil {
+		return fmt.Errorf("failed to submit transaction: %w", err)
+	}
+
+	fmt.Printf("*** Transaction %s, %s persons deleted\n", result, result.Result)
+	return nil
+}
+
+// byPhoneCommand lists the persons with the given phone number: by-phone <phone>
+func byPhoneCommand(contract *client.Contract, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: by-phone <phone>")
+	}
+
+	result, err := evaluateTransaction(contract, "ReadPersonByPhone", args[0])
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+
+	fmt.Println(formatJSON(result))
+	return nil
+}
+
+// linkSpousesCommand records two married persons as each other's spouse: link-spouses <id> <id>
+func linkSpousesCommand(contract *client.Contract, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: link-spouses <id> <id>")
+	}
+
+	submitted, err := submitTransaction(contract, "CreateSpouseLink", args[0], args[1])
+	if err != nil {
+		return fmt.Errorf("failed to submit transaction: %w", err)
+	}
+
+	fmt.Printf("*** Transaction %s, %s and %s linked\n", submitted, args[0], args[1])
+	return nil
+}
+
+// unlinkSpouseCommand removes the link between a person and their spouse: unlink-spouse <id>
+func unlinkSpouseCommand(contract *client.Contract, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: unlink-spouse <id>")
+	}
+
+	submitted, err := submitTransaction(contract, "UnlinkSpouse", args[0])
+	if err != nil {
+		return fmt.Errorf("failed to submit transaction: %w", err)
+	}
+
+	fmt.Printf("*** Transaction %s, %s unlinked\n", submitted, args[0])
+	return nil
+}
+
+// marryCommand marks two persons as married to each other: marry <id> <id>
+func marryCommand(contract *client.Contract, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: marry <id> <id>")
+	}
+
+	submitted, err := submitTransaction(contract, "MarryPersons", args[0], args[1])
+	if err != nil {
+		return fmt.Errorf("failed to submit transaction: %w", err)
+	}
+
+	fmt.Printf("*** Transaction %s, %s and %s married\n", submitted, args[0], args[1])
+	return nil
+}
+
+// divorceCommand marks two persons married to each other as unmarried: divorce <id> <id>
+func divorceCommand(contract *client.Contract, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: divorce <id> <id>")
+	}
+
+	submitted, err := submitTransaction(contract, "DivorcePersons", args[0], args[1])
+	if err != nil {
+		return fmt.Errorf("failed to submit transaction: %w", err)
+	}
+
+	fmt.Printf("*** Transaction %s, %s and %s divorced\n", submitted, args[0], args[1])
+	return nil
+}
+
+// archiveCityCommand moves every person living in a city to the archive: archive-city <city>
+func archiveCityCommand(contract *client.Contract, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: archive-city <city>")
+	}
+	confirmed, err := confirmUnlessAssumed(fmt.Sprintf("Archive every person living in %s?", args[0]))
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fmt.Println("Cancelled")
+		return nil
+	}
+
+	submitted, err := submitTransaction(contract, "ArchivePersonsByCity", args[0])
+	if err != nil {
+		return fmt.Errorf("failed to submit transaction: %w", err)
+	}
+
+	fmt.Printf("*** Transaction %s, %s persons archived\n", submitted, submitted.Result)
+	return nil
+}
+
+// archivedCommand prints an archived person: archived <id>
+func archivedCommand(contract *client.Contract, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: archived <id>")
+	}
+
+	result, err := evaluateTransaction(contract, "GetArchivedPerson", args[0])
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+
+	fmt.Println(formatJSON(result))
+	return nil
+}
+
+// restoreArchivedCommand moves an archived person back to the active persons: restore-archived <id>
+func restoreArchivedCommand(contract *client.Contract, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: restore-archived <id>")
+	}
+
+	submitted, err := submitTransaction(contract, "RestoreArchivedPerson", args[0])
+	if err != nil {
+		return fmt.Errorf("failed to submit transaction: %w", err)
+	}
+
+	fmt.Printf("*** Transaction %s, %s restored\n", submitted, args[0])
+	return nil
+}
+
+// redactedCommand prints a person with only the given fields, for sharing it with parties that need no more:
+// redacted <id> [field,field...]. Without fields only the id and name are shown.
+func redactedCommand(contract *client.Contract, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New("usage: redacted <id> [field,field...]")
+	}
+	fields := ""
+	if len(args) == 2 {
+		fields = args[1]
+	}
+
+	result, err := evaluateTransaction(contract, "ReadPersonRedacted", args[0], fields)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+
+	fmt.Println(formatJSON(result))
+	return nil
+}
+
+// byReferenceCommand lists the persons carrying the given external reference: by-reference <reference>
+func byReferenceCommand(contract *client.Contract, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: by-reference <reference>")
+	}
+
+	result, err := evaluateTransaction(contract, "GetPersonsByReference", args[0])
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+
+	fmt.Println(formatJSON(result))
+	return nil
+}
+
+// getManyCommand prints the given persons, read in a single call, and lists the ids that do not exist:
+// get-many <id>...
+func getManyCommand(contract *client.Contract, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: get-many <id>...")
+	}
+	idsJSON, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+
+	result, err := evaluateTransaction(contract, "GetPersonsByIDs", string(idsJSON))
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+	var found struct {
+		Persons []Person `json:"persons"`
+		Missing []string `json:"missing"`
+	}
+	if err := json.Unmarshal(result, &found); err != nil {
+		return fmt.Errorf("failed to parse result: %w", err)
+	}
+
+	fmt.Println(formatValue(found.Persons))
+	if len(found.Missing) > 0 {
+		fmt.Printf("Not found: %s\n", strings.Join(found.Missing, ", "))
+	}
+	return nil
+}
+
+// searchCommand lists the persons whose name or surname contains the given text, ignoring case: search <text>
+func searchCommand(contract *client.Contract, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: search <text>")
+	}
+
+	result, err := evaluateTransaction(contract, "SearchPersonsByName", strings.Join(args, " "))
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+
+	fmt.Println(formatJSON(result))
+	return nil
+}
+
+// byCityCommand lists the persons living in the given city: by-city [-index] <city>
+func byCityCommand(contract *client.Contract, args []string) error {
+	flags := flag.NewFlagSet("by-city", flag.ContinueOnError)
+	useIndex := flags.Bool("index", false, "look the city up through the composite-key index, for peers on LevelDB")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: by-city [-index] <city>")
+	}
+
+	transaction := "QueryPersonsByCity"
+	if *useIndex {
+		transaction = "GetPersonsByCityIndex"
+	}
+	result, err := evaluateTransaction(contract, transaction, flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+
+	fmt.Println(formatJSON(result))
+	return nil
+}
+
+// byGenderCommand lists the persons of the given gender: by-gender <M|F|X>
+func byGenderCommand(contract *client.Contract, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: by-gender <%s>", strings.Join(validation.Genders, "|"))
+	}
+
+	result, err := evaluateTransaction(contract, "GetPersonsByGender", args[0])
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+
+	fmt.Println(formatJSON(result))
+	return nil
+}
+
+// ageCommand prints the age in years of a person with a recorded birthdate: age <id>
+func ageCommand(contract *client.Contract, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: age <id>")
+	}
+
+	result, err := evaluateTransaction(contract, "GetPersonAge", args[0])
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+
+	fmt.Printf("Person %s is %s years old\n", args[0], string(result))
+	return nil
+}
+
+// sampleCommand prints the first persons of the ledger: sample [-limit n]
+func sampleCommand(contract *client.Contract, args []string) error {
+	flags := flag.NewFlagSet("sample", flag.ContinueOnError)
+	limit := flags.Int("limit", 20, "maximum number of persons to print")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: sample [-limit n]")
+	}
+	if *limit <= 0 {
+		return fmt.Errorf("limit must be positive, got %d", *limit)
+	}
+
+	result, err := evaluateTransaction(contract, "GetPersonsLimited", strconv.Itoa(*limit))
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+
+	fmt.Println(formatJSON(result))
+	return nil
+}
+
+// expiredCommand lists the persons whose passport expired before the given date, or before now: expired [date]
+func expiredCommand(contract *client.Contract, args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: expired [YYYY-MM-DD|RFC3339]")
+	}
+	asOf := ""
+	if len(args) == 1 {
+		asOf = args[0]
+	}
+
+	result, err := evaluateTransaction(contract, "GetExpiredPersons", asOf)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+
+	fmt.Println(formatJSON(result))
+	return nil
+}
+
+// provisionalCommand makes a person provisional until the given time, after which listings and queries leave it out:
+// provisional <id> <YYYY-MM-DD|RFC3339>. The time "none" makes the person permanent again.
+func provisionalCommand(contract *client.Contract, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: provisional <id> <YYYY-MM-DD|RFC3339|none>")
+	}
+	expiresAt := args[1]
+	if expiresAt == "none" {
+		expiresAt = ""
+	}
+
+	submitted, err := submitTransaction(contract, "SetPersonExpiresAt", args[0], expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to submit transaction: %w", err)
+	}
+
+	if expiresAt == "" {
+		fmt.Printf("*** Transaction %s, %s is permanent\n", submitted, args[0])
+	} else {
+		fmt.Printf("*** Transaction %s, %s is provisional until %s\n", submitted, args[0], expiresAt)
+	}
+	return nil
+}
+
+// expiredProvisionalCommand lists the provisional persons past their expiry: expired-provisional
+func expiredProvisionalCommand(contract *client.Contract, args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: expired-provisional")
+	}
+
+	result, err := evaluateTransaction(contract, "GetExpiredProvisionalPersons")
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+
+	fmt.Println(formatJSON(result))
+	return nil
+}
+
+// purgeExpiredCommand deletes the provisional persons past their expiry after a confirmation, which requires an
+// administrator identity: purge-expired
+func purgeExpiredCommand(contract *client.Contract, args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: purge-expired")
+	}
+	confirmed, err := confirmUnlessAssumed("Delete every expired provisional person? They cannot be restored")
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fmt.Println("Cancelled")
+		return nil
+	}
+
+	submitted, err := submitTransaction(contract, "PurgeExpired")
+	if err != nil {
+		return fmt.Errorf("failed to submit transaction: %w", err)
+	}
+
+	fmt.Printf("*** Transaction %s, %s persons purged\n", submitted, submitted.Result)
+	return nil
+}
+
+// orphanedAuditsCommand lists the audit records of persons that no longer exist and, with -prune, deletes them after a
+// confirmation: orphaned-audits [-prune]
+func orphanedAuditsCommand(contract *client.Contract, args []string) error {
+	flags := flag.NewFlagSet("orphaned-audits", flag.ContinueOnError)
+	prune := flags.Bool("prune", false, "delete the orphaned audit records, which requires an administrator identity")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: orphaned-audits [-prune]")
+	}
+
+	result, err := evaluateTransaction(contract, "FindOrphanedAudits")
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+	var ids []string
+	if err := json.Unmarshal(result, &ids); err != nil {
+		return fmt.Errorf("failed to parse result: %w", err)
+	}
+
+	if len(ids) == 0 {
+		fmt.Println("No orphaned audit records")
+		return nil
+	}
+	fmt.Printf("%d audit records refer to deleted persons: %s\n", len(ids), strings.Join(ids, ", "))
+	if !*prune {
+		return nil
+	}
+
+	confirmed, err := confirmUnlessAssumed("Delete these audit records? They cannot be restored")
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fmt.Println("Cancelled")
+		return nil
+	}
+	submitted, err := submitTransaction(contract, "PruneOrphanedAudits")
+	if err != nil {
+		return fmt.Errorf("failed to submit transaction: %w", err)
+	}
+
+	fmt.Printf("*** Transaction %s, %s audit records deleted\n", submitted, submitted.Result)
+	return nil
+}
+
+// incompleteCommand lists the persons lacking required fields, with the fields each one is missing: incomplete
+func incompleteCommand(contract *client.Contract, args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: incomplete")
+	}
+
+	result, err := evaluateTransaction(contract, "FindIncompletePersons")
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+	var persons []Person
+	if err := json.Unmarshal(result, &persons); err != nil {
+		return fmt.Errorf("failed to parse result: %w", err)
+	}
+
+	if len(persons) == 0 {
+		fmt.Println("Every person has all required fields")
+		return nil
+	}
+	fmt.Printf("%d persons lack required fields:\n", len(persons))
+	for _, person := range persons {
+		missing := validation.MissingFields(validationFields(person))
+		fmt.Printf("  %s: %s\n", person.ID, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// validationIssue is a problem a stored person has under the current validation rules, as ValidateAllPersons
+// reports it.
+type validationIssue struct {
+	ID      string `json:"id"`
+	Field   string `json:"field"`
+	Problem string `json:"problem"`
+}
+
+// validateLedgerCommand checks every stored person against the current validation rules and prints the failing
+// records grouped by problem: validate-ledger
+func validateLedgerCommand(contract *client.Contract, args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: validate-ledger")
+	}
+
+	result, err := evaluateTransaction(contract, "ValidateAllPersons")
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+	var issues []validationIssue
+	if err := json.Unmarshal(result, &issues); err != nil {
+		return fmt.Errorf("failed to parse result: %w", err)
+	}
+
+	if len(issues) == 0 {
+		fmt.Println("Every person passes the current validation rules")
+		return nil
+	}
+
+	// problems are listed in the order they are first met, the ids of each in ledger order
+	var problems []string
+	idsByProblem := make(map[string][]string)
+	for _, issue := range issues {
+		if _, ok := idsByProblem[issue.Problem]; !ok {
+			problems = append(problems, issue.Problem)
+		}
+		idsByProblem[issue.Problem] = append(idsByProblem[issue.Problem], issue.ID)
+	}
+
+	fmt.Printf("%d problems found:\n", len(issues))
+	for _, problem := range problems {
+		ids := idsByProblem[problem]
+		fmt.Printf("  %s (%d): %s\n", problem, len(ids), strings.Join(ids, ", "))
+	}
+	return nil
+}
+
+// createdBetweenCommand lists the persons created in a time window: created-between <start> <end>. A plain end date
+// includes that whole day in UTC.
+func createdBetweenCommand(contract *client.Contract, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: created-between <YYYY-MM-DD|RFC3339> <YYYY-MM-DD|RFC3339>")
+	}
+
+	start, err := parseRangeTime(args[0], false)
+	if err != nil {
+		return err
+	}
+	end, err := parseRangeTime(args[1], true)
+	if err != nil {
+		return err
+	}
+	if !start.Before(end) {
+		return fmt.Errorf("start %s must be before end %s", args[0], args[1])
+	}
+
+	result, err := evaluateTransaction(contract, "GetPersonsCreatedBetween", start.Format(time.RFC3339), end.Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+
+	fmt.Println(formatJSON(result))
+	return nil
+}
+
+// historyBetweenCommand prints the updates of a person made in a time window, deletions included:
+// history-between <id> <from> <to>. A plain to date includes that whole day in UTC.
+func historyBetweenCommand(contract *client.Contract, args []string) error {
+	if len(args) != 3 {
+		return errors.New("usage: history-between <id> <YYYY-MM-DD|RFC3339> <YYYY-MM-DD|RFC3339>")
+	}
+
+	from, err := parseRangeTime(args[1], false)
+	if err != nil {
+		return err
+	}
+	end, err := parseRangeTime(args[2], true)
+	if err != nil {
+		return err
+	}
+	// the chaincode range is inclusive, so a whole day ends just before the following one starts
+	to := end
+	if _, err := time.Parse(time.RFC3339, args[2]); err != nil {
+		to = end.Add(-time.Nanosecond)
+	}
+	if to.Before(from) {
+		return fmt.Errorf("from %s must not be after to %s", args[1], args[2])
+	}
+
+	result, err := evaluateTransaction(contract, "GetPersonHistoryBetween", args[0], from.Format(time.RFC3339Nano), to.Format(time.RFC3339Nano))
+	if err != nil {
+		if code, _ := chaincodeError(err); code == errCodeHistoryUnavailable {
+			return errors.New("history is not enabled on this network, ask the operator to enable the peer history database")
+		}
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+
+	var updates []Update
+	if err := json.Unmarshal(result, &updates); err != nil {
+		return fmt.Errorf("failed to parse history of person %s: %w", args[0], err)
+	}
+	if len(updates) == 0 {
+		fmt.Printf("No updates of person %s in this range\n", args[0])
+		return nil
+	}
+	printUpdates(updates)
+	return nil
+}
+
+// parseRangeTime accepts an RFC3339 timestamp or a plain date, which stands for the start of that day in UTC, or the
+// start of the following day when it closes a range.
+func parseRangeTime(value string, rangeEnd bool) (time.Time, error) {
+	at, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return at, nil
+	}
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD or RFC3339", value)
+	}
+	if rangeEnd {
+		day = day.Add(24 * time.Hour)
+	}
+	return day, nil
+}
+
+// serveTokenEnv holds the bearer token the /persons endpoints of serve require, kept out of the command line where
+// other users could read it.
+const serveTokenEnv = "PASSPORT_SERVE_TOKEN"
+
+// serveCommand runs the client as an HTTP daemon until interrupted, serving the health probes and the /persons REST
+// endpoints: serve [-listen addr]
+//
+// The endpoints submit transactions with the identity of the client, so they are served on the loopback interface
+// only, unless $PASSPORT_SERVE_TOKEN sets a token every request to them must carry.
+func serveCommand(contract *client.Contract, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	listen := flags.String("listen", "127.0.0.1:8080", "address to serve HTTP requests on, a non-loopback one requires $"+serveTokenEnv)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	token := os.Getenv(serveTokenEnv)
+	if token == "" && !isLoopbackAddress(*listen) {
+		return fmt.Errorf("refusing to serve the /persons endpoints on %s without authentication, set $%s or listen on a loopback address", *listen, serveTokenEnv)
+	}
+
+	evaluate := func(name string, args ...string) ([]byte, error) {
+		return evaluateTransaction(contract, name, args...)
+	}
+	httpServer := server.New(*listen, token, evaluate, restPersons{contract: contract}, chaincodeError)
+
+	// an interrupt releases the lifecycle, which lets in-flight requests finish before ListenAndServe returns
+	appLifecycle.AddFunc("HTTP server", func() error {
+		log.Println("Shutting down HTTP server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return httpServer.Shutdown(shutdownCtx)
+	})
+
+	log.Printf("Serving HTTP on %s", *listen)
+	return httpServer.ListenAndServe()
+}
+
+// isLoopbackAddress reports whether a listen address only accepts connections from the local host. An empty host
+// listens on every interface.
+func isLoopbackAddress(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// invokeRawInteractive prompts for a transaction name, its arguments and the invocation mode.
+func invokeRawInteractive(contract *client.Contract) error {
+	name, err := promptWord("Transaction name: ")
+	if err != nil {
+		return err
+	}
+	if len(name) == 0 {
+		fmt.Println("transaction name is required!")
+		return nil
+	}
+
+	line, err := promptLine("Arguments (space separated, or a JSON array of strings): ")
+	if err != nil {
+		return err
+	}
+	args, err := parseRawArgs(line)
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
+
+	mode, err := promptWord("Submit or evaluate? [s/e]: ")
+	if err != nil {
+		return err
+	}
+	mode = strings.ToLower(mode)
+
+	if err := invokeRaw(contract, mode == "s" || mode == "submit", name, args); err != nil {
+		if isSessionEnd(err) {
+			return err
+		}
+		fmt.Println(err)
+	}
+	return nil
+}
+
+// parseRawArgs splits an argument line on whitespace, or decodes it as a JSON array when arguments contain spaces.
+func parseRawArgs(line string) ([]string, error) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "[") {
+		return strings.Fields(line), nil
+	}
+
+	var args []string
+	if err := json.Unmarshal([]byte(line), &args); err != nil {
+		return nil, fmt.Errorf("arguments must be a JSON array of strings: %w", err)
+	}
+	return args, nil
+}
+
+func invokeRaw(contract *client.Contract, submit bool, name string, args []string) error {
+	var result []byte
+	var err error
+	if submit {
+		var confirmed bool
+		confirmed, err = confirm(fmt.Sprintf("Submit %s with arguments %q to the ledger?", name, args))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("Cancelled")
+			return nil
+		}
+		fmt.Printf("Submit Transaction: %s\n", name)
+		var submitted *SubmitResult
+		submitted, err = submitTransaction(contract, name, args...)
+		if err == nil {
+			fmt.Printf("*** Transaction %s\n", submitted)
+			result = submitted.Result
+		}
+	} else {
+		fmt.Printf("Evaluate Transaction: %s\n", name)
+		result, err = evaluateTransaction(contract, name, args...)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to invoke transaction %s: %w", name, err)
+	}
+
+	switch {
+	case len(result) == 0:
+		fmt.Println("*** Empty result")
+	case json.Valid(result):
+		fmt.Printf("*** Result:%s\n", formatJSON(result))
+	default:
+		fmt.Printf("*** Result:%s\n", result)
+	}
+	return nil
+}
+
+// confirm asks a yes/no question and reports whether the operator answered yes.
+func confirm(question string) (bool, error) {
+	answer, err := promptWord(question + " (yes/no): ")
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
+
+// confirmUnlessAssumed asks a yes/no question like confirm, unless -yes answers it beforehand.
+func confirmUnlessAssumed(question string) (bool, error) {
+	if *assumeYes {
+		return true, nil
+	}
+	return confirm(question)
+}
@@ -0,0 +1,28 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestInvokeRawReportsFailedSubmit(t *testing.T) {
+	useSubmitMiddleware(t)
+	saved := stdin
+	defer func() { stdin = saved }()
+	stdin = newLineReader(strings.NewReader("yes\n"), time.Second)
+	contract := newFakeContract(t, &fakeGateway{submitErr: status.Error(codes.Unavailable, "failed to send transaction to orderer")})
+
+	err := invokeRaw(contract, true, "DeletePerson", []string{"person1"})
+
+	if err == nil || !strings.Contains(err.Error(), "failed to invoke transaction DeletePerson") {
+		t.Errorf("got %v, want the failed submit reported", err)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// personField is a named, printable attribute of a person.
+type personField struct {
+	Name  string
+	Value string
+}
+
+// fieldDiff is an attribute whose value differs between two persons.
+type fieldDiff struct {
+	Field string
+	Left  string
+	Right string
+}
+
+// personFields lists the attributes of a person in display order.
+func personFields(p Person) []personField {
+	return []personField{
+		{"id", p.ID},
+		{"serial", p.Serial},
+		{"name", p.Name},
+		{"surname", p.Surname},
+		{"city", p.City},
+		{"address", p.Address},
+		{"phone", p.Phone},
+		{"married", strconv.FormatBool(p.Married)},
+		{"expiry", p.Expiry},
+		{"tags", strings.Join(p.Tags, ",")},
+		{"reference", p.Reference},
+		{"spouse", p.SpouseID},
+		{"gender", p.Gender},
+		{"expires at", p.ExpiresAt},
+		{"birthdate", p.Birthdate},
+	}
+}
+
+// diffPersons returns the attributes whose values differ between left and right.
+func diffPersons(left Person, right Person) []fieldDiff {
+	leftFields := personFields(left)
+	rightFields := personFields(right)
+
+	var diffs []fieldDiff
+	for i := range leftFields {
+		if leftFields[i].Value != rightFields[i].Value {
+			diffs = append(diffs, fieldDiff{Field: leftFields[i].Name, Left: leftFields[i].Value, Right: rightFields[i].Value})
+		}
+	}
+	return diffs
+}
+
+// comparePersons reads two persons and prints their attributes side by side, marking the ones that differ.
+func comparePersons(contract *client.Contract, leftId string, rightId string) {
+	left, ok := fetchPerson(contract, leftId)
+	if !ok {
+		return
+	}
+	right, ok := fetchPerson(contract, rightId)
+	if !ok {
+		return
+	}
+
+	differing := make(map[string]bool)
+	for _, diff := range diffPersons(left, right) {
+		differing[diff.Field] = true
+	}
+	// the ids differ by definition, only the remaining attributes are interesting
+	delete(differing, "id")
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(writer, "\tfield\t%s\t%s\n", leftId, rightId)
+	rightFields := personFields(right)
+	for i, field := range personFields(left) {
+		marker := ""
+		if differing[field.Name] {
+			marker = "*"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", marker, field.Name, field.Value, rightFields[i].Value)
+	}
+	writer.Flush()
+
+	if len(differing) == 0 {
+		fmt.Println("Persons have identical fields")
+	} else {
+		fmt.Printf("%d field(s) differ, marked with *\n", len(differing))
+	}
+}
+
+// fetchPerson reads a person, printing a message and reporting false when it cannot be read.
+func fetchPerson(contract *client.Contract, personId string) (Person, bool) {
+	person, err := readPerson(contract, personId)
+	if err != nil {
+		fmt.Printf("Person %s could not be read\n", personId)
+		printGatewayError(err)
+		return Person{}, false
+	}
+	return *person, true
+}
@@ -0,0 +1,260 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	configPath  = flag.String("config", "", "YAML or JSON file holding the connection settings, such as the one gen-profile writes; settings it leaves out keep their defaults")
+	debugConfig = flag.Bool("debug", false, "log the resolved connection settings and their sources at startup")
+)
+
+// Config holds the settings needed to connect to the network. Its files are written by gen-profile and read with
+// -config. The TLS client certificate and key are only needed by networks requiring mutual TLS, and are either both
+// set or both left empty.
+type Config struct {
+	MSPID             string `json:"mspId" yaml:"mspId"`
+	CertPath          string `json:"certPath" yaml:"certPath"`
+	KeyPath           string `json:"keyPath" yaml:"keyPath"`
+	TLSCertPath       string `json:"tlsCertPath" yaml:"tlsCertPath"`
+	TLSClientCertPath string `json:"tlsClientCertPath,omitempty" yaml:"tlsClientCertPath,omitempty"`
+	TLSClientKeyPath  string `json:"tlsClientKeyPath,omitempty" yaml:"tlsClientKeyPath,omitempty"`
+	PeerEndpoint      string `json:"peerEndpoint" yaml:"peerEndpoint"`
+	GatewayPeer       string `json:"gatewayPeer" yaml:"gatewayPeer"`
+	Channel           string `json:"channel" yaml:"channel"`
+	Chaincode         string `json:"chaincode" yaml:"chaincode"`
+
+	// sources records, by setting name, the settings that do not come from their default and where they come from
+	// instead. It is filled as the settings are loaded.
+	sources map[string]string
+}
+
+// source returns where the connection setting with given name comes from.
+func (config *Config) source(name string) string {
+	if source, ok := config.sources[name]; ok {
+		return source
+	}
+	return sourceDefault
+}
+
+func (config *Config) setSource(name string, source string) {
+	if config.sources == nil {
+		config.sources = make(map[string]string)
+	}
+	config.sources[name] = source
+}
+
+// defaultCryptoPath is the crypto material of Org1 in a test network checked out next to this repository.
+const defaultCryptoPath = "../../../fabric-samples-mod/test-network/organizations/peerOrganizations/org1.example.com"
+
+// defaultConfig returns the settings used when no configuration file is given: User1 of Org1 of the test network.
+func defaultConfig() *Config {
+	return &Config{
+		MSPID:        "Org1MSP",
+		CertPath:     defaultCryptoPath + "/users/User1@org1.example.com/msp/signcerts/cert.pem",
+		KeyPath:      defaultCryptoPath + "/users/User1@org1.example.com/msp/keystore/",
+		TLSCertPath:  defaultCryptoPath + "/peers/peer0.org1.example.com/tls/ca.crt",
+		PeerEndpoint: "localhost:7051",
+		GatewayPeer:  "peer0.org1.example.com",
+		Channel:      "mychannel",
+		Chaincode:    "passport",
+	}
+}
+
+// loadConfig reads the configuration file at path, JSON when its name ends in .json and YAML otherwise. Settings the
+// file leaves out keep their defaults, unknown ones are an error so that a misspelled setting is not silently
+// ignored. An empty path gives the defaults.
+func loadConfig(path string) (*Config, error) {
+	config := defaultConfig()
+	if path == "" {
+		return config, nil
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	// the settings the file holds are collected too, so that one set to its default value still counts as set
+	var settings map[string]interface{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(config)
+		if err == nil {
+			err = json.Unmarshal(content, &settings)
+		}
+	} else {
+		err = yaml.UnmarshalStrict(content, config)
+		if err == nil {
+			err = yaml.Unmarshal(content, &settings)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	for _, field := range connectionFields(config) {
+		if _, ok := settings[field.key]; ok {
+			config.setSource(field.name, sourceFile)
+		}
+	}
+	return config, nil
+}
+
+// connectionField is a connection setting of Config, its key in configuration files and the environment variable
+// overriding it.
+type connectionField struct {
+	name   string
+	key    string
+	envVar string
+	value  *string
+}
+
+// connectionFields returns the connection settings of config, in the order config show lists them.
+func connectionFields(config *Config) []connectionField {
+	return []connectionField{
+		{"msp-id", "mspId", "FABRIC_MSP_ID", &config.MSPID},
+		{"peer-endpoint", "peerEndpoint", "FABRIC_PEER_ENDPOINT", &config.PeerEndpoint},
+		{"gateway-peer", "gatewayPeer", "FABRIC_GATEWAY_PEER", &config.GatewayPeer},
+		{"channel", "channel", "FABRIC_CHANNEL", &config.Channel},
+		{"chaincode", "chaincode", "FABRIC_CHAINCODE", &config.Chaincode},
+		{"cert", "certPath", "FABRIC_CERT_PATH", &config.CertPath},
+		{"key", "keyPath", "FABRIC_KEY_PATH", &config.KeyPath},
+		{"tls-ca-cert", "tlsCertPath", "FABRIC_TLS_CERT_PATH", &config.TLSCertPath},
+		{"tls-client-cert", "tlsClientCertPath", "FABRIC_TLS_CLIENT_CERT_PATH", &config.TLSClientCertPath},
+		{"tls-client-key", "tlsClientKeyPath", "FABRIC_TLS_CLIENT_KEY_PATH", &config.TLSClientKeyPath},
+	}
+}
+
+// credentialPEMEnvVars maps the settings locating a credential to the environment variable that may hold the
+// credential itself, which takes precedence over any path.
+var credentialPEMEnvVars = map[string]string{
+	"cert":        certPEMEnv,
+	"key":         keyPEMEnv,
+	"tls-ca-cert": tlsCAPEMEnv,
+}
+
+// resolveConfig resolves every connection setting, from the first of: its FABRIC_* environment variable when set to
+// a non-empty value, the configuration file at path, the built-in default.
+func resolveConfig(path string) (*Config, error) {
+	config, err := loadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range connectionFields(config) {
+		if value := os.Getenv(field.envVar); value != "" {
+			*field.value = value
+			config.setSource(field.name, sourceEnv)
+		}
+		if pemEnvVar, ok := credentialPEMEnvVars[field.name]; ok && isEnvSet(pemEnvVar) {
+			config.setSource(field.name, sourceEnv)
+		}
+	}
+	return config, nil
+}
+
+// logConfig logs the resolved connection settings and their sources.
+func logConfig(config *Config) {
+	for _, setting := range connectionSettings(config) {
+		log.Printf("config: %s = %s (%s)", setting.Name, setting.Value, setting.Source)
+	}
+}
+
+// Sources a configuration value can come from.
+const (
+	sourceDefault = "default"
+	sourceFile    = "file"
+	sourceEnv     = "env"
+	sourceFlag    = "flag"
+)
+
+// configSetting is one resolved configuration value and where it came from.
+type configSetting struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// effectiveConfig resolves every setting of the client: the connection settings, then the command line flags.
+func effectiveConfig(config *Config) []configSetting {
+	settings := connectionSettings(config)
+
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	flag.VisitAll(func(f *flag.Flag) {
+		source := sourceDefault
+		if set[f.Name] {
+			source = sourceFlag
+		}
+		settings = append(settings, configSetting{Name: f.Name, Value: f.Value.String(), Source: source})
+	})
+
+	return settings
+}
+
+// connectionSettings describes the resolved connection settings with the sources resolveConfig recorded for them.
+// Credentials are shown by location only: a value held in an environment variable is named, never printed.
+func connectionSettings(config *Config) []configSetting {
+	var settings []configSetting
+	for _, field := range connectionFields(config) {
+		setting := configSetting{Name: field.name, Value: *field.value, Source: config.source(field.name)}
+		if pemEnvVar, ok := credentialPEMEnvVars[field.name]; ok && isEnvSet(pemEnvVar) {
+			setting.Value = "$" + pemEnvVar + " (contents redacted)"
+		}
+		settings = append(settings, setting)
+	}
+	return settings
+}
+
+func isEnvSet(envVar string) bool {
+	_, ok := os.LookupEnv(envVar)
+	return ok
+}
+
+// configCommand prints the effective configuration and the source of each value: config show [-json]
+func configCommand(config *Config, args []string) error {
+	if len(args) == 0 || args[0] != "show" {
+		return errors.New("usage: config show [-json]")
+	}
+
+	flags := flag.NewFlagSet("config show", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the configuration as JSON")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	settings := effectiveConfig(config)
+	if *asJSON {
+		settingsJSON, err := json.Marshal(settings)
+		if err != nil {
+			return err
+		}
+		fmt.Println(formatJSON(settingsJSON))
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "setting\tsource\tvalue")
+	for _, setting := range settings {
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", setting.Name, setting.Source, setting.Value)
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,117 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+var mvccRetries = flag.Int("mvcc-retries", 3, "number of times an update failing with an MVCC read conflict is applied again to the current person")
+
+// isMVCCConflict reports whether a submit failed to commit because another transaction committed in between changed
+// the state it had read.
+func isMVCCConflict(err error) bool {
+	var failedCommitErr *failedCommitError
+	if errors.As(err, &failedCommitErr) {
+		return failedCommitErr.Code == peer.TxValidationCode_MVCC_READ_CONFLICT
+	}
+	var commitErr *client.CommitError
+	if errors.As(err, &commitErr) {
+		return commitErr.Code == peer.TxValidationCode_MVCC_READ_CONFLICT
+	}
+	return false
+}
+
+// updatePersonWithRetry applies change to the given person and submits the result with UpdatePersonWithDetails. When the
+// update fails to commit with an MVCC read conflict, the person is read again, change is applied to what is now stored
+// and the update resubmitted, up to -mvcc-retries times. change must therefore only set the attributes it means to
+// update, so that concurrent changes to the others survive.
+func updatePersonWithRetry(contract *client.Contract, person *VersionedPerson, change func(*Person)) (*SubmitResult, error) {
+	submit := func(etag string, p *Person) (*SubmitResult, error) {
+		return submitPersonUpdate(contract, etag, p)
+	}
+	read := func(id string) (*VersionedPerson, error) {
+		return readVersionedPerson(contract, id)
+	}
+	return retryPersonUpdate(person, change, submit, read)
+}
+
+// retryPersonUpdate does the work of updatePersonWithRetry with the given functions submitting an update and reading
+// the person again.
+func retryPersonUpdate(person *VersionedPerson, change func(*Person),
+	submit func(etag string, p *Person) (*SubmitResult, error),
+	read func(id string) (*VersionedPerson, error)) (*SubmitResult, error) {
+	for attempt := 0; ; attempt++ {
+		updated := person.Person
+		change(&updated)
+
+		result, err := submit(person.ETag, &updated)
+		if err == nil || attempt == *mvccRetries || !isMVCCConflict(err) {
+			return result, err
+		}
+
+		fmt.Printf("Person %s was changed concurrently, applying the update again\n", person.ID)
+		person, err = read(person.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read person %s again: %w", updated.ID, err)
+		}
+	}
+}
+
+// submitPersonUpdate submits UpdatePersonWithDetails for a person read with the given ETag.
+func submitPersonUpdate(contract *client.Contract, etag string, p *Person) (*SubmitResult, error) {
+	address, err := encryptField(p.Address)
+	if err != nil {
+		return nil, err
+	}
+	detailsJSON, err := personDetailsJSON(p, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return submitTransaction(contract, "UpdatePersonWithDetails", detailsJSON, etag)
+}
+
+// editedFields returns a change setting the attributes that differ between original and edited to their edited value,
+// for use with updatePersonWithRetry.
+func editedFields(original Person, edited Person) func(*Person) {
+	return func(p *Person) {
+		if edited.Serial != original.Serial {
+			p.Serial = edited.Serial
+		}
+		if edited.Name != original.Name {
+			p.Name = edited.Name
+		}
+		if edited.Surname != original.Surname {
+			p.Surname = edited.Surname
+		}
+		if edited.City != original.City {
+			p.City = edited.City
+		}
+		if edited.Address != original.Address {
+			p.Address = edited.Address
+		}
+		if edited.Phone != original.Phone {
+			p.Phone = edited.Phone
+		}
+		if edited.Married != original.Married {
+			p.Married = edited.Married
+		}
+		if edited.Reference != original.Reference {
+			p.Reference = edited.Reference
+		}
+		if edited.Gender != original.Gender {
+			p.Gender = edited.Gender
+		}
+		if edited.Birthdate != original.Birthdate {
+			p.Birthdate = edited.Birthdate
+		}
+	}
+}
@@ -0,0 +1,48 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+func TestRetryPersonUpdateAppliesChangeAgainAfterMVCCConflict(t *testing.T) {
+	original := &VersionedPerson{Person: Person{ID: "person1", City: "Moscow", Phone: "88005553535"}, ETag: "etag1"}
+	// meanwhile another client changed the phone
+	concurrent := &VersionedPerson{Person: Person{ID: "person1", City: "Moscow", Phone: "88005553536"}, ETag: "etag2"}
+
+	var submitted []Person
+	var etags []string
+	submit := func(etag string, p *Person) (*SubmitResult, error) {
+		submitted = append(submitted, *p)
+		etags = append(etags, etag)
+		if len(submitted) == 1 {
+			return nil, &failedCommitError{TransactionID: "tx1", Code: peer.TxValidationCode_MVCC_READ_CONFLICT}
+		}
+		return &SubmitResult{TxID: "tx2"}, nil
+	}
+	reads := 0
+	read := func(id string) (*VersionedPerson, error) {
+		reads++
+		return concurrent, nil
+	}
+
+	result, err := retryPersonUpdate(original, func(p *Person) { p.City = "Kazan" }, submit, read)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.TxID != "tx2" || len(submitted) != 2 || reads != 1 {
+		t.Fatalf("got %v after %d submits and %d reads, want the second submit to commit after one read", result, len(submitted), reads)
+	}
+	if etags[1] != "etag2" {
+		t.Errorf("retry submitted with ETag %s, want the ETag read again", etags[1])
+	}
+	if retried := submitted[1]; retried.City != "Kazan" || retried.Phone != "88005553536" {
+		t.Errorf("retry submitted %+v, want the change applied on top of the concurrent one", retried)
+	}
+}
@@ -0,0 +1,51 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// contextInfo is the chaincode's view of a transaction, as GetContextInfo reports it.
+type contextInfo struct {
+	ChannelID   string    `json:"channelId"`
+	TxID        string    `json:"txId"`
+	TxTimestamp time.Time `json:"txTimestamp"`
+	CreatorMSP  string    `json:"creatorMspId"`
+}
+
+// contextInfoCommand prints the channel, transaction and client identity the chaincode sees: context-info
+func contextInfoCommand(config *Config, network *client.Network, contract *client.Contract, args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: context-info")
+	}
+
+	result, err := evaluateTransaction(contract, "GetContextInfo")
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+	var info contextInfo
+	if err := json.Unmarshal(result, &info); err != nil {
+		return fmt.Errorf("failed to parse result: %w", err)
+	}
+
+	fmt.Printf("Channel:        %s\n", info.ChannelID)
+	fmt.Printf("Transaction:    %s\n", info.TxID)
+	fmt.Printf("Timestamp:      %s\n", info.TxTimestamp.Format(time.RFC3339Nano))
+	fmt.Printf("Client MSP ID:  %s\n", info.CreatorMSP)
+
+	if info.ChannelID != network.Name() {
+		fmt.Printf("WARNING: the chaincode runs on channel %s, not %s\n", info.ChannelID, network.Name())
+	}
+	if info.CreatorMSP != config.MSPID {
+		fmt.Printf("WARNING: the chaincode sees MSP ID %s, not the configured %s\n", info.CreatorMSP, config.MSPID)
+	}
+	return nil
+}
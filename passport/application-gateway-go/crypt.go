@@ -0,0 +1,149 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
+)
+
+// The address of a person can be encrypted by the client before it is submitted, so that the ledger, and every peer
+// holding a copy of it, only ever sees ciphertext. The chaincode is unaware of this and stores the value as it would
+// any other address.
+//
+// Key management is entirely up to the operator:
+//   - every client reading or writing addresses must be given the same key, there is no key exchange or rotation;
+//   - a lost key makes the stored addresses unrecoverable, and a leaked key exposes every address ever written with it,
+//     since the ledger history cannot be rewritten;
+//   - the ciphertext length leaks the approximate address length, and the remaining fields stay in clear text;
+//   - the chaincode length limit applies to the ciphertext, which leaves room for addresses of about 60 bytes.
+//
+// Values carry encryptedPrefix so that records written before encryption was enabled, or by clients without a key,
+// keep working: values without the prefix are passed through unchanged.
+var encryptionKeyPath = flag.String("encryption-key", "", "file holding a base64 encoded AES key (16, 24 or 32 bytes) used to encrypt person addresses")
+
+const encryptedPrefix = "enc:v1:"
+
+// fieldCipher is nil unless -encryption-key is set.
+var fieldCipher cipher.AEAD
+
+// encryptedAddress matches an encrypted address attribute in the JSON returned by the chaincode.
+var encryptedAddress = regexp.MustCompile(`"address":"(` + regexp.QuoteMeta(encryptedPrefix) + `[A-Za-z0-9+/=]*)"`)
+
+// initFieldEncryption loads the -encryption-key key, if any.
+func initFieldEncryption() error {
+	if *encryptionKeyPath == "" {
+		return nil
+	}
+
+	encoded, err := ioutil.ReadFile(*encryptionKeyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read encryption key: %w", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return fmt.Errorf("failed to decode encryption key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return fmt.Errorf("invalid encryption key: %w", err)
+	}
+	fieldCipher, err = cipher.NewGCM(block)
+	return err
+}
+
+// encryptField seals a value with AES-GCM under a random nonce. Without a key the value is returned unchanged.
+func encryptField(plaintext string) (string, error) {
+	if fieldCipher == nil {
+		return plaintext, nil
+	}
+
+	nonce := make([]byte, fieldCipher.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := fieldCipher.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	ciphertext := encryptedPrefix + base64.StdEncoding.EncodeToString(sealed)
+	if len(ciphertext) > validation.MaxLength {
+		return "", fmt.Errorf("the value is too long to be stored encrypted: %d characters after encryption, at most %d allowed", len(ciphertext), validation.MaxLength)
+	}
+	return ciphertext, nil
+}
+
+// decryptField opens a value produced by encryptField. Values without the encryption prefix were stored in clear text
+// and are returned unchanged.
+func decryptField(value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return value, nil
+	}
+	if fieldCipher == nil {
+		return "", errors.New("the value is encrypted and no -encryption-key is configured")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode encrypted value: %w", err)
+	}
+	if len(sealed) < fieldCipher.NonceSize() {
+		return "", errors.New("encrypted value is truncated")
+	}
+
+	nonce, ciphertext := sealed[:fieldCipher.NonceSize()], sealed[fieldCipher.NonceSize():]
+	plaintext, err := fieldCipher.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// encryptAddresses encrypts the address of every given person in place.
+func encryptAddresses(persons []Person) error {
+	for i := range persons {
+		address, err := encryptField(persons[i].Address)
+		if err != nil {
+			return fmt.Errorf("person %s: %w", persons[i].ID, err)
+		}
+		persons[i].Address = address
+	}
+	return nil
+}
+
+// decryptAddresses replaces every encrypted address in a chaincode JSON result with its clear text, leaving the rest of
+// the document untouched. Addresses that cannot be decrypted are left encrypted.
+func decryptAddresses(result []byte) []byte {
+	// system chaincode results such as blocks are protobuf, and may embed encrypted addresses that must stay intact
+	if fieldCipher == nil || !json.Valid(result) {
+		return result
+	}
+
+	return encryptedAddress.ReplaceAllFunc(result, func(match []byte) []byte {
+		value := string(encryptedAddress.FindSubmatch(match)[1])
+		plaintext, err := decryptField(value)
+		if err != nil {
+			log.Printf("leaving address encrypted: %s", err)
+			return match
+		}
+		plaintextJSON, err := json.Marshal(plaintext)
+		if err != nil {
+			return match
+		}
+		return append([]byte(`"address":`), plaintextJSON...)
+	})
+}
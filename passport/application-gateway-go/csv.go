@@ -0,0 +1,58 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// csvHeader names the columns exportPersonsCSV writes, the JSON names of the person fields.
+var csvHeader = []string{"id", "passport", "name", "surname", "city", "address", "phone", "married"}
+
+// exportPersonsCSV writes every person to a CSV file with a header row, one line per person, and reports how many
+// were written. Addresses are written in clear text when the client holds the encryption key.
+func exportPersonsCSV(contract *client.Contract, filename string) {
+	fmt.Println("Evaluate Transaction: GetAllPersons, function returns all the current assets on the ledger")
+	persons, err := listPersons(contract)
+	if err != nil {
+		printGatewayError(err)
+		return
+	}
+
+	if err := writePersonsCSV(filename, persons); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("*** %d persons written to %s\n", len(persons), filename)
+}
+
+func writePersonsCSV(filename string, persons []Person) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, p := range persons {
+		record := []string{p.ID, p.Serial, p.Name, p.Surname, p.City, p.Address, p.Phone, strconv.FormatBool(p.Married)}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV file: %w", err)
+	}
+	return file.Close()
+}
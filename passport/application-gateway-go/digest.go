@@ -0,0 +1,46 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+var assumeYes = flag.Bool("yes", false, "skip the typed confirmations of bulk operations, for use in automation")
+
+// digestPrefixLength is how many leading characters of a batch digest the operator has to type back.
+const digestPrefixLength = 8
+
+// batchDigest returns the hex-encoded SHA-256 hash of a batch of persons as read from its file, before any field
+// encryption, so the same file always yields the same digest and plan can show it ahead of the actual submit.
+func batchDigest(persons []Person) string {
+	personsJSON, err := json.Marshal(persons)
+	if err != nil {
+		// a Person holds nothing but strings and booleans, so marshaling cannot fail
+		panic(err)
+	}
+	hash := sha256.Sum256(personsJSON)
+	return hex.EncodeToString(hash[:])
+}
+
+// confirmDigest shows the digest of a batch and reports whether the operator typed back its first characters, which
+// guards against submitting another file than the one that was reviewed. It always succeeds with -yes.
+func confirmDigest(digest string) (bool, error) {
+	fmt.Printf("Batch digest: %s\n", digest)
+	if *assumeYes {
+		return true, nil
+	}
+
+	answer, err := promptLine(fmt.Sprintf("Type the first %d characters of the digest to confirm: ", digestPrefixLength))
+	if err != nil {
+		return false, err
+	}
+	return strings.ToLower(answer) == digest[:digestPrefixLength], nil
+}
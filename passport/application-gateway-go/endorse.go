@@ -0,0 +1,131 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go/common"
+	gwproto "github.com/hyperledger/fabric-protos-go/gateway"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+var (
+	showEndorsers = flag.Bool("show-endorsers", false, "print the organizations that endorsed each create")
+	requiredOrgs  = flag.String("required-orgs", "", "comma-separated MSP IDs expected to endorse every create, a warning is printed when one is missing; implies -show-endorsers")
+)
+
+// checkEndorsers reports whether creates should print their endorsing organizations.
+func checkEndorsers() bool {
+	return *showEndorsers || *requiredOrgs != ""
+}
+
+// submitCheckingEndorsers submits a transaction like submitTransaction, printing the organizations whose peers endorsed
+// it and warning about any -required-orgs organization missing among them. The transaction is submitted regardless:
+// whether the endorsements suffice is for the endorsement policy to decide at commit.
+func submitCheckingEndorsers(contract *client.Contract, name string, args ...string) (*SubmitResult, error) {
+	var status *client.Status
+	submit := func(name string, args ...string) ([]byte, error) {
+		proposal, err := contract.NewProposal(name, client.WithArguments(args...))
+		if err != nil {
+			return nil, err
+		}
+		transaction, err := proposal.Endorse()
+		if err != nil {
+			return nil, err
+		}
+
+		orgs, err := endorsingOrgs(transaction)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("Endorsed by: %s\n", strings.Join(orgs, ", "))
+		if missing := missingOrgs(orgs, *requiredOrgs); len(missing) > 0 {
+			fmt.Printf("WARNING: no endorsement from %s\n", strings.Join(missing, ", "))
+		}
+
+		commit, err := transaction.Submit()
+		if err != nil {
+			return nil, err
+		}
+		status, err = awaitCommit(commit)
+		if err != nil {
+			return nil, err
+		}
+		return transaction.Result(), nil
+	}
+
+	result, err := chain(submitMiddleware, submit)(name, args...)
+	if err != nil {
+		return nil, err
+	}
+	return newSubmitResult(result, status), nil
+}
+
+// endorsingOrgs returns the sorted MSP IDs of the peers whose endorsements an endorsed transaction carries.
+func endorsingOrgs(transaction *client.Transaction) ([]string, error) {
+	preparedBytes, err := transaction.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	prepared := &gwproto.PreparedTransaction{}
+	if err := proto.Unmarshal(preparedBytes, prepared); err != nil {
+		return nil, fmt.Errorf("failed to parse prepared transaction: %w", err)
+	}
+
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(prepared.GetEnvelope().GetPayload(), payload); err != nil {
+		return nil, fmt.Errorf("failed to parse transaction payload: %w", err)
+	}
+	tx := &peer.Transaction{}
+	if err := proto.Unmarshal(payload.GetData(), tx); err != nil {
+		return nil, fmt.Errorf("failed to parse transaction: %w", err)
+	}
+
+	orgSet := make(map[string]bool)
+	for _, action := range tx.GetActions() {
+		actionPayload := &peer.ChaincodeActionPayload{}
+		if err := proto.Unmarshal(action.GetPayload(), actionPayload); err != nil {
+			return nil, fmt.Errorf("failed to parse chaincode action: %w", err)
+		}
+		for _, endorsement := range actionPayload.GetAction().GetEndorsements() {
+			endorser := &msp.SerializedIdentity{}
+			if err := proto.Unmarshal(endorsement.GetEndorser(), endorser); err != nil {
+				return nil, fmt.Errorf("failed to parse endorser identity: %w", err)
+			}
+			orgSet[endorser.GetMspid()] = true
+		}
+	}
+
+	orgs := make([]string, 0, len(orgSet))
+	for org := range orgSet {
+		orgs = append(orgs, org)
+	}
+	sort.Strings(orgs)
+	return orgs, nil
+}
+
+// missingOrgs returns the organizations of the comma-separated required list that are not among orgs.
+func missingOrgs(orgs []string, required string) []string {
+	endorsed := make(map[string]bool, len(orgs))
+	for _, org := range orgs {
+		endorsed[org] = true
+	}
+
+	var missing []string
+	for _, org := range strings.Split(required, ",") {
+		org = strings.TrimSpace(org)
+		if org != "" && !endorsed[org] {
+			missing = append(missing, org)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,218 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go/common"
+	gwproto "github.com/hyperledger/fabric-protos-go/gateway"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// chaincodeNotFoundError replaces the error of a call to a chaincode the gateway does not know, which otherwise reads
+// like a generic endorsement failure. A misspelled chaincode or channel name looks like this.
+type chaincodeNotFoundError struct {
+	chaincode string
+	channel   string
+	err       error
+}
+
+func (e *chaincodeNotFoundError) Error() string {
+	return fmt.Sprintf("chaincode '%s' not found on channel '%s' — check deployment and names", e.chaincode, e.channel)
+}
+
+func (e *chaincodeNotFoundError) Unwrap() error {
+	return e.err
+}
+
+// isChaincodeNotFound reports whether a call failed because the chaincode is not deployed on the channel.
+func isChaincodeNotFound(err error) bool {
+	var notFoundErr *chaincodeNotFoundError
+	return errors.As(err, &notFoundErr)
+}
+
+// withChaincodeNotFound turns the errors the gateway reports with the NotFound gRPC status, which it returns when the
+// chaincode or the channel of a call does not exist, into a chaincodeNotFoundError naming the given ones. Errors of
+// the chaincode itself never carry that status, they are reported as failed endorsements.
+func withChaincodeNotFound(chaincode string, channel string) Middleware {
+	return func(next Invoker) Invoker {
+		return func(name string, args ...string) ([]byte, error) {
+			result, err := next(name, args...)
+			if err != nil && gatewayStatus(err).Code() == codes.NotFound {
+				return result, &chaincodeNotFoundError{chaincode: chaincode, channel: channel, err: err}
+			}
+			return result, err
+		}
+	}
+}
+
+// maintenanceStatus is the broadcast status an orderer answers ordinary transactions with while its channel is in
+// maintenance mode. The gateway names it in the error of the submit.
+var maintenanceStatus = common.Status_SERVICE_UNAVAILABLE.String()
+
+// submissionRejectedError reports a transaction the network refused to order, as opposed to one its chaincode or
+// endorsement policy rejected. Resubmitting it changes nothing until the channel accepts transactions again.
+type submissionRejectedError struct {
+	err error
+}
+
+func (e *submissionRejectedError) Error() string {
+	return "the network rejected this submission (channel may be read-only or orderer unavailable): " + e.err.Error()
+}
+
+func (e *submissionRejectedError) Unwrap() error {
+	return e.err
+}
+
+// isSubmissionRejected reports whether a submit failed because the orderer refused to order the endorsed transaction
+// while its channel is in maintenance mode. Other failures to reach the orderer are transient and left as they are.
+func isSubmissionRejected(err error) bool {
+	var submitErr *client.SubmitError
+	return errors.As(err, &submitErr) && errorMentions(submitErr, maintenanceStatus)
+}
+
+// errorMentions reports whether the error message, or the message of any endpoint error detail embedded in its gRPC
+// status, contains the given text. It is meant for the failures of the network, such as an orderer status: chaincode
+// failures are told apart by their code, which chaincodeError returns, never by their wording.
+func errorMentions(err error, text string) bool {
+	if err == nil {
+		return false
+	}
+	if strings.Contains(err.Error(), text) {
+		return true
+	}
+	for _, detail := range gatewayStatus(err).Details() {
+		if errDetail, ok := detail.(*gwproto.ErrorDetail); ok && strings.Contains(errDetail.Message, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Codes the chaincode prefixes to the messages of the errors callers may want to tell apart, followed by ": " and a
+// readable message.
+const (
+	errCodeNotFound   = "NOT_FOUND"
+	errCodeValidation = "VALIDATION"
+	errCodeConflict   = "CONFLICT"
+	// errCodeHistoryUnavailable is the code of the chaincode's ErrHistoryUnavailable.
+	errCodeHistoryUnavailable = "HISTORY_UNAVAILABLE"
+)
+
+var chaincodeErrorCodes = []string{errCodeNotFound, errCodeValidation, errCodeConflict, errCodeHistoryUnavailable}
+
+// chaincodeError returns the code of a coded chaincode error and its readable message, looking at the error message
+// and at the endpoint error details of its gRPC status. The code is empty for any other error.
+func chaincodeError(err error) (code string, message string) {
+	if err == nil {
+		return "", ""
+	}
+	messages := []string{err.Error()}
+	for _, detail := range gatewayStatus(err).Details() {
+		if errDetail, ok := detail.(*gwproto.ErrorDetail); ok {
+			messages = append(messages, errDetail.Message)
+		}
+	}
+
+	for _, text := range messages {
+		for _, code := range chaincodeErrorCodes {
+			if i := strings.Index(text, code+": "); i >= 0 {
+				return code, text[i+len(code)+2:]
+			}
+		}
+	}
+	return "", ""
+}
+
+// printChaincodeError prints a short explanation of a coded chaincode error and reports whether err was one. The
+// gateway details are left out, they add nothing to a rejection the chaincode has explained.
+func printChaincodeError(err error) bool {
+	code, message := chaincodeError(err)
+	switch code {
+	case errCodeNotFound:
+		fmt.Printf("Not found: %s\n", message)
+	case errCodeValidation:
+		fmt.Printf("Invalid input: %s\n", message)
+	case errCodeConflict:
+		fmt.Printf("Conflicts with the ledger: %s\n", message)
+	case errCodeHistoryUnavailable:
+		fmt.Println("History is not enabled on this network, ask the operator to enable the peer history database")
+	default:
+		return false
+	}
+	return true
+}
+
+// printGatewayError describes a failed submit or evaluate call: the kind of failure reported by the gateway, followed by
+// the error returned by each peer or orderer endpoint involved. Errors that carry no endpoint details, such as a
+// connection failure before the gateway was reached, are printed as they are.
+func printGatewayError(err error) {
+	var notFoundErr *chaincodeNotFoundError
+	if errors.As(err, &notFoundErr) {
+		fmt.Println(notFoundErr)
+	}
+	var rejectedErr *submissionRejectedError
+	if errors.As(err, &rejectedErr) {
+		fmt.Println("The network rejected this submission, the channel may be read-only or the orderer unavailable")
+	}
+
+	var endorseErr *client.EndorseError
+	var submitErr *client.SubmitError
+	var commitStatusErr *client.CommitStatusError
+	var commitErr *client.CommitError
+	var failedCommitErr *failedCommitError
+
+	switch {
+	case errors.As(err, &endorseErr):
+		fmt.Printf("Endorse error for transaction %s with gRPC status %v: %s\n", endorseErr.TransactionID, status.Code(endorseErr), endorseErr)
+	case errors.As(err, &submitErr):
+		fmt.Printf("Submit error for transaction %s with gRPC status %v: %s\n", submitErr.TransactionID, status.Code(submitErr), submitErr)
+	case errors.As(err, &commitStatusErr):
+		if errors.Is(err, context.DeadlineExceeded) {
+			fmt.Printf("Timeout waiting for transaction %s commit status: %s\n", commitStatusErr.TransactionID, commitStatusErr)
+		} else {
+			fmt.Printf("Error obtaining commit status for transaction %s with gRPC status %v: %s\n", commitStatusErr.TransactionID, status.Code(commitStatusErr), commitStatusErr)
+		}
+	case errors.As(err, &commitErr):
+		fmt.Printf("Transaction %s failed to commit with status %d: %s\n", commitErr.TransactionID, int32(commitErr.Code), commitErr)
+	case errors.As(err, &failedCommitErr):
+		fmt.Printf("Transaction %s failed to commit with status %d: %s\n", failedCommitErr.TransactionID, int32(failedCommitErr.Code), failedCommitErr)
+	case hasGRPCStatus(err):
+		fmt.Printf("Error with gRPC status %v: %s\n", gatewayStatus(err).Code(), err)
+	default:
+		fmt.Println(err)
+	}
+
+	/*
+	 Any error that originates from a peer or orderer node external to the gateway will have its details
+	 embedded within the gRPC status error.
+	*/
+	for _, detail := range gatewayStatus(err).Details() {
+		if errDetail, ok := detail.(*gwproto.ErrorDetail); ok {
+			fmt.Printf("Error from endpoint: %s, mspId: %s, message: %s\n", errDetail.Address, errDetail.MspId, errDetail.Message)
+		}
+	}
+}
+
+// hasGRPCStatus reports whether err, or any error it wraps, carries a gRPC status.
+func hasGRPCStatus(err error) bool {
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	return errors.As(err, &grpcErr)
+}
+
+// gatewayStatus returns the gRPC status carried by err, looking through any wrapping added by the client helpers.
+func gatewayStatus(err error) *status.Status {
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcErr) {
+		return grpcErr.GRPCStatus()
+	}
+	return status.Convert(err)
+}
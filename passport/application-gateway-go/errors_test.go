@@ -0,0 +1,122 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	gwproto "github.com/hyperledger/fabric-protos-go/gateway"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMissingChaincodeIsReportedByName(t *testing.T) {
+	fake := &fakeInvoker{err: status.Error(codes.NotFound, "chaincode passport-typo not found")}
+	evaluate := withChaincodeNotFound("passport-typo", "mychannel")(fake.invoke)
+
+	_, err := evaluate("org.hyperledger.fabric:GetMetadata")
+
+	if !isChaincodeNotFound(err) {
+		t.Fatalf("got %v, want the chaincode reported missing", err)
+	}
+	want := "chaincode 'passport-typo' not found on channel 'mychannel'"
+	if !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("got %q, want it to start with %q", err, want)
+	}
+	if gatewayStatus(err).Code() != codes.NotFound {
+		t.Error("the gateway error is no longer reachable through the reported one")
+	}
+}
+
+func TestChaincodeErrorsAreNotMistakenForMissingChaincode(t *testing.T) {
+	fake := &fakeInvoker{err: status.Error(codes.Aborted, "NOT_FOUND: the person person1 does not exist")}
+	evaluate := withChaincodeNotFound("passport", "mychannel")(fake.invoke)
+
+	_, err := evaluate("ReadPerson", "person1")
+
+	if isChaincodeNotFound(err) {
+		t.Errorf("a person missing from the ledger was reported as a missing chaincode: %v", err)
+	}
+	if code, _ := chaincodeError(err); code != errCodeNotFound {
+		t.Errorf("got code %q, want the chaincode error code kept", code)
+	}
+}
+
+func TestChaincodeError(t *testing.T) {
+	inDetails, err := status.New(codes.Aborted, "failed to endorse transaction").WithDetails(&gwproto.ErrorDetail{
+		Address: "peer0.org1.example.com:7051",
+		Message: "chaincode response 500, CONFLICT: the person person1 is linked to the spouse person2",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    string
+		wantMessage string
+	}{
+		{"in the message", status.Error(codes.Unknown, "evaluate call to endorser returned error: NOT_FOUND: the person person1 does not exist"), errCodeNotFound, "the person person1 does not exist"},
+		{"in the gateway details", fmt.Errorf("submit: %w", inDetails.Err()), errCodeConflict, "the person person1 is linked to the spouse person2"},
+		{"uncoded", status.Error(codes.Unavailable, "connection refused"), "", ""},
+		{"nil", nil, "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, message := chaincodeError(test.err)
+			if code != test.wantCode || message != test.wantMessage {
+				t.Errorf("got %q, %q, want %q, %q", code, message, test.wantCode, test.wantMessage)
+			}
+		})
+	}
+}
+
+// useSubmitMiddleware sets the submit middleware chain for the duration of the test.
+func useSubmitMiddleware(t *testing.T, middleware ...Middleware) {
+	t.Helper()
+	saved := submitMiddleware
+	submitMiddleware = middleware
+	t.Cleanup(func() { submitMiddleware = saved })
+}
+
+func TestSubmitRefusedInMaintenanceModeIsRejected(t *testing.T) {
+	useSubmitMiddleware(t, withSubmissionRejection)
+	contract := newFakeContract(t, &fakeGateway{
+		submitErr: status.Error(codes.Aborted, "received unsuccessful response from orderer: SERVICE_UNAVAILABLE"),
+	})
+
+	_, err := submitTransaction(contract, "DeletePerson", "person1")
+
+	var rejectedErr *submissionRejectedError
+	if !errors.As(err, &rejectedErr) {
+		t.Fatalf("got %v, want the submission reported as rejected", err)
+	}
+	var submitErr *client.SubmitError
+	if !errors.As(err, &submitErr) {
+		t.Error("the SubmitError is no longer reachable through the rejection")
+	}
+}
+
+func TestOtherSubmitFailuresAreNotRejections(t *testing.T) {
+	useSubmitMiddleware(t, withSubmissionRejection)
+	contract := newFakeContract(t, &fakeGateway{
+		submitErr: status.Error(codes.Unavailable, "failed to send transaction to orderer: connection refused"),
+	})
+
+	_, err := submitTransaction(contract, "DeletePerson", "person1")
+
+	var submitErr *client.SubmitError
+	if !errors.As(err, &submitErr) {
+		t.Fatalf("got %v, want a SubmitError", err)
+	}
+	if isSubmissionRejected(err) {
+		t.Errorf("a transient submit failure was reported as a rejection: %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/gateway"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeGateway endorses every proposal with an empty result, answers every submit with submitErr and reports every
+// submitted transaction committed with commitStatus.
+type fakeGateway struct {
+	gateway.UnimplementedGatewayServer
+	submitErr    error
+	commitStatus peer.TxValidationCode
+}
+
+func (fake *fakeGateway) Endorse(ctx context.Context, request *gateway.EndorseRequest) (*gateway.EndorseResponse, error) {
+	channelHeader, err := proto.Marshal(&common.ChannelHeader{ChannelId: request.ChannelId})
+	if err != nil {
+		return nil, err
+	}
+	transaction, err := proto.Marshal(&peer.Transaction{Actions: []*peer.TransactionAction{{}}})
+	if err != nil {
+		return nil, err
+	}
+	payload, err := proto.Marshal(&common.Payload{Header: &common.Header{ChannelHeader: channelHeader}, Data: transaction})
+	if err != nil {
+		return nil, err
+	}
+	return &gateway.EndorseResponse{PreparedTransaction: &common.Envelope{Payload: payload}}, nil
+}
+
+func (fake *fakeGateway) Submit(ctx context.Context, request *gateway.SubmitRequest) (*gateway.SubmitResponse, error) {
+	if fake.submitErr != nil {
+		return nil, fake.submitErr
+	}
+	return &gateway.SubmitResponse{}, nil
+}
+
+func (fake *fakeGateway) CommitStatus(ctx context.Context, request *gateway.SignedCommitStatusRequest) (*gateway.CommitStatusResponse, error) {
+	return &gateway.CommitStatusResponse{Result: fake.commitStatus, BlockNumber: 1}, nil
+}
+
+// fakeIdentity is the identity of the client of a fakeGateway, which checks no credentials.
+type fakeIdentity struct{}
+
+func (fakeIdentity) MspID() string       { return "Org1MSP" }
+func (fakeIdentity) Credentials() []byte { return nil }
+
+// newFakeContract returns a contract served by fake over an in-memory connection.
+func newFakeContract(t *testing.T, fake *fakeGateway) *client.Contract {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	gateway.RegisterGatewayServer(server, fake)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	dial := func(context.Context, string) (net.Conn, error) { return listener.Dial() }
+	connection, err := grpc.Dial("bufnet", grpc.WithContextDialer(dial), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { connection.Close() })
+
+	sign := func([]byte) ([]byte, error) { return []byte("signature"), nil }
+	gw, err := client.Connect(fakeIdentity{}, client.WithSign(sign), client.WithClientConnection(connection))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { gw.Close() })
+	return gw.GetNetwork("mychannel").GetContract("passport")
+}
@@ -3,9 +3,15 @@ module passport
 go 1.16
 
 require (
+	github.com/golang/protobuf v1.5.2
 	github.com/hyperledger/fabric-gateway v1.0.0
 	github.com/hyperledger/fabric-protos-go v0.0.0-20211118165945-23d738fc3553
+	github.com/hyperledger/fabric-samples/passport/chaincode-go v0.0.0-00010101000000-000000000000
 	golang.org/x/net v0.0.0-20211015210444-4f30a5c0130f // indirect
 	golang.org/x/text v0.3.7 // indirect
+	golang.org/x/time v0.0.0-20210723032227-1f47c861a9ac
 	google.golang.org/grpc v1.42.0
+	gopkg.in/yaml.v2 v2.4.0
 )
+
+replace github.com/hyperledger/fabric-samples/passport/chaincode-go => ../chaincode-go
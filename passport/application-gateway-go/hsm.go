@@ -0,0 +1,95 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+)
+
+// hsmPinEnv holds the user PIN of the token, kept out of the command line where other users could read it.
+const hsmPinEnv = "PASSPORT_HSM_PIN"
+
+// Signing with a PKCS#11 token needs a client built with the pkcs11 tag, go build -tags pkcs11, which requires cgo.
+// The token is found by its label, which identifies the slot it is plugged into.
+var (
+	useHSM     = flag.Bool("hsm", false, "sign with a PKCS#11 token instead of the private key file, needs a build with -tags pkcs11")
+	hsmLibrary = flag.String("hsm-library", "", "path of the PKCS#11 library of the token")
+	hsmLabel   = flag.String("hsm-label", "", "label of the PKCS#11 token holding the signing key")
+	hsmKeyID   = flag.String("hsm-key-id", "", "hex-encoded CKA_ID of the signing key, by default the hash of the client certificate public key")
+)
+
+// hsmConfig locates the signing key on a PKCS#11 token.
+type hsmConfig struct {
+	Library string
+	Label   string
+	Pin     string
+	// KeyID is the raw CKA_ID of the private key.
+	KeyID string
+}
+
+// newHSMConfig builds the token configuration from the command line flags and the environment. Without an explicit
+// key id, the key is looked up by the identifier Fabric tooling gives it: the hash of the certificate public key.
+func newHSMConfig(certificate *x509.Certificate) (hsmConfig, error) {
+	config := hsmConfig{
+		Library: *hsmLibrary,
+		Label:   *hsmLabel,
+		Pin:     os.Getenv(hsmPinEnv),
+	}
+	if config.Library == "" || config.Label == "" {
+		return config, fmt.Errorf("-hsm requires -hsm-library and -hsm-label")
+	}
+	if config.Pin == "" {
+		return config, fmt.Errorf("-hsm requires the token PIN in %s", hsmPinEnv)
+	}
+
+	if *hsmKeyID != "" {
+		keyID, err := hex.DecodeString(*hsmKeyID)
+		if err != nil {
+			return config, fmt.Errorf("-hsm-key-id must be hex-encoded: %w", err)
+		}
+		config.KeyID = string(keyID)
+	} else {
+		publicKey, ok := certificate.PublicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return config, fmt.Errorf("the client certificate does not hold an ECDSA key, set -hsm-key-id")
+		}
+		ski := sha256.Sum256(elliptic.Marshal(publicKey.Curve, publicKey.X, publicKey.Y))
+		config.KeyID = string(ski[:])
+	}
+	return config, nil
+}
+
+// newSignFromFlags returns the signing function selected on the command line: the PKCS#11 token with -hsm, the
+// private key file otherwise. A token session is registered with appLifecycle to be closed on exit.
+func newSignFromFlags(config *Config) identity.Sign {
+	if !*useHSM {
+		return newSign(config)
+	}
+
+	certificate, err := loadCertificate(certPEMEnv, config.CertPath)
+	if err != nil {
+		panic(err)
+	}
+	tokenConfig, err := newHSMConfig(certificate)
+	if err != nil {
+		panic(err)
+	}
+
+	sign, closeSign, err := newSignHSM(tokenConfig)
+	if err != nil {
+		panic(fmt.Errorf("failed to sign with the PKCS#11 token: %w", err))
+	}
+	appLifecycle.AddFunc("HSM signer", closeSign)
+	return sign
+}
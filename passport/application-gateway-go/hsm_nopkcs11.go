@@ -0,0 +1,19 @@
+//go:build !pkcs11
+// +build !pkcs11
+
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"errors"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+)
+
+// newSignHSM fails in builds without PKCS#11 support, which keeps the default build free of cgo.
+func newSignHSM(config hsmConfig) (identity.Sign, func() error, error) {
+	return nil, nil, errors.New("this client was built without PKCS#11 support, rebuild it with go build -tags pkcs11")
+}
@@ -0,0 +1,37 @@
+//go:build pkcs11
+// +build pkcs11
+
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+)
+
+// newSignHSM returns a signing function backed by the key config locates on a PKCS#11 token, and the function that
+// releases the token session.
+func newSignHSM(config hsmConfig) (identity.Sign, func() error, error) {
+	factory, err := identity.NewHSMSignerFactory(config.Library)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sign, closeSign, err := factory.NewHSMSigner(identity.HSMSignerOptions{
+		Label:      config.Label,
+		Pin:        config.Pin,
+		Identifier: config.KeyID,
+	})
+	if err != nil {
+		factory.Dispose()
+		return nil, nil, err
+	}
+
+	return sign, func() error {
+		err := closeSign()
+		factory.Dispose()
+		return err
+	}, nil
+}
@@ -0,0 +1,125 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var idleTimeout = flag.Duration("idle-timeout", 0, "end the interactive session after this long without input (0 waits forever)")
+
+// errIdleTimeout is returned by readLine when no input arrives within -idle-timeout. Prompts hand it back to their
+// callers up to the menu loop, which ends the session so that the deferred gateway and connection cleanups in main run
+// before the process exits.
+var errIdleTimeout = errors.New("interactive session idle timeout")
+
+// errInputClosed is returned by readLine once the input is exhausted, for instance when a script piped into the client
+// ends. Like errIdleTimeout it ends the session instead of letting every prompt read empty lines forever.
+var errInputClosed = errors.New("interactive session input closed")
+
+// lineReader reads lines from a source in a background goroutine so that waiting for input can be bounded by a timer.
+type lineReader struct {
+	lines   chan string
+	timeout time.Duration
+	// err is the error that stopped the scanner, if any. It is written before lines is closed.
+	err error
+}
+
+func newLineReader(source io.Reader, timeout time.Duration) *lineReader {
+	reader := &lineReader{
+		lines:   make(chan string),
+		timeout: timeout,
+	}
+
+	go func() {
+		scanner := bufio.NewScanner(source)
+		for scanner.Scan() {
+			reader.lines <- scanner.Text()
+		}
+		reader.err = scanner.Err()
+		close(reader.lines)
+	}()
+
+	return reader
+}
+
+// ReadLine waits for the next line of input. It fails with errIdleTimeout when the timeout elapses first and with
+// errInputClosed when the input is exhausted.
+func (reader *lineReader) ReadLine() (string, error) {
+	var timeout <-chan time.Time
+	if reader.timeout > 0 {
+		timer := time.NewTimer(reader.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case line, ok := <-reader.lines:
+		if !ok {
+			return "", errInputClosed
+		}
+		return line, nil
+	case <-timeout:
+		return "", errIdleTimeout
+	}
+}
+
+// isSessionEnd reports whether err is errIdleTimeout or errInputClosed, which end the interactive session.
+func isSessionEnd(err error) bool {
+	return errors.Is(err, errIdleTimeout) || errors.Is(err, errInputClosed)
+}
+
+// printSessionEnd tells why the interactive session ended with err.
+func printSessionEnd(err error) {
+	switch {
+	case errors.Is(err, errIdleTimeout):
+		fmt.Printf("\nNo input for %s, session closed\n", *idleTimeout)
+	case errors.Is(err, errInputClosed) && stdin.err != nil:
+		fmt.Printf("\nFailed to read input: %v, session closed\n", stdin.err)
+	case errors.Is(err, errInputClosed):
+		fmt.Println("\nEnd of input, session closed")
+	default:
+		fmt.Println(err)
+	}
+}
+
+// stdin is the single reader of the process standard input shared by all interactive prompts.
+var stdin *lineReader
+
+func readLine() (string, error) {
+	return stdin.ReadLine()
+}
+
+// readWord returns the first whitespace-separated word of the next input line.
+func readWord() (string, error) {
+	line, err := readLine()
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return fields[0], nil
+}
+
+// promptWord prints label and reads the first word of the answer.
+func promptWord(label string) (string, error) {
+	fmt.Print(label)
+	return readWord()
+}
+
+// promptLine prints label and reads the answer, trimmed of surrounding whitespace.
+func promptLine(label string) (string, error) {
+	fmt.Print(label)
+	line, err := readLine()
+	return strings.TrimSpace(line), err
+}
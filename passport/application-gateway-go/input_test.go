@@ -0,0 +1,62 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLineReaderReportsClosedInput(t *testing.T) {
+	reader := newLineReader(strings.NewReader("create\nexit\n"), time.Second)
+
+	for _, want := range []string{"create", "exit"} {
+		line, err := reader.ReadLine()
+		if err != nil || line != want {
+			t.Fatalf("ReadLine() = %q, %v, want %q", line, err, want)
+		}
+	}
+	if _, err := reader.ReadLine(); err != errInputClosed {
+		t.Errorf("ReadLine() past the end of input failed with %v, want errInputClosed", err)
+	}
+	if _, err := reader.ReadLine(); err != errInputClosed {
+		t.Errorf("ReadLine() after the end of input failed with %v, want errInputClosed again", err)
+	}
+}
+
+func TestLineReaderTimesOutOnIdleInput(t *testing.T) {
+	pipeReader, pipeWriter := io.Pipe()
+	defer pipeWriter.Close()
+	reader := newLineReader(pipeReader, 10*time.Millisecond)
+
+	if _, err := reader.ReadLine(); err != errIdleTimeout {
+		t.Fatalf("ReadLine() without input failed with %v, want errIdleTimeout", err)
+	}
+
+	// a line arriving after a timeout is still read by the next call
+	go io.WriteString(pipeWriter, "late\n")
+	reader.timeout = time.Second
+	if line, err := reader.ReadLine(); err != nil || line != "late" {
+		t.Errorf("ReadLine() = %q, %v, want the late line", line, err)
+	}
+}
+
+func TestReadWord(t *testing.T) {
+	saved := stdin
+	defer func() { stdin = saved }()
+	stdin = newLineReader(strings.NewReader("  person1 person2\n\n\t \n"), time.Second)
+
+	for _, want := range []string{"person1", "", ""} {
+		word, err := readWord()
+		if err != nil || word != want {
+			t.Errorf("readWord() = %q, %v, want %q", word, err, want)
+		}
+	}
+	if _, err := readWord(); !isSessionEnd(err) {
+		t.Errorf("readWord() past the end of input failed with %v, want the end of the session", err)
+	}
+}
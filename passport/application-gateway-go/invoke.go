@@ -0,0 +1,180 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// queryOrg pins evaluate requests to the peers of a single organization instead of letting gateway discovery choose.
+// This gives read-your-writes consistency against a known org and helps diagnose state divergence between orgs.
+var queryOrg = flag.String("query-org", "", "MSP ID of the organization whose peers should serve queries")
+
+// bulkEndorseTimeout is the endorsement deadline for bulk submits, which do much more work per transaction than
+// single-record ones. The gateway-wide WithEndorseTimeout only applies to calls made without an explicit context,
+// so endorsing with a context carrying this deadline replaces the default for that call alone.
+var bulkEndorseTimeout = flag.Duration("bulk-endorse-timeout", time.Minute, "endorsement deadline for bulk submits")
+
+// SubmitResult describes a committed transaction. The block number lets it be looked up in a block explorer.
+type SubmitResult struct {
+	Result      []byte
+	TxID        string
+	BlockNumber uint64
+	Status      peer.TxValidationCode
+}
+
+func (result *SubmitResult) String() string {
+	return fmt.Sprintf("committed tx %s in block %d", result.TxID, result.BlockNumber)
+}
+
+// submitTransaction submits a transaction through the submit middleware chain and waits for it to commit.
+func submitTransaction(contract *client.Contract, name string, args ...string) (*SubmitResult, error) {
+	var status *client.Status
+	submit := func(name string, args ...string) ([]byte, error) {
+		result, commit, err := contract.SubmitAsync(name, client.WithArguments(args...))
+		if err != nil {
+			return nil, err
+		}
+
+		// the commit wait happens inside the chain, so that middleware sees commit failures as well
+		status, err = awaitCommit(commit)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	result, err := chain(submitMiddleware, submit)(name, args...)
+	if err != nil {
+		return nil, err
+	}
+	return newSubmitResult(result, status), nil
+}
+
+// submitWithEndorseTimeout submits a transaction whose endorsement must complete within the given timeout rather than
+// the gateway default, through the submit middleware chain, and waits for it to commit. Submission to the orderer and
+// the commit status wait keep their gateway defaults.
+func submitWithEndorseTimeout(contract *client.Contract, timeout time.Duration, name string, args ...string) (*SubmitResult, error) {
+	var status *client.Status
+	submit := func(name string, args ...string) ([]byte, error) {
+		result, commit, err := endorseAndSubmit(contract, timeout, name, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		// the commit wait happens inside the chain, like in submitTransaction, so that middleware sees commit failures
+		status, err = awaitCommit(commit)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	result, err := chain(submitMiddleware, submit)(name, args...)
+	if err != nil {
+		return nil, err
+	}
+	return newSubmitResult(result, status), nil
+}
+
+// failedCommitError is returned by awaitCommit for a transaction that was ordered but failed validation. It plays the
+// part of client.CommitError, which only the gateway client itself can create.
+type failedCommitError struct {
+	TransactionID string
+	Code          peer.TxValidationCode
+}
+
+func (e *failedCommitError) Error() string {
+	return fmt.Sprintf("transaction %s failed to commit with status code %d (%s)", e.TransactionID, int32(e.Code), e.Code)
+}
+
+// awaitCommit waits for the commit status of a submitted transaction and fails unless it committed successfully.
+func awaitCommit(commit *client.Commit) (*client.Status, error) {
+	status, err := commit.Status()
+	if err != nil {
+		return nil, err
+	}
+	if !status.Successful {
+		return nil, &failedCommitError{TransactionID: status.TransactionID, Code: status.Code}
+	}
+	return status, nil
+}
+
+func newSubmitResult(result []byte, status *client.Status) *SubmitResult {
+	return &SubmitResult{
+		Result:      result,
+		TxID:        status.TransactionID,
+		BlockNumber: status.BlockNumber,
+		Status:      status.Code,
+	}
+}
+
+// submitAsyncWithEndorseTimeout is submitWithEndorseTimeout without the wait for the commit, which is left to the
+// caller through the returned commit.
+func submitAsyncWithEndorseTimeout(contract *client.Contract, timeout time.Duration, name string, args ...string) ([]byte, *client.Commit, error) {
+	var commit *client.Commit
+	submit := func(name string, args ...string) ([]byte, error) {
+		result, submitted, err := endorseAndSubmit(contract, timeout, name, args...)
+		commit = submitted
+		return result, err
+	}
+
+	result, err := chain(submitMiddleware, submit)(name, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, commit, nil
+}
+
+// endorseAndSubmit endorses a transaction within the given timeout and submits it to the orderer, returning its
+// result and its commit. It bypasses the middleware chain, which its callers apply.
+func endorseAndSubmit(contract *client.Contract, timeout time.Duration, name string, args ...string) ([]byte, *client.Commit, error) {
+	proposal, err := contract.NewProposal(name, client.WithArguments(args...))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	transaction, err := proposal.EndorseWithContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	commit, err := transaction.Submit()
+	if err != nil {
+		return nil, nil, err
+	}
+	return transaction.Result(), commit, nil
+}
+
+// evaluateTransaction evaluates a transaction through the evaluate middleware chain, targeting the -query-org peers
+// when that flag is set. Encrypted addresses in the result are decrypted.
+func evaluateTransaction(contract *client.Contract, name string, args ...string) ([]byte, error) {
+	evaluate := func(name string, args ...string) ([]byte, error) {
+		return contract.Evaluate(name, evaluateOptions(args)...)
+	}
+
+	result, err := chain(evaluateMiddleware, evaluate)(name, args...)
+	if err != nil {
+		return nil, err
+	}
+	return decryptAddresses(result), nil
+}
+
+func evaluateOptions(args []string) []client.ProposalOption {
+	options := []client.ProposalOption{client.WithArguments(args...)}
+	if *queryOrg != "" {
+		options = append(options, client.WithEndorsingOrganizations(*queryOrg))
+	}
+	return options
+}
@@ -0,0 +1,58 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// recordErrors returns a middleware appending the error of every call it passes on to errs.
+func recordErrors(errs *[]error) Middleware {
+	return func(next Invoker) Invoker {
+		return func(name string, args ...string) ([]byte, error) {
+			result, err := next(name, args...)
+			*errs = append(*errs, err)
+			return result, err
+		}
+	}
+}
+
+func TestSubmitWithEndorseTimeoutChainsCommitFailures(t *testing.T) {
+	var seen []error
+	useSubmitMiddleware(t, recordErrors(&seen))
+	contract := newFakeContract(t, &fakeGateway{commitStatus: peer.TxValidationCode_MVCC_READ_CONFLICT})
+
+	_, err := submitWithEndorseTimeout(contract, time.Second, "DeletePersonsBulk", `["person1"]`, "false")
+
+	var commitErr *failedCommitError
+	if !errors.As(err, &commitErr) {
+		t.Fatalf("got %v, want the failed commit reported", err)
+	}
+	if len(seen) != 1 || !errors.As(seen[0], &commitErr) {
+		t.Errorf("the middleware saw %v, want the failed commit", seen)
+	}
+}
+
+func TestSubmitWithEndorseTimeoutWaitsForTheCommit(t *testing.T) {
+	var seen []error
+	useSubmitMiddleware(t, recordErrors(&seen))
+	contract := newFakeContract(t, &fakeGateway{commitStatus: peer.TxValidationCode_VALID})
+
+	result, err := submitWithEndorseTimeout(contract, time.Second, "DeletePersonsBulk", `["person1"]`, "false")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.BlockNumber != 1 || result.Status != peer.TxValidationCode_VALID {
+		t.Errorf("got %s with status %s, want the commit of block 1", result, result.Status)
+	}
+	if len(seen) != 1 || seen[0] != nil {
+		t.Errorf("the middleware saw %v, want a single successful call", seen)
+	}
+}
@@ -0,0 +1,133 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// lifecycle collects everything that must be released before the process exits, such as the audit log, event
+// listeners, the HTTP server and the gateway connection, and releases it in reverse order of registration, on a
+// normal exit as well as on SIGINT or SIGTERM.
+type lifecycle struct {
+	ctx  context.Context
+	stop context.CancelFunc
+
+	mutex   sync.Mutex
+	closers []*namedCloser
+	closed  bool
+}
+
+type namedCloser struct {
+	name  string
+	close func() error
+}
+
+// newLifecycle returns a lifecycle whose context is canceled when the process receives SIGINT or SIGTERM.
+func newLifecycle() *lifecycle {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	return &lifecycle{ctx: ctx, stop: stop}
+}
+
+// appLifecycle is the lifecycle of the running client.
+var appLifecycle = newLifecycle()
+
+// Context returns the root context of the process, canceled on SIGINT or SIGTERM. Long-running work should stop
+// when it is done.
+func (l *lifecycle) Context() context.Context {
+	return l.ctx
+}
+
+// Add registers a closer, released by Close.
+func (l *lifecycle) Add(name string, closer io.Closer) {
+	l.AddFunc(name, closer.Close)
+}
+
+// AddCancel registers the cancel function of a context, such as the one of an event listener, released by Close. The
+// returned function cancels the context at once and deregisters it, for work that ends before the process does, so
+// that a long session does not pile up the cancel functions of every listener it ever ran.
+func (l *lifecycle) AddCancel(name string, cancel context.CancelFunc) context.CancelFunc {
+	remove := l.AddFunc(name, func() error {
+		cancel()
+		return nil
+	})
+	return func() {
+		remove()
+		cancel()
+	}
+}
+
+// AddFunc registers a release function, called by Close. The returned function deregisters it without calling it,
+// and does nothing once Close has run.
+func (l *lifecycle) AddFunc(name string, close func() error) (remove func()) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.closed {
+		// too late to be released with the rest, release at once
+		if err := close(); err != nil {
+			log.Printf("failed to close %s: %s", name, err)
+		}
+		return func() {}
+	}
+	closer := &namedCloser{name: name, close: close}
+	l.closers = append(l.closers, closer)
+	return func() { l.remove(closer) }
+}
+
+// remove deregisters closer, if it is still registered.
+func (l *lifecycle) remove(closer *namedCloser) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for i, registered := range l.closers {
+		if registered == closer {
+			l.closers = append(l.closers[:i], l.closers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Close releases everything registered, the most recent first, logging failures and returning the first one. Calls
+// after the first do nothing.
+func (l *lifecycle) Close() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+
+	var firstErr error
+	for i := len(l.closers) - 1; i >= 0; i-- {
+		if err := l.closers[i].close(); err != nil {
+			log.Printf("failed to close %s: %s", l.closers[i].name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	l.closers = nil
+	l.stop()
+	return firstErr
+}
+
+// exitOnSignal releases the lifecycle and exits once a termination signal arrives. It lets an interrupted session
+// shut down cleanly wherever the main goroutine happens to be blocked.
+func (l *lifecycle) exitOnSignal() {
+	go func() {
+		<-l.ctx.Done()
+		log.Println("Interrupted, shutting down")
+		l.Close()
+		os.Exit(130)
+	}()
+}
@@ -0,0 +1,121 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLifecycleClosesInReverseOrder(t *testing.T) {
+	l := newLifecycle()
+	var closed []string
+	for _, name := range []string{"audit log", "listener", "connection"} {
+		name := name
+		l.AddFunc(name, func() error {
+			closed = append(closed, name)
+			return nil
+		})
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"connection", "listener", "audit log"}
+	if !reflect.DeepEqual(closed, want) {
+		t.Errorf("closed %v, want %v", closed, want)
+	}
+}
+
+func TestLifecycleCloseReturnsFirstFailureAndClosesTheRest(t *testing.T) {
+	l := newLifecycle()
+	first := errors.New("first")
+	closedAll := false
+	l.AddFunc("last to close", func() error {
+		closedAll = true
+		return errors.New("second")
+	})
+	l.AddFunc("first to close", func() error { return first })
+
+	if err := l.Close(); err != first {
+		t.Errorf("Close returned %v, want %v", err, first)
+	}
+	if !closedAll {
+		t.Error("a failure stopped the remaining closers")
+	}
+}
+
+func TestLifecycleCloseOnce(t *testing.T) {
+	l := newLifecycle()
+	calls := 0
+	l.AddFunc("closer", func() error {
+		calls++
+		return nil
+	})
+
+	l.Close()
+	l.Close()
+
+	if calls != 1 {
+		t.Errorf("closer called %d times, want once", calls)
+	}
+	if l.Context().Err() == nil {
+		t.Error("Close left the context of the lifecycle running")
+	}
+}
+
+func TestLifecycleReleasesLateRegistrationAtOnce(t *testing.T) {
+	l := newLifecycle()
+	l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l.AddCancel("late listener", cancel)
+
+	if ctx.Err() == nil {
+		t.Error("a cancel function registered after Close was not called")
+	}
+}
+
+func TestLifecycleCancelsRegisteredContexts(t *testing.T) {
+	l := newLifecycle()
+	ctx, cancel := context.WithCancel(context.Background())
+	l.AddCancel("listener", cancel)
+
+	l.Close()
+
+	if ctx.Err() == nil {
+		t.Error("Close did not cancel the registered context")
+	}
+}
+
+func TestLifecycleCancelReturnedByAddCancelDeregisters(t *testing.T) {
+	l := newLifecycle()
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel = l.AddCancel("listener", cancel)
+
+		cancel()
+
+		if ctx.Err() == nil {
+			t.Fatal("the returned function did not cancel the context")
+		}
+	}
+	if len(l.closers) != 0 {
+		t.Errorf("%d cancel functions are still registered after their listeners ended", len(l.closers))
+	}
+
+	kept := false
+	l.AddFunc("connection", func() error {
+		kept = true
+		return nil
+	})
+	l.Close()
+	if !kept {
+		t.Error("deregistering a listener dropped another closer")
+	}
+}
@@ -0,0 +1,172 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// getMetadataTransaction is the system transaction every contractapi chaincode answers with a description of its
+// contracts.
+const getMetadataTransaction = "org.hyperledger.fabric:GetMetadata"
+
+// chaincodeMetadata is the part of the contractapi metadata describing the transactions of each contract.
+type chaincodeMetadata struct {
+	Contracts map[string]contractMetadata `json:"contracts"`
+}
+
+type contractMetadata struct {
+	Name         string                `json:"name"`
+	Default      bool                  `json:"default"`
+	Transactions []transactionMetadata `json:"transactions"`
+}
+
+type transactionMetadata struct {
+	Name       string              `json:"name"`
+	Tag        []string            `json:"tag"`
+	Parameters []parameterMetadata `json:"parameters"`
+	Returns    *schemaMetadata     `json:"returns"`
+}
+
+type parameterMetadata struct {
+	Name   string         `json:"name"`
+	Schema schemaMetadata `json:"schema"`
+}
+
+// schemaMetadata is the subset of JSON schema used to describe parameter and return types.
+type schemaMetadata struct {
+	Type   string          `json:"type"`
+	Format string          `json:"format"`
+	Ref    string          `json:"$ref"`
+	Items  *schemaMetadata `json:"items"`
+}
+
+// typeName renders a schema as a short Go-like type name, such as string, int32 or []Person.
+func (schema *schemaMetadata) typeName() string {
+	switch {
+	case schema.Ref != "":
+		return schema.Ref[strings.LastIndex(schema.Ref, "/")+1:]
+	case schema.Type == "array" && schema.Items != nil:
+		return "[]" + schema.Items.typeName()
+	case schema.Format != "":
+		return schema.Format
+	default:
+		return schema.Type
+	}
+}
+
+// signature renders a transaction as name(parameters) returns.
+func (transaction *transactionMetadata) signature() string {
+	parameters := make([]string, len(transaction.Parameters))
+	for i, parameter := range transaction.Parameters {
+		parameters[i] = parameter.Name + " " + parameter.Schema.typeName()
+	}
+
+	signature := fmt.Sprintf("%s(%s)", transaction.Name, strings.Join(parameters, ", "))
+	if transaction.Returns != nil {
+		signature += " " + transaction.Returns.typeName()
+	}
+	return signature
+}
+
+// fetchMetadata evaluates the metadata system transaction of the chaincode.
+func fetchMetadata(contract *client.Contract) (*chaincodeMetadata, error) {
+	result, err := evaluateTransaction(contract, getMetadataTransaction)
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata chaincodeMetadata
+	if err := json.Unmarshal(result, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse chaincode metadata: %w", err)
+	}
+	return &metadata, nil
+}
+
+// metadataCommand lists the transactions the deployed chaincode declares, with their parameter and return types:
+// metadata
+func metadataCommand(contract *client.Contract, args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: metadata")
+	}
+
+	metadata, err := fetchMetadata(contract)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+
+	names := make([]string, 0, len(metadata.Contracts))
+	for name := range metadata.Contracts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		contractMetadata := metadata.Contracts[name]
+		if contractMetadata.Default {
+			fmt.Printf("%s (default contract)\n", name)
+		} else {
+			fmt.Println(name)
+		}
+		for _, transaction := range contractMetadata.Transactions {
+			fmt.Printf("  %s [%s]\n", transaction.signature(), strings.Join(transaction.Tag, ", "))
+		}
+	}
+	return nil
+}
+
+// unavailableMenuOptions holds the numbers of the menu options whose transactions the deployed chaincode lacks.
+var unavailableMenuOptions = map[int]bool{}
+
+// probeMenuOptions marks the menu options relying on transactions missing from the chaincode metadata as unavailable.
+// When the metadata cannot be read every option is left available, calls then fail the way they always did. It only
+// fails when the chaincode is not deployed at all, as no option could work then.
+func probeMenuOptions(contract *client.Contract) error {
+	metadata, err := fetchMetadata(contract)
+	if isChaincodeNotFound(err) {
+		return err
+	}
+	if err != nil {
+		log.Printf("Could not read the chaincode metadata, assuming every menu option is available: %v", err)
+		return nil
+	}
+
+	available := metadata.defaultTransactions()
+	for _, option := range menuOptions {
+		for _, transaction := range option.transactions {
+			if invoked, ok := invokedTransaction(transaction); !ok || !available[invoked] {
+				unavailableMenuOptions[option.number] = true
+			}
+		}
+	}
+	return nil
+}
+
+// menuOptionAvailable reports whether the menu option with given number can be used on this network.
+func menuOptionAvailable(number int) bool {
+	return !unavailableMenuOptions[number]
+}
+
+// defaultTransactions returns the names of the transactions of the default contract, the ones callable without a
+// contract name prefix.
+func (metadata *chaincodeMetadata) defaultTransactions() map[string]bool {
+	names := make(map[string]bool)
+	for _, contractMetadata := range metadata.Contracts {
+		if !contractMetadata.Default {
+			continue
+		}
+		for _, transaction := range contractMetadata.Transactions {
+			names[transaction.Name] = true
+		}
+	}
+	return names
+}
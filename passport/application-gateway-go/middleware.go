@@ -0,0 +1,335 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+)
+
+// Invoker runs a named transaction with its arguments. Every submit and evaluate made by the client goes through a
+// chain of Middleware around the Invoker that actually reaches the gateway, so cross-cutting concerns such as rate
+// limiting, retries, metrics and auditing live in one place instead of in each helper.
+type Invoker func(name string, args ...string) ([]byte, error)
+
+// Middleware wraps an Invoker with additional behaviour.
+type Middleware func(next Invoker) Invoker
+
+var (
+	maxRetries   = flag.Int("retries", 0, "number of times a transaction failing with a transient gRPC error is retried")
+	retryBackoff = flag.Duration("retry-backoff", 500*time.Millisecond, "delay before the first retry, doubled for every further one")
+	metrics      = flag.Bool("metrics", false, "print per-transaction call counts, errors and latencies on exit")
+	auditLogPath = flag.String("audit-log", "", "append a JSON line for every submitted transaction, naming the persons it concerns, to this file")
+)
+
+// The middleware chains built by initInvokers from the command line flags, outermost first.
+var (
+	submitMiddleware   []Middleware
+	evaluateMiddleware []Middleware
+)
+
+// initInvokers builds the submit and evaluate middleware chains from the parsed command line flags. What the chains
+// hold open is registered with appLifecycle.
+func initInvokers(config *Config) error {
+	// outermost, so the error is classified once whatever the other middleware did with it
+	notFound := withChaincodeNotFound(config.Chaincode, config.Channel)
+	submitChain := []Middleware{notFound, withSubmissionRejection}
+	evaluateChain := []Middleware{notFound}
+
+	if *auditLogPath != "" {
+		file, err := os.OpenFile(*auditLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to open audit log: %w", err)
+		}
+		appLifecycle.AddFunc("audit log", func() error {
+			// flush to disk, a record lost on a crash is a submit nobody can account for
+			if err := file.Sync(); err != nil {
+				file.Close()
+				return err
+			}
+			return file.Close()
+		})
+		submitChain = append(submitChain, withAudit(file))
+	}
+
+	if *readCacheTTL > 0 {
+		// ahead of the rest of the evaluate chain, so a cache hit skips the metrics, retries and rate limit. On submits
+		// the cache is only cleared after the call, which the audit and rejection middleware around it do not affect.
+		cache := newReadCache(*readCacheTTL)
+		submitChain = append(submitChain, withCacheInvalidation(cache))
+		evaluateChain = append(evaluateChain, withReadCache(cache))
+	}
+
+	if *metrics {
+		stats := newCallMetrics()
+		appLifecycle.AddFunc("metrics", func() error {
+			stats.print(os.Stdout)
+			return nil
+		})
+		submitChain = append(submitChain, withMetrics(stats))
+		evaluateChain = append(evaluateChain, withMetrics(stats))
+	}
+
+	if *maxRetries > 0 {
+		submitChain = append(submitChain, withRetry(*maxRetries, *retryBackoff))
+		evaluateChain = append(evaluateChain, withRetry(*maxRetries, *retryBackoff))
+	}
+
+	if *namespace != "" {
+		submitChain = append(submitChain, withNamespace(*namespace))
+		evaluateChain = append(evaluateChain, withNamespace(*namespace))
+	}
+
+	// innermost, so every retry attempt draws from the budget too
+	submitChain = append(submitChain, withRateLimit(submitLimiter))
+	evaluateChain = append(evaluateChain, withRateLimit(evaluateLimiter))
+
+	submitMiddleware = submitChain
+	evaluateMiddleware = evaluateChain
+	return nil
+}
+
+// chain wraps invoker in the given middleware, the first one being the outermost.
+func chain(middleware []Middleware, invoker Invoker) Invoker {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		invoker = middleware[i](invoker)
+	}
+	return invoker
+}
+
+// withRateLimit waits for the limiter before every call, giving up when the client shuts down.
+func withRateLimit(limiter *rate.Limiter) Middleware {
+	return func(next Invoker) Invoker {
+		return func(name string, args ...string) ([]byte, error) {
+			if err := limiter.Wait(appLifecycle.Context()); err != nil {
+				return nil, err
+			}
+			return next(name, args...)
+		}
+	}
+}
+
+// withSubmissionRejection turns the errors of submits the network refused to order into a submissionRejectedError.
+func withSubmissionRejection(next Invoker) Invoker {
+	return func(name string, args ...string) ([]byte, error) {
+		result, err := next(name, args...)
+		if err != nil && isSubmissionRejected(err) {
+			return result, &submissionRejectedError{err: err}
+		}
+		return result, err
+	}
+}
+
+// withRetry retries calls failing with a transient error, waiting backoff before the first retry and doubling the
+// wait for every further one. Each retry is logged, so that a slow call can be told from a struggling network. A
+// shutdown ends the wait, returning the last error.
+func withRetry(retries int, backoff time.Duration) Middleware {
+	return func(next Invoker) Invoker {
+		return func(name string, args ...string) ([]byte, error) {
+			delay := backoff
+			for attempt := 0; ; attempt++ {
+				result, err := next(name, args...)
+				if err == nil || attempt == retries || !isTransient(err) {
+					return result, err
+				}
+				log.Printf("%s failed with %s, retry %d of %d in %s", name, gatewayStatus(err).Code(), attempt+1, retries, delay)
+				select {
+				case <-time.After(delay):
+				case <-appLifecycle.Context().Done():
+					return result, err
+				}
+				delay *= 2
+			}
+		}
+	}
+}
+
+// isTransient reports whether a failed call can safely be made again. Only failures that happened before the
+// transaction reached the orderer qualify: retrying after that could apply the transaction twice.
+func isTransient(err error) bool {
+	var submitErr *client.SubmitError
+	var commitStatusErr *client.CommitStatusError
+	var commitErr *client.CommitError
+	var failedCommitErr *failedCommitError
+	if errors.As(err, &submitErr) || errors.As(err, &commitStatusErr) || errors.As(err, &commitErr) || errors.As(err, &failedCommitErr) {
+		return false
+	}
+
+	switch gatewayStatus(err).Code() {
+	case codes.Unavailable, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
+
+// callMetrics accumulates call counts, errors and latencies per transaction name.
+type callMetrics struct {
+	mutex sync.Mutex
+	calls map[string]*callStats
+}
+
+type callStats struct {
+	count   int
+	errors  int
+	elapsed time.Duration
+	max     time.Duration
+}
+
+func newCallMetrics() *callMetrics {
+	return &callMetrics{calls: make(map[string]*callStats)}
+}
+
+func (metrics *callMetrics) record(name string, elapsed time.Duration, err error) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	stats, ok := metrics.calls[name]
+	if !ok {
+		stats = &callStats{}
+		metrics.calls[name] = stats
+	}
+	stats.count++
+	if err != nil {
+		stats.errors++
+	}
+	stats.elapsed += elapsed
+	if elapsed > stats.max {
+		stats.max = elapsed
+	}
+}
+
+func (metrics *callMetrics) print(out io.Writer) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+
+	names := make([]string, 0, len(metrics.calls))
+	for name := range metrics.calls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "transaction\tcalls\terrors\tavg\tmax")
+	for _, name := range names {
+		stats := metrics.calls[name]
+		average := stats.elapsed / time.Duration(stats.count)
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%s\t%s\n", name, stats.count, stats.errors, average.Round(time.Millisecond), stats.max.Round(time.Millisecond))
+	}
+	writer.Flush()
+}
+
+// withMetrics records the outcome and latency of every call.
+func withMetrics(metrics *callMetrics) Middleware {
+	return func(next Invoker) Invoker {
+		return func(name string, args ...string) ([]byte, error) {
+			start := time.Now()
+			result, err := next(name, args...)
+			metrics.record(name, time.Since(start), err)
+			return result, err
+		}
+	}
+}
+
+// auditRecord is one line of the audit log. The arguments of the transaction are left out, they hold personal data
+// such as addresses and phones: only the ids of the persons it names are kept.
+type auditRecord struct {
+	Time        time.Time `json:"time"`
+	Transaction string    `json:"transaction"`
+	IDs         []string  `json:"ids,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// idArguments is the number of leading arguments holding person ids, by transaction.
+var idArguments = map[string]int{
+	"CreatePerson":          1,
+	"UpdatePerson":          1,
+	"UpdatePersonIfMatch":   1,
+	"DeletePerson":          1,
+	"DeletePersonForce":     1,
+	"AddPersonTag":          1,
+	"RemovePersonTag":       1,
+	"SetPassportExpiry":     1,
+	"SetPersonExpiresAt":    1,
+	"UnlinkSpouse":          1,
+	"RestoreArchivedPerson": 1,
+	"CreateSpouseLink":      2,
+	"MarryPersons":          2,
+	"DivorcePersons":        2,
+}
+
+// auditedIDs returns the ids of the persons a call names, taken from its arguments or, for CreatePersonAutoID, from
+// its result.
+func auditedIDs(name string, args []string, result []byte, err error) []string {
+	var ids []string
+	switch name {
+	case "CreatePersonsBulk", "UpdatePersonsBulk":
+		var persons []Person
+		if len(args) > 0 && json.Unmarshal([]byte(args[0]), &persons) == nil {
+			for _, person := range persons {
+				ids = append(ids, person.ID)
+			}
+		}
+	case "CreatePersonWithDetails", "UpdatePersonWithDetails":
+		var person Person
+		if len(args) > 0 && json.Unmarshal([]byte(args[0]), &person) == nil {
+			ids = []string{person.ID}
+		}
+	case "DeletePersonsBulk":
+		if len(args) > 0 {
+			json.Unmarshal([]byte(args[0]), &ids)
+		}
+	case "CreatePersonAutoID":
+		if err == nil {
+			ids = []string{string(result)}
+		}
+	default:
+		if count, ok := idArguments[name]; ok && len(args) >= count {
+			ids = append(ids, args[:count]...)
+		}
+	}
+
+	for i, id := range ids {
+		ids[i] = validation.Normalize(id)
+	}
+	return ids
+}
+
+// withAudit appends a JSON line describing every call and its outcome to out.
+func withAudit(out io.Writer) Middleware {
+	var mutex sync.Mutex
+	return func(next Invoker) Invoker {
+		return func(name string, args ...string) ([]byte, error) {
+			result, err := next(name, args...)
+
+			record := auditRecord{Time: time.Now().UTC(), Transaction: name, IDs: auditedIDs(name, args, result, err)}
+			if err != nil {
+				record.Error = err.Error()
+			}
+			recordJSON, marshalErr := json.Marshal(record)
+			if marshalErr == nil {
+				mutex.Lock()
+				out.Write(append(recordJSON, '\n'))
+				mutex.Unlock()
+			}
+
+			return result, err
+		}
+	}
+}
@@ -0,0 +1,99 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeInvoker records the calls that reach it and answers them with result and err.
+type fakeInvoker struct {
+	calls  []string
+	result []byte
+	err    error
+}
+
+func (fake *fakeInvoker) invoke(name string, args ...string) ([]byte, error) {
+	fake.calls = append(fake.calls, name)
+	return fake.result, fake.err
+}
+
+// auditRecords parses the lines written by withAudit.
+func auditRecords(t *testing.T, log *bytes.Buffer) []auditRecord {
+	t.Helper()
+	var records []auditRecord
+	for _, line := range strings.Split(strings.TrimSpace(log.String()), "\n") {
+		var record auditRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("audit line %q: %s", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestAuditLogsIDsWithoutPersonalData(t *testing.T) {
+	var log bytes.Buffer
+	fake := &fakeInvoker{}
+	invoke := withAudit(&log)(fake.invoke)
+
+	invoke("CreatePerson", " person1", "0510 228148", "Ivan", "Petrov", "Moscow", "Lenina 1", "88005553535", "false")
+	invoke("CreatePersonsBulk", `[{"id":"person2","address":"Lenina 2"},{"id":"person3","phone":"88005553535"}]`)
+	invoke("MarryPersons", "person1", "person2")
+	invoke("DeletePersonForce", "person2 ")
+	invoke("PurgeExpired")
+
+	if strings.Contains(log.String(), "Lenina") || strings.Contains(log.String(), "555") {
+		t.Fatalf("audit log holds personal data: %s", log.String())
+	}
+	want := [][]string{{"person1"}, {"person2", "person3"}, {"person1", "person2"}, {"person2"}, nil}
+	for i, record := range auditRecords(t, &log) {
+		if !reflect.DeepEqual(record.IDs, want[i]) {
+			t.Errorf("%s logged ids %v, want %v", record.Transaction, record.IDs, want[i])
+		}
+	}
+}
+
+func TestAuditLogsCreatedAutoID(t *testing.T) {
+	var log bytes.Buffer
+	fake := &fakeInvoker{result: []byte("person7")}
+
+	withAudit(&log)(fake.invoke)("CreatePersonAutoID", "0510 228148", "Ivan")
+
+	if ids := auditRecords(t, &log)[0].IDs; !reflect.DeepEqual(ids, []string{"person7"}) {
+		t.Errorf("logged ids %v, want the created id", ids)
+	}
+}
+
+func TestFailedCreatesReadsAuditLog(t *testing.T) {
+	var log bytes.Buffer
+	fake := &fakeInvoker{err: errors.New("endorsement failed")}
+	invoke := withAudit(&log)(fake.invoke)
+	invoke("CreatePersonsBulk", `[{"id":"person1"},{"id":"person2"}]`)
+	invoke("CreatePerson", "person3")
+	fake.err = nil
+	invoke("CreatePerson", "person1")
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	if err := ioutil.WriteFile(path, log.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	failed, err := failedCreates(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{"person2": true, "person3": true}
+	if !reflect.DeepEqual(failed, want) {
+		t.Errorf("failed creates %v, want %v", failed, want)
+	}
+}
@@ -0,0 +1,51 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// namespace confines the client to the persons of one tenant of a chaincode shared by several, stored apart from the
+// un-namespaced persons and from those of other namespaces.
+var namespace = flag.String("namespace", "", "work on the persons of this namespace only, which supports creating, listing and reading persons")
+
+// namespaceTransactions maps the transactions available within a namespace to their namespaced counterparts, which
+// take the namespace as first argument.
+var namespaceTransactions = map[string]string{
+	"CreatePerson":  "CreatePersonNS",
+	"ReadPerson":    "ReadPersonNS",
+	"PersonExists":  "PersonExistsNS",
+	"GetAllPersons": "GetAllPersonsNS",
+}
+
+// withNamespace turns every call into its counterpart within namespace ns, refusing the transactions that have none
+// rather than letting them act outside the namespace. System chaincode calls such as the metadata query pass through.
+func withNamespace(ns string) Middleware {
+	return func(next Invoker) Invoker {
+		return func(name string, args ...string) ([]byte, error) {
+			if strings.Contains(name, ":") {
+				return next(name, args...)
+			}
+			namespaced, ok := namespaceTransactions[name]
+			if !ok {
+				return nil, fmt.Errorf("%s is not available within namespace %s", name, ns)
+			}
+			return next(namespaced, append([]string{ns}, args...)...)
+		}
+	}
+}
+
+// invokedTransaction returns the transaction actually invoked for the given one, its namespaced counterpart when
+// -namespace is set. It reports false when the transaction is not available within the namespace.
+func invokedTransaction(transaction string) (string, bool) {
+	if *namespace == "" {
+		return transaction, true
+	}
+	namespaced, ok := namespaceTransactions[transaction]
+	return namespaced, ok
+}
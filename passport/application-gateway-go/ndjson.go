@@ -0,0 +1,177 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// maxNDJSONLine is the longest line import-ndjson accepts, far above any valid person.
+const maxNDJSONLine = 1024 * 1024
+
+// exportNDJSONCommand writes every person as a compact JSON document per line, as each page arrives:
+// export-ndjson [-page-size n] [-o file]
+//
+// Persons are read like every other query, so with -encryption-key set the file holds their addresses in clear text.
+// import-ndjson encrypts them again with the key it is given, so the file loads back as is.
+func exportNDJSONCommand(contract *client.Contract, args []string) error {
+	flags := flag.NewFlagSet("export-ndjson", flag.ContinueOnError)
+	pageSize := flags.Int("page-size", 100, "number of persons fetched per request")
+	output := flags.String("o", "", "write to this file instead of standard output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: export-ndjson [-page-size n] [-o file]")
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create export file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	writer := bufio.NewWriter(out)
+	// Encode terminates every document with a newline and does not indent
+	encoder := json.NewEncoder(writer)
+
+	count := 0
+	var encodeErr error
+	err := streamAllPersons(contract, *pageSize, func(person *Person) {
+		if encodeErr != nil {
+			return
+		}
+		if encodeErr = encoder.Encode(person); encodeErr == nil {
+			count++
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if encodeErr != nil {
+		return fmt.Errorf("failed to write person: %w", encodeErr)
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	// the summary goes to stderr so that stdout stays a clean stream of persons
+	fmt.Fprintf(os.Stderr, "*** %d persons exported\n", count)
+	return nil
+}
+
+// importNDJSONCommand creates the persons of a file holding a JSON document per line, such as export-ndjson writes,
+// reading it line by line and submitting CreatePersonsBulk batches: import-ndjson [-batch n] <file>
+//
+// Each batch is atomic, the import as a whole is not: a failing batch stops the import, the batches before it stay
+// committed. Tags, expiry and spouse links are not part of a create and are not restored. With -encryption-key set the
+// addresses are encrypted before they are sent, but for those the file holds encrypted already, as export-ndjson writes
+// them when run without the key.
+func importNDJSONCommand(contract *client.Contract, args []string) error {
+	flags := flag.NewFlagSet("import-ndjson", flag.ContinueOnError)
+	batchSize := flags.Int("batch", 100, "number of persons created per transaction")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || *batchSize <= 0 {
+		return errors.New("usage: import-ndjson [-batch n] <file>")
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("failed to open import file: %w", err)
+	}
+	defer file.Close()
+
+	imported := 0
+	var batch []Person
+	firstLine := 0
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := importBatch(contract, batch); err != nil {
+			return fmt.Errorf("failed to import the batch starting at line %d, %d persons were imported before it: %w", firstLine, imported, err)
+		}
+		imported += len(batch)
+		fmt.Printf("Imported %d persons\n", imported)
+		batch = batch[:0]
+		return nil
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxNDJSONLine)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var person Person
+		if err := json.Unmarshal(scanner.Bytes(), &person); err != nil {
+			return fmt.Errorf("line %d: failed to parse person: %w", line, err)
+		}
+		if len(batch) == 0 {
+			firstLine = line
+		}
+		batch = append(batch, person)
+		if len(batch) == *batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read import file: %w", err)
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("*** %d persons imported\n", imported)
+	return nil
+}
+
+// importBatch validates a batch of persons and creates them in one transaction.
+func importBatch(contract *client.Contract, persons []Person) error {
+	if err := validatePersons(persons); err != nil {
+		return err
+	}
+	if err := encryptImportedAddresses(persons); err != nil {
+		return err
+	}
+	personsJSON, err := json.Marshal(persons)
+	if err != nil {
+		return err
+	}
+	_, err = submitWithEndorseTimeout(contract, *bulkEndorseTimeout, "CreatePersonsBulk", string(personsJSON))
+	return err
+}
+
+// encryptImportedAddresses encrypts the addresses of imported persons that are not encrypted yet. Without
+// -encryption-key they are left unchanged.
+func encryptImportedAddresses(persons []Person) error {
+	for i := range persons {
+		if strings.HasPrefix(persons[i].Address, encryptedPrefix) {
+			continue
+		}
+		address, err := encryptField(persons[i].Address)
+		if err != nil {
+			return fmt.Errorf("person %s: %w", persons[i].ID, err)
+		}
+		persons[i].Address = address
+	}
+	return nil
+}
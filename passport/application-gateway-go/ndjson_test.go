@@ -0,0 +1,63 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"strings"
+	"testing"
+)
+
+// useTestCipher sets a field encryption key for the duration of the test.
+func useTestCipher(t *testing.T) {
+	t.Helper()
+	block, err := aes.NewCipher(make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := fieldCipher
+	fieldCipher = aead
+	t.Cleanup(func() { fieldCipher = saved })
+}
+
+func TestImportEncryptsClearAddresses(t *testing.T) {
+	useTestCipher(t)
+	encrypted, err := encryptField("Lenina 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	persons := []Person{{ID: "person1", Address: "Lenina 1"}, {ID: "person2", Address: encrypted}}
+
+	if err := encryptImportedAddresses(persons); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(persons[0].Address, encryptedPrefix) {
+		t.Errorf("clear address imported as %q, want it encrypted", persons[0].Address)
+	}
+	if address, err := decryptField(persons[0].Address); err != nil || address != "Lenina 1" {
+		t.Errorf("imported address decrypts to %q, %v", address, err)
+	}
+	if persons[1].Address != encrypted {
+		t.Errorf("encrypted address imported as %q, want it sent as is", persons[1].Address)
+	}
+}
+
+func TestImportLeavesAddressesWithoutKey(t *testing.T) {
+	persons := []Person{{ID: "person1", Address: "Lenina 1"}}
+
+	if err := encryptImportedAddresses(persons); err != nil {
+		t.Fatal(err)
+	}
+
+	if persons[0].Address != "Lenina 1" {
+		t.Errorf("address imported as %q without a key, want it unchanged", persons[0].Address)
+	}
+}
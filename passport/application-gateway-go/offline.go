@@ -0,0 +1,155 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"google.golang.org/grpc"
+)
+
+// newOfflineGateway connects a gateway holding the client identity but no signing implementation, so that every
+// proposal, transaction and commit status request made through it has to be signed by the caller, as submitOffline
+// does. The private key never needs to be available to the gateway connection.
+func newOfflineGateway(id identity.Identity, clientConnection *grpc.ClientConn) (*client.Gateway, error) {
+	return client.Connect(
+		id,
+		client.WithClientConnection(clientConnection),
+		client.WithEvaluateTimeout(5*time.Second),
+		client.WithEndorseTimeout(15*time.Second),
+		client.WithSubmitTimeout(5*time.Second),
+		client.WithCommitStatusTimeout(1*time.Minute),
+	)
+}
+
+// submitOffline submits a transaction through a contract of an offline gateway, one connected without a signing
+// implementation. Each of the three messages the flow sends is built unsigned, its digest passed to sign, and the
+// signature attached before sending, so sign may stand for an HSM or an air-gapped machine the digests are carried to.
+func submitOffline(gateway *client.Gateway, contract *client.Contract, sign identity.Sign, name string, args ...string) (*SubmitResult, error) {
+	var status *client.Status
+	submit := func(name string, args ...string) ([]byte, error) {
+		unsignedProposal, err := contract.NewProposal(name, client.WithArguments(args...))
+		if err != nil {
+			return nil, err
+		}
+		proposalBytes, err := unsignedProposal.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		proposalSignature, err := signDigest(sign, "proposal", unsignedProposal.Digest())
+		if err != nil {
+			return nil, err
+		}
+		proposal, err := gateway.NewSignedProposal(proposalBytes, proposalSignature)
+		if err != nil {
+			return nil, err
+		}
+
+		unsignedTransaction, err := proposal.Endorse()
+		if err != nil {
+			return nil, err
+		}
+		transactionBytes, err := unsignedTransaction.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		transactionSignature, err := signDigest(sign, "transaction", unsignedTransaction.Digest())
+		if err != nil {
+			return nil, err
+		}
+		transaction, err := gateway.NewSignedTransaction(transactionBytes, transactionSignature)
+		if err != nil {
+			return nil, err
+		}
+
+		unsignedCommit, err := transaction.Submit()
+		if err != nil {
+			return nil, err
+		}
+		commitBytes, err := unsignedCommit.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		commitSignature, err := signDigest(sign, "commit status request", unsignedCommit.Digest())
+		if err != nil {
+			return nil, err
+		}
+		commit, err := gateway.NewSignedCommit(commitBytes, commitSignature)
+		if err != nil {
+			return nil, err
+		}
+
+		status, err = awaitCommit(commit)
+		if err != nil {
+			return nil, err
+		}
+		return transaction.Result(), nil
+	}
+
+	result, err := chain(submitMiddleware, submit)(name, args...)
+	if err != nil {
+		return nil, err
+	}
+	return newSubmitResult(result, status), nil
+}
+
+// signDigest signs the digest of a message of the offline flow, printing it as it would be handed to an external signer.
+func signDigest(sign identity.Sign, message string, digest []byte) ([]byte, error) {
+	fmt.Printf("Signing %s digest %s\n", message, hex.EncodeToString(digest))
+	signature, err := sign(digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign %s: %w", message, err)
+	}
+	return signature, nil
+}
+
+// submitOfflineInteractive prompts for a transaction and its arguments and submits it through an offline gateway,
+// signing with sign.
+func submitOfflineInteractive(network *client.Network, contract *client.Contract, id identity.Identity, clientConnection *grpc.ClientConn, sign identity.Sign) error {
+	name, err := promptWord("Transaction name: ")
+	if err != nil {
+		return err
+	}
+	if len(name) == 0 {
+		fmt.Println("transaction name is required!")
+		return nil
+	}
+
+	line, err := promptLine("Arguments (space separated, or a JSON array of strings): ")
+	if err != nil {
+		return err
+	}
+	args, err := parseRawArgs(line)
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
+
+	gateway, err := newOfflineGateway(id, clientConnection)
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
+	defer gateway.Close()
+	offlineContract := gateway.GetNetwork(network.Name()).GetContract(contract.ChaincodeName())
+
+	result, err := submitOffline(gateway, offlineContract, sign, name, args...)
+	if err != nil {
+		if !printChaincodeError(err) {
+			printGatewayError(err)
+		}
+		return nil
+	}
+
+	fmt.Printf("*** Transaction %s\n", result)
+	if len(result.Result) > 0 {
+		fmt.Println(formatJSON(result.Result))
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// identityOpCount is the number of persons created by one client identity, as GetTopCreators reports it.
+type identityOpCount struct {
+	MSPID  string `json:"mspId"`
+	CertID string `json:"certId"`
+	Count  int    `json:"count"`
+}
+
+// opCountCommand prints how many persons an identity has created: op-count <msp-id> <cert-id>. The cert id is the
+// one top-creators lists.
+func opCountCommand(contract *client.Contract, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: op-count <msp-id> <cert-id>")
+	}
+
+	result, err := evaluateTransaction(contract, "GetIdentityOpCount", args[0], args[1])
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+
+	fmt.Printf("%s of %s created %s persons\n", describeCertID(args[1]), args[0], result)
+	return nil
+}
+
+// topCreatorsCommand lists the identities that created the most persons, which requires an administrator identity:
+// top-creators [-limit n]
+func topCreatorsCommand(contract *client.Contract, args []string) error {
+	flags := flag.NewFlagSet("top-creators", flag.ContinueOnError)
+	limit := flags.Int("limit", 10, "maximum number of identities listed")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: top-creators [-limit n]")
+	}
+
+	result, err := evaluateTransaction(contract, "GetTopCreators", strconv.Itoa(*limit))
+	if err != nil {
+		return fmt.Errorf("failed to evaluate transaction: %w", err)
+	}
+	var counts []identityOpCount
+	if err := json.Unmarshal(result, &counts); err != nil {
+		return fmt.Errorf("failed to parse result: %w", err)
+	}
+
+	if len(counts) == 0 {
+		fmt.Println("No persons have been created yet")
+		return nil
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "persons\tmsp\tidentity\tcert id")
+	for _, count := range counts {
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\n", count.Count, count.MSPID, describeCertID(count.CertID), count.CertID)
+	}
+	return writer.Flush()
+}
+
+// describeCertID returns the readable form of a client identity id, which the chaincode's client identity library
+// builds as the base64 encoding of "x509::<subject>::<issuer>". Ids of another form are returned as they are.
+func describeCertID(certID string) string {
+	decoded, err := base64.StdEncoding.DecodeString(certID)
+	if err != nil {
+		return certID
+	}
+	return string(decoded)
+}
@@ -7,65 +7,128 @@ SPDX-License-Identifier: Apache-2.0
 package main
 
 import (
-	"bufio"
 	"bytes"
-	"context"
+	"crypto"
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"github.com/hyperledger/fabric-gateway/pkg/client"
 	"github.com/hyperledger/fabric-gateway/pkg/identity"
-	gwproto "github.com/hyperledger/fabric-protos-go/gateway"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/status"
 	"io/ioutil"
 	"log"
 	"os"
 	"path"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// Environment variables carrying PEM content directly. When set, they take precedence over the configured file paths,
+// which is convenient for containerized deployments where secrets are injected as variables rather than files.
 const (
-	mspID         = "Org1MSP"
-	cryptoPath    = "../../../fabric-samples-mod/test-network/organizations/peerOrganizations/org1.example.com"
-	certPath      = cryptoPath + "/users/User1@org1.example.com/msp/signcerts/cert.pem"
-	keyPath       = cryptoPath + "/users/User1@org1.example.com/msp/keystore/"
-	tlsCertPath   = cryptoPath + "/peers/peer0.org1.example.com/tls/ca.crt"
-	peerEndpoint  = "localhost:7051"
-	gatewayPeer   = "peer0.org1.example.com"
-	channelName   = "mychannel"
-	chaincodeName = "passport"
+	certPEMEnv  = "FABRIC_CERT_PEM"
+	keyPEMEnv   = "FABRIC_KEY_PEM"
+	tlsCAPEMEnv = "FABRIC_TLS_CA_PEM"
 )
 
 type Person struct {
-	ID      string `json:"id"`
-	Serial  string `json:"passport"`
-	Name    string `json:"name"`
-	Surname string `json:"surname"`
-	City    string `json:"city"`
-	Address string `json:"address"`
-	Phone   string `json:"phone"`
-	Married bool   `json:"married"`
+	ID        string   `json:"id"`
+	Serial    string   `json:"passport"`
+	Name      string   `json:"name"`
+	Surname   string   `json:"surname"`
+	City      string   `json:"city"`
+	Address   string   `json:"address"`
+	Phone     string   `json:"phone"`
+	Married   bool     `json:"married"`
+	Expiry    string   `json:"expiry,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	Reference string   `json:"reference,omitempty"`
+	SpouseID  string   `json:"spouseId,omitempty"`
+	Gender    string   `json:"gender,omitempty"`
+	ExpiresAt string   `json:"expiresAt,omitempty"`
+	Birthdate string   `json:"birthdate,omitempty"`
+}
+
+// VersionedPerson is a person as returned by ReadPersonWithETag, together with the ETag of its stored representation.
+type VersionedPerson struct {
+	Person
+	ETag string `json:"etag"`
 }
 
 type Update struct {
 	Tx        string    `json:"tx"`
 	Timestamp time.Time `json:"timestamp"`
 	Data      *Person   `json:"data"`
+	IsDelete  bool      `json:"isDelete,omitempty"`
+}
+
+// InitResult is the result of InitLedger and ReseedLedger.
+type InitResult struct {
+	Created     []string `json:"created"`
+	Overwritten []string `json:"overwritten"`
+	Skipped     []string `json:"skipped"`
+}
+
+// PersonHistory is the result of GetRecentPersonHistory, most recent update first.
+type PersonHistory struct {
+	Updates    []Update `json:"updates"`
+	Truncated  bool     `json:"truncated"`
+	Suggestion string   `json:"suggestion,omitempty"`
 }
 
 func main() {
+	os.Exit(run())
+}
+
+// run drives the application and returns the process exit code once every deferred cleanup has run.
+func run() int {
+	flag.Parse()
+	initRateLimiters()
+
+	config, err := resolveConfig(*configPath)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	if *debugConfig {
+		logConfig(config)
+	}
+
+	// commands inspecting the configuration must not depend on it being valid, so they run before any credential is
+	// loaded
+	if handled, err := runOfflineCommand(config, flag.Args()); handled {
+		if err != nil {
+			fmt.Println(err)
+			return 1
+		}
+		return 0
+	}
+
+	defer appLifecycle.Close()
+	appLifecycle.exitOnSignal()
+
+	if err := initFieldEncryption(); err != nil {
+		panic(err)
+	}
+	if err := initInvokers(config); err != nil {
+		panic(err)
+	}
+	stdin = newLineReader(os.Stdin, *idleTimeout)
+
 	log.Println("============ application-golang starts ============")
 
 	// The gRPC client connection should be shared by all Gateway connections to this endpoint
-	clientConnection := newGrpcConnection()
-	defer clientConnection.Close()
+	clientConnection := newGrpcConnection(config)
+	appLifecycle.Add("gRPC connection", clientConnection)
 
-	id := newIdentity()
-	sign := newSign()
+	id := newIdentity(config)
+	sign := newSignFromFlags(config)
 
 	// Create a Gateway connection for a specific client identity
 	gateway, err := client.Connect(
@@ -81,70 +144,265 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
-	defer gateway.Close()
+	appLifecycle.Add("gateway", gateway)
+
+	network := gateway.GetNetwork(config.Channel)
+	contract := network.GetContract(config.Chaincode)
 
-	network := gateway.GetNetwork(channelName)
-	contract := network.GetContract(chaincodeName)
+	if flag.NArg() > 0 {
+		err := runCommand(config, network, contract, flag.Args())
+		if err == nil {
+			err = awaitPendingCommits()
+		}
+		if err != nil {
+			printGatewayError(err)
+			return 1
+		}
+		return 0
+	}
 
+	if err := probeMenuOptions(contract); err != nil {
+		fmt.Println(err)
+		return 1
+	}
 	printHelp()
 	for {
 		fmt.Print("\ncmd: ")
-		var cmd int
-		fmt.Scanf("%d", &cmd)
-		switch cmd {
-		case 9:
-			return
-		case 1:
-			createPerson(contract)
-		case 2:
-			getAllPersons(contract)
-		case 3:
-			fmt.Print("Enter id: ")
-			var personId string
-			fmt.Scanf("%s", &personId)
-			personBytes := readPersonByID(contract, personId)
-			if len(personBytes) != 0 {
-				fmt.Println(formatJSON(personBytes))
+		word, err := readWord()
+		if err != nil {
+			printSessionEnd(err)
+			return 0
+		}
+		cmd, _ := strconv.Atoi(word)
+		if !menuOptionAvailable(cmd) {
+			fmt.Println("This option needs chaincode transactions the network does not provide, the deployed chaincode is probably older than this client")
+			continue
+		}
+		if cmd == 9 {
+			if err := awaitPendingCommits(); err != nil {
+				fmt.Println(err)
+				return 1
 			}
-		case 4:
-			fmt.Print("Enter id: ")
-			var personId string
-			fmt.Scanf("%s", &personId)
-			updatePerson(contract, personId)
-		case 5:
-			fmt.Print("Enter id: ")
-			var personId string
-			fmt.Scanf("%s", &personId)
-			getPersonHistory(contract, personId)
-		default:
-			println("Unknown cmd! Try one more time")
-			printHelp()
+			return 0
+		}
+		if err := runMenuOption(cmd, network, contract, id, clientConnection, sign); err != nil {
+			printSessionEnd(err)
+			return 0
 		}
 	}
 
 }
 
+// runMenuOption prompts for the input of a menu option and runs it. Failures of the option itself are printed, an
+// error is only returned when the input of the session ends.
+func runMenuOption(cmd int, network *client.Network, contract *client.Contract, id identity.Identity, clientConnection *grpc.ClientConn, sign identity.Sign) error {
+	switch cmd {
+	case 1:
+		return createPerson(contract)
+	case 2:
+		getAllPersons(contract)
+	case 3:
+		personId, err := promptWord("Enter id: ")
+		if err != nil {
+			return err
+		}
+		if *protoFormat {
+			printPersonProto(contract, personId)
+			return nil
+		}
+		if person := readPersonByID(contract, personId); person != nil {
+			fmt.Println(formatValue(person))
+		}
+	case 4:
+		personId, err := promptWord("Enter id: ")
+		if err != nil {
+			return err
+		}
+		return updatePerson(contract, personId)
+	case 5:
+		personId, err := promptWord("Enter id: ")
+		if err != nil {
+			return err
+		}
+		getPersonHistory(contract, personId)
+	case 6, 7:
+		personId, err := promptWord("Enter id: ")
+		if err != nil {
+			return err
+		}
+		tag, err := promptWord("Enter tag: ")
+		if err != nil {
+			return err
+		}
+		if cmd == 6 {
+			addPersonTag(contract, personId, tag)
+		} else {
+			removePersonTag(contract, personId, tag)
+		}
+	case 8:
+		tag, err := promptWord("Enter tag: ")
+		if err != nil {
+			return err
+		}
+		getPersonsByTag(contract, tag)
+	case 10:
+		personId, err := promptWord("Enter id: ")
+		if err != nil {
+			return err
+		}
+		date, err := promptWord("Enter date (YYYY-MM-DD or RFC3339): ")
+		if err != nil {
+			return err
+		}
+		getPersonAtTime(contract, personId, date)
+	case 11:
+		return invokeRawInteractive(contract)
+	case 12:
+		city, err := promptLine("Enter city: ")
+		if err != nil {
+			return err
+		}
+		answer, err := promptWord("Married? (true/false): ")
+		if err != nil {
+			return err
+		}
+		married, err := strconv.ParseBool(answer)
+		if err != nil {
+			fmt.Println("Invalid input! Expected true or false")
+			return nil
+		}
+		queryMarriedInCity(contract, city, married)
+	case 13:
+		leftId, err := promptWord("Enter first id: ")
+		if err != nil {
+			return err
+		}
+		rightId, err := promptWord("Enter second id: ")
+		if err != nil {
+			return err
+		}
+		comparePersons(contract, leftId, rightId)
+	case 14:
+		asOf, err := promptLine("Enter date (YYYY-MM-DD or RFC3339, empty for now): ")
+		if err != nil {
+			return err
+		}
+		getExpiredPersons(contract, asOf)
+	case 15:
+		personId, err := promptWord("Enter id: ")
+		if err != nil {
+			return err
+		}
+		expiry, err := promptWord("Enter expiry date (YYYY-MM-DD or RFC3339): ")
+		if err != nil {
+			return err
+		}
+		setPassportExpiry(contract, personId, expiry)
+	case 16:
+		return initLedger(contract)
+	case 17:
+		return createPersonAutoID(contract)
+	case 18:
+		phone, err := readField("Enter phone: ", validation.FieldPhone)
+		if err != nil {
+			return err
+		}
+		getPersonsByPhone(contract, phone)
+	case 19:
+		personId, err := promptWord("Enter id: ")
+		if err != nil {
+			return err
+		}
+		return deletePerson(contract, personId)
+	case 20:
+		filename, err := promptLine("Enter file path: ")
+		if err != nil {
+			return err
+		}
+		createPersonsFromFile(contract, filename)
+	case 21:
+		return watchEventsInteractive(network, contract)
+	case 22:
+		filename, err := promptLine("Enter file path: ")
+		if err != nil {
+			return err
+		}
+		exportPersonsCSV(contract, filename)
+	case 23:
+		return submitOfflineInteractive(network, contract, id, clientConnection, sign)
+	default:
+		println("Unknown cmd! Try one more time")
+		printHelp()
+	}
+	return nil
+}
+
+// menuOption is an entry of the interactive menu and the chaincode transactions it relies on.
+type menuOption struct {
+	number       int
+	label        string
+	transactions []string
+}
+
+// menuOptions lists the menu in display order. Numbers are never reused, so scripts piping input keep working: delete
+// was requested as option 6 but takes 19, as 6 already adds a tag.
+var menuOptions = []menuOption{
+	{1, "create", []string{"PersonExists", "CreatePerson", "CreatePersonWithDetails"}},
+	{2, "getAll", []string{"GetAllPersons"}},
+	{3, "getByID", []string{"ReadPerson"}},
+	{4, "update", []string{"ReadPersonWithETag", "UpdatePersonWithDetails"}},
+	{5, "getHistory", []string{"GetRecentPersonHistory"}},
+	{6, "addTag", []string{"AddPersonTag"}},
+	{7, "removeTag", []string{"RemovePersonTag"}},
+	{8, "getByTag", []string{"GetPersonsByTag"}},
+	{10, "getAtTime", []string{"GetPersonAtTime"}},
+	{11, "raw transaction", nil},
+	{12, "getMarriedInCity", []string{"QueryMarriedInCity"}},
+	{13, "compare", []string{"ReadPerson"}},
+	{14, "getExpired", []string{"GetExpiredPersons"}},
+	{15, "setExpiry", []string{"SetPassportExpiry"}},
+	{16, "init", []string{"IsLedgerInitialized", "InitLedger"}},
+	{17, "create with generated id", []string{"CreatePersonAutoID", "ReadPersonWithETag", "UpdatePersonWithDetails"}},
+	{18, "getByPhone", []string{"ReadPersonByPhone"}},
+	{19, "delete", []string{"PersonExists", "DeletePerson", "DeletePersonForce"}},
+	{20, "create from file", []string{"PersonExists", "CreatePerson", "CreatePersonWithDetails"}},
+	{21, "watch events", nil},
+	{22, "export to CSV", []string{"GetAllPersons"}},
+	{23, "submit with offline signing", nil},
+	{9, "exit", nil},
+}
+
 func printHelp() {
-	fmt.Println("1 - create ")
-	fmt.Println("2 - getAll ")
-	fmt.Println("3 - getByID ")
-	fmt.Println("4 - update ")
-	fmt.Println("5 - getHistory ")
-	fmt.Println("9 - exit ")
+	for _, option := range menuOptions {
+		if menuOptionAvailable(option.number) {
+			fmt.Printf("%d - %s \n", option.number, option.label)
+		} else {
+			fmt.Printf("%d - %s (unavailable on this network)\n", option.number, option.label)
+		}
+	}
 }
 
 // newGrpcConnection creates a gRPC connection to the Gateway server.
-func newGrpcConnection() *grpc.ClientConn {
-	certificate, err := loadCertificate(tlsCertPath)
+func newGrpcConnection(config *Config) *grpc.ClientConn {
+	certificate, err := loadCertificate(tlsCAPEMEnv, config.TLSCertPath)
 	if err != nil {
 		panic(err)
 	}
 
 	certPool := x509.NewCertPool()
 	certPool.AddCert(certificate)
-	transportCredentials := credentials.NewClientTLSFromCert(certPool, gatewayPeer)
+	// the client key pair is loaded before dialing, so a bad one fails here rather than in the first handshake
+	clientCertificates, err := loadTLSClientCertificates(config)
+	if err != nil {
+		panic(err)
+	}
+	transportCredentials := credentials.NewTLS(&tls.Config{
+		RootCAs:      certPool,
+		ServerName:   config.GatewayPeer,
+		Certificates: clientCertificates,
+	})
 
-	connection, err := grpc.Dial(peerEndpoint, grpc.WithTransportCredentials(transportCredentials))
+	connection, err := grpc.Dial(config.PeerEndpoint, grpc.WithTransportCredentials(transportCredentials))
 	if err != nil {
 		panic(fmt.Errorf("failed to create gRPC connection: %w", err))
 	}
@@ -152,14 +410,31 @@ func newGrpcConnection() *grpc.ClientConn {
 	return connection
 }
 
+// loadTLSClientCertificates loads the TLS client key pair presented to peers requiring mutual TLS. It returns none
+// when neither path is configured, leaving the connection to one-way TLS.
+func loadTLSClientCertificates(config *Config) ([]tls.Certificate, error) {
+	if config.TLSClientCertPath == "" && config.TLSClientKeyPath == "" {
+		return nil, nil
+	}
+	if config.TLSClientCertPath == "" || config.TLSClientKeyPath == "" {
+		return nil, errors.New("mutual TLS needs both the TLS client certificate and key, only one is configured")
+	}
+
+	keyPair, err := tls.LoadX509KeyPair(config.TLSClientCertPath, config.TLSClientKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS client key pair %s, %s: %w", config.TLSClientCertPath, config.TLSClientKeyPath, err)
+	}
+	return []tls.Certificate{keyPair}, nil
+}
+
 // newIdentity creates a client identity for this Gateway connection using an X.509 certificate.
-func newIdentity() *identity.X509Identity {
-	certificate, err := loadCertificate(certPath)
+func newIdentity(config *Config) *identity.X509Identity {
+	certificate, err := loadCertificate(certPEMEnv, config.CertPath)
 	if err != nil {
 		panic(err)
 	}
 
-	id, err := identity.NewX509Identity(mspID, certificate)
+	id, err := identity.NewX509Identity(config.MSPID, certificate)
 	if err != nil {
 		panic(err)
 	}
@@ -167,7 +442,17 @@ func newIdentity() *identity.X509Identity {
 	return id
 }
 
-func loadCertificate(filename string) (*x509.Certificate, error) {
+// loadCertificate parses the certificate held in the envVar environment variable, falling back to reading filename
+// when the variable is unset.
+func loadCertificate(envVar string, filename string) (*x509.Certificate, error) {
+	if certificatePEM, ok := os.LookupEnv(envVar); ok {
+		certificate, err := identity.CertificateFromPEM([]byte(certificatePEM))
+		if err != nil {
+			return nil, fmt.Errorf("%s does not contain a valid PEM certificate: %w", envVar, err)
+		}
+		return certificate, nil
+	}
+
 	certificatePEM, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read certificate file: %w", err)
@@ -175,19 +460,36 @@ func loadCertificate(filename string) (*x509.Certificate, error) {
 	return identity.CertificateFromPEM(certificatePEM)
 }
 
-// newSign creates a function that generates a digital signature from a message digest using a private key.
-func newSign() identity.Sign {
+// loadPrivateKey parses the private key held in the FABRIC_KEY_PEM environment variable, falling back to the first
+// file found in the keystore directory when the variable is unset.
+func loadPrivateKey(keyPath string) (crypto.PrivateKey, error) {
+	if privateKeyPEM, ok := os.LookupEnv(keyPEMEnv); ok {
+		privateKey, err := identity.PrivateKeyFromPEM([]byte(privateKeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("%s does not contain a valid PEM private key: %w", keyPEMEnv, err)
+		}
+		return privateKey, nil
+	}
+
 	files, err := ioutil.ReadDir(keyPath)
 	if err != nil {
-		panic(fmt.Errorf("failed to read private key directory: %w", err))
+		return nil, fmt.Errorf("failed to read private key directory: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("private key directory %s is empty", keyPath)
 	}
 	privateKeyPEM, err := ioutil.ReadFile(path.Join(keyPath, files[0].Name()))
 
 	if err != nil {
-		panic(fmt.Errorf("failed to read private key file: %w", err))
+		return nil, fmt.Errorf("failed to read private key file: %w", err)
 	}
 
-	privateKey, err := identity.PrivateKeyFromPEM(privateKeyPEM)
+	return identity.PrivateKeyFromPEM(privateKeyPEM)
+}
+
+// newSign creates a function that generates a digital signature from a message digest using a private key.
+func newSign(config *Config) identity.Sign {
+	privateKey, err := loadPrivateKey(config.KeyPath)
 	if err != nil {
 		panic(err)
 	}
@@ -204,304 +506,707 @@ func newSign() identity.Sign {
  This type of transaction would typically only be run once by an application the first time it was started after its
  initial deployment. A new version of the chaincode deployed later would likely not need to run an "init" function.
 */
-func initLedger(contract *client.Contract) {
-	fmt.Printf("Submit Transaction: InitLedger, function creates the initial set of assets on the ledger \n")
+func initLedger(contract *client.Contract) error {
+	result, err := evaluateTransaction(contract, "IsLedgerInitialized")
+	if err != nil {
+		printGatewayError(err)
+		return nil
+	}
+
+	// seeding an initialized ledger again overwrites the seed persons, which takes ReseedLedger
+	transaction := "InitLedger"
+	question := "Seed the ledger with the initial set of persons?"
+	if initialized, _ := strconv.ParseBool(string(result)); initialized {
+		transaction = "ReseedLedger"
+		question = "The ledger is already initialized. Seed it again, overwriting the seed persons?"
+	}
+	confirmed, err := confirm(question)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fmt.Println("Cancelled")
+		return nil
+	}
+
+	fmt.Printf("Submit Transaction: %s, function creates the initial set of assets on the ledger \n", transaction)
 
-	_, err := contract.SubmitTransaction("InitLedger")
+	submitted, err := submitTransaction(contract, transaction)
 	if err != nil {
-		panic(fmt.Errorf("failed to submit transaction: %w", err))
+		printGatewayError(err)
+		return nil
 	}
 
-	fmt.Printf("*** Transaction committed successfully\n")
+	fmt.Printf("*** Transaction %s\n", submitted)
+	var outcome InitResult
+	if err := json.Unmarshal(submitted.Result, &outcome); err != nil {
+		fmt.Printf("failed to parse result: %s\n", err)
+		return nil
+	}
+	fmt.Printf("Created: %s\n", strings.Join(outcome.Created, ", "))
+	if len(outcome.Overwritten) > 0 {
+		fmt.Printf("Overwritten: %s\n", strings.Join(outcome.Overwritten, ", "))
+	}
+	if len(outcome.Skipped) > 0 {
+		fmt.Printf("Skipped, already existing: %s\n", strings.Join(outcome.Skipped, ", "))
+	}
+	return nil
 }
 
-func checkPersonExists(contract *client.Contract, personId string) bool {
-	evaluateResult, err := contract.EvaluateTransaction("PersonExists", personId)
+// checkPersonExists reports whether the person with given id exists.
+func checkPersonExists(contract *client.Contract, personId string) (bool, error) {
+	evaluateResult, err := evaluateTransaction(contract, "PersonExists", personId)
 	if err != nil {
-		panic(fmt.Errorf("failed to evaluate transaction: %w", err))
+		return false, fmt.Errorf("failed to check person %s: %w", personId, err)
 	}
-	var res bool
-	json.Unmarshal(evaluateResult, &res)
-	return res
+	var exists bool
+	if err := json.Unmarshal(evaluateResult, &exists); err != nil {
+		return false, err
+	}
+	return exists, nil
 }
 
-func parsePersonInputCreate(contract *client.Contract) Person {
+// parsePersonInputCreate reads a new person, asking for another id while the one entered is taken. It reports false,
+// after printing why, when the existence of the id could not be checked.
+func parsePersonInputCreate(contract *client.Contract) (Person, bool, error) {
 
 	fmt.Println("Input Person Data to Create.")
 
 	var p Person
-	var input string
-	scanner := bufio.NewScanner(os.Stdin)
 
 	for {
-		fmt.Print("Id: ")
-		p.ID = readLine(scanner)
-		if checkPersonExists(contract, p.ID) {
+		var err error
+		p.ID, err = readField("Id: ", validation.FieldID)
+		if err != nil {
+			return p, false, err
+		}
+		exists, err := checkPersonExists(contract, p.ID)
+		if err != nil {
+			printGatewayError(err)
+			return p, false, nil
+		}
+		if exists {
 			fmt.Println("Person with this ID already exists! Try another")
 		} else {
 			break
 		}
 	}
 
-	for {
-		fmt.Print("Serial: ")
-		input = readLine(scanner)
-		if len(input) == 0 {
-			fmt.Printf("required field!\n")
-		} else {
-			p.Serial = input
-			break
-		}
-	}
+	details, err := parsePersonDetails()
+	details.ID = p.ID
+	return details, err == nil, err
+}
 
-	for {
-		fmt.Print("Name: ")
-		input = readLine(scanner)
-		if len(input) == 0 {
-			fmt.Printf("required field!\n")
-		} else {
-			p.Name = input
-			break
-		}
+// parsePersonDetails reads every attribute of a new person but its id.
+func parsePersonDetails() (Person, error) {
+	var p Person
+
+	prompts := &fieldPrompts{}
+	p.Serial = prompts.read("Serial: ", validation.FieldSerial)
+	p.Name = prompts.read("Name: ", validation.FieldName)
+	p.Surname = prompts.read("Surname: ", validation.FieldSurname)
+	p.City = prompts.read("City: ", validation.FieldCity)
+	p.Address = prompts.read("Address: ", validation.FieldAddress)
+	p.Phone = prompts.read("Phone: ", validation.FieldPhone)
+	p.Reference = prompts.read("Reference (optional): ", validation.FieldReference)
+	p.Gender = prompts.read("Gender ("+strings.Join(validation.Genders, "/")+", optional): ", validation.FieldGender)
+	p.Birthdate = prompts.read("Birthdate (YYYY-MM-DD, optional): ", validation.FieldBirthdate)
+	if prompts.err != nil {
+		return p, prompts.err
 	}
 
 	for {
-		fmt.Print("Surname: ")
-		input = readLine(scanner)
+		fmt.Print("Married?: ")
+		input, err := readLine()
+		if err != nil {
+			return p, err
+		}
 		if len(input) == 0 {
 			fmt.Printf("required field!\n")
 		} else {
-			p.Surname = input
-			break
+			p.Married, err = strconv.ParseBool(input)
+			if err != nil {
+				fmt.Println("Invalid input! Try ine more time")
+			} else {
+				break
+			}
 		}
 	}
 
-	for {
-		fmt.Print("City: ")
-		input = readLine(scanner)
-		if len(input) == 0 {
-			fmt.Printf("required field!\n")
-		} else {
-			p.City = input
-			break
-		}
+	return p, nil
+}
+
+func parsePersonInputUpdate(p Person) (Person, error) {
+
+	fmt.Println("Input Person Data to Update.")
+	fmt.Println("To keep current value leave blank input")
+
+	prompts := &fieldPrompts{}
+	p.Serial = prompts.update("Serial: ", validation.FieldSerial, p.Serial)
+	p.Name = prompts.update("name: ", validation.FieldName, p.Name)
+	p.Surname = prompts.update("surname: ", validation.FieldSurname, p.Surname)
+	p.City = prompts.update("city:", validation.FieldCity, p.City)
+	p.Address = prompts.update("address:", validation.FieldAddress, p.Address)
+	p.Phone = prompts.update("phone:", validation.FieldPhone, p.Phone)
+	p.Reference = prompts.update("reference:", validation.FieldReference, p.Reference)
+	p.Gender = prompts.update("gender:", validation.FieldGender, p.Gender)
+	p.Birthdate = prompts.update("birthdate:", validation.FieldBirthdate, p.Birthdate)
+	if prompts.err != nil {
+		return p, prompts.err
 	}
 
 	for {
-		fmt.Print("Address: ")
-		input = readLine(scanner)
-		if len(input) == 0 {
-			fmt.Printf("required field!\n")
+		fmt.Println("married?:", p.Married, "\nnew value: ")
+		input, err := readLine()
+		if err != nil {
+			return p, err
+		}
+		if len(input) != 0 {
+			p.Married, err = strconv.ParseBool(input)
+			if err != nil {
+				fmt.Println("Invalid input! Try ine more time")
+			} else {
+				break
+			}
 		} else {
-			p.Address = input
 			break
 		}
 	}
 
+	return p, nil
+}
+
+// readField prompts until the input, trimmed of surrounding whitespace, satisfies the validation rules the chaincode
+// applies to the field.
+func readField(label string, field string) (string, error) {
 	for {
-		fmt.Print("Phone: ")
-		input = readLine(scanner)
-		if len(input) == 0 {
-			fmt.Printf("required field!\n")
+		fmt.Print(label)
+		line, err := readLine()
+		if err != nil {
+			return "", err
+		}
+		input := validation.Normalize(line)
+		if err := validation.Field(field, input); err != nil {
+			fmt.Println(err)
 		} else {
-			p.Phone = input
-			break
+			return input, nil
 		}
 	}
+}
 
+// readFieldUpdate prompts for a new value of the field, keeping the current one on blank input.
+func readFieldUpdate(label string, field string, current string) (string, error) {
 	for {
-		fmt.Print("Married?: ")
-		input = readLine(scanner)
+		fmt.Print(label, current, "\nnew value: ")
+		line, err := readLine()
+		if err != nil {
+			return "", err
+		}
+		input := validation.Normalize(line)
 		if len(input) == 0 {
-			fmt.Printf("required field!\n")
+			return current, nil
+		}
+		if err := validation.Field(field, input); err != nil {
+			fmt.Println(err)
 		} else {
-			var err error
-			p.Married, err = strconv.ParseBool(input)
-			if err != nil {
-				fmt.Println("Invalid input! Try ine more time")
-			} else {
-				break
-			}
+			return input, nil
 		}
 	}
+}
 
-	return p
+// fieldPrompts reads a series of fields, skipping every prompt once the input has ended so that the error only needs
+// checking after the last one.
+type fieldPrompts struct {
+	err error
 }
 
-func parsePersonInputUpdate(p Person) Person {
+func (prompts *fieldPrompts) read(label string, field string) string {
+	if prompts.err != nil {
+		return ""
+	}
+	var value string
+	value, prompts.err = readField(label, field)
+	return value
+}
 
-	fmt.Println("Input Person Data to Update.")
-	fmt.Println("To keep current value leave blank input")
+func (prompts *fieldPrompts) update(label string, field string, current string) string {
+	if prompts.err != nil {
+		return current
+	}
+	var value string
+	value, prompts.err = readFieldUpdate(label, field, current)
+	return value
+}
 
-	var input string
-	scanner := bufio.NewScanner(os.Stdin)
+func createPerson(contract *client.Contract) error {
+	p, ok, err := parsePersonInputCreate(contract)
+	if !ok {
+		return err
+	}
 
-	fmt.Print("Serial: ", p.Serial, "\nnew value: ")
-	input = readLine(scanner)
-	if len(input) != 0 {
-		p.Serial = input
+	address, err := encryptField(p.Address)
+	if err != nil {
+		fmt.Println(err)
+		return nil
 	}
 
-	fmt.Print("name: ", p.Name, "\nnew value: ")
-	input = readLine(scanner)
-	if len(input) != 0 {
-		p.Name = input
+	transaction, args, err := createPersonTransaction(&p, address)
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
+	if !validateCreatePerson(contract, transaction, args) {
+		return nil
+	}
+	if *asyncSubmit {
+		if _, err := submitAsync(contract, "create person "+p.ID, transaction, args...); err != nil {
+			printGatewayError(err)
+		}
+		return nil
 	}
 
-	fmt.Print("surname: ", p.Surname, "\nnew value: ")
-	input = readLine(scanner)
-	if len(input) != 0 {
-		p.Surname = input
+	fmt.Println("Committing to blockchain...")
+	submit := submitTransaction
+	if checkEndorsers() {
+		submit = submitCheckingEndorsers
+	}
+	result, err := submit(contract, transaction, args...)
+	if err != nil {
+		if !printChaincodeError(err) {
+			printGatewayError(err)
+		}
+		return nil
 	}
 
-	fmt.Print("city:", p.City, "\nnew value: ")
-	input = readLine(scanner)
-	if len(input) != 0 {
-		p.City = input
+	fmt.Printf("*** Transaction %s\n", result)
+	return nil
+}
+
+// validateCreatePerson evaluates the given create transaction without submitting it, so a person the chaincode would
+// reject is reported before anything is submitted. CreatePerson is checked with EvaluateCreatePerson, which takes the
+// same arguments. Within a namespace there is no such check and the submit itself validates.
+func validateCreatePerson(contract *client.Contract, transaction string, args []string) bool {
+	if *namespace != "" {
+		return true
 	}
 
-	fmt.Print("address:", p.Address, "\nnew value: ")
-	input = readLine(scanner)
-	if len(input) != 0 {
-		p.Address = input
+	evaluated := transaction
+	if transaction == "CreatePerson" {
+		evaluated = "EvaluateCreatePerson"
 	}
+	fmt.Println("Validating...")
+	if _, err := evaluateTransaction(contract, evaluated, args...); err != nil {
+		if !printChaincodeError(err) {
+			printGatewayError(err)
+		}
+		return false
+	}
+	return true
+}
 
-	fmt.Print("phone:", p.Phone, "\nnew value: ")
-	input = readLine(scanner)
-	if len(input) != 0 {
-		p.Phone = input
+// createPersonsFromFile creates the persons of a JSON array file one transaction each, skipping those whose id already
+// exists, and reports the outcome of every record at the end instead of stopping at the first failure.
+func createPersonsFromFile(contract *client.Contract, filename string) {
+	personsJSON, err := ioutil.ReadFile(filename)
+	if err != nil {
+		fmt.Printf("failed to read persons file: %s\n", err)
+		return
 	}
-	for {
-		fmt.Println("married?:", p.Married, "\nnew value: ")
-		input = readLine(scanner)
-		if len(input) != 0 {
-			var err error
-			p.Married, err = strconv.ParseBool(input)
-			if err != nil {
-				fmt.Println("Invalid input! Try ine more time")
-			} else {
-				break
-			}
-		} else {
-			break
+	var persons []Person
+	if err := json.Unmarshal(personsJSON, &persons); err != nil {
+		fmt.Printf("failed to parse persons file: %s\n", err)
+		return
+	}
+
+	var created, skipped, failed []string
+	for i, p := range persons {
+		normalizePerson(&p)
+		label := fmt.Sprintf("person %d (%s)", i, p.ID)
+		if err := validation.PersonAll(validationFields(p)); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", label, err))
+			continue
+		}
+
+		exists, err := checkPersonExists(contract, p.ID)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", label, err))
+			continue
+		}
+		if exists {
+			skipped = append(skipped, p.ID)
+			continue
+		}
+
+		address, err := encryptField(p.Address)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", label, err))
+			continue
 		}
+		transaction, args, err := createPersonTransaction(&p, address)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", label, err))
+			continue
+		}
+		fmt.Printf("Committing %s to blockchain...\n", p.ID)
+		if _, err := submitTransaction(contract, transaction, args...); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", label, err))
+			continue
+		}
+		created = append(created, p.ID)
 	}
 
-	return p
+	fmt.Printf("*** %d created, %d skipped, %d failed\n", len(created), len(skipped), len(failed))
+	if len(created) > 0 {
+		fmt.Printf("Created: %s\n", strings.Join(created, ", "))
+	}
+	if len(skipped) > 0 {
+		fmt.Printf("Skipped, already existing: %s\n", strings.Join(skipped, ", "))
+	}
+	for _, failure := range failed {
+		fmt.Printf("Failed %s\n", failure)
+	}
 }
 
-func createPerson(contract *client.Contract) {
-	p := parsePersonInputCreate(contract)
+// createPersonAutoID creates a person under an id chosen by the chaincode and prints that id.
+func createPersonAutoID(contract *client.Contract) error {
+	fmt.Println("Input Person Data to Create, the id is generated.")
+	p, err := parsePersonDetails()
+	if err != nil {
+		return err
+	}
+
+	address, err := encryptField(p.Address)
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
 
 	fmt.Println("Committing to blockchain...")
-	_, err := contract.SubmitTransaction("CreatePerson", p.ID, p.Serial, p.Name, p.Surname, p.City, p.Address, p.Phone, strconv.FormatBool(p.Married))
+	result, err := submitTransaction(contract, "CreatePersonAutoID", p.Serial, p.Name, p.Surname, p.City, address, p.Phone, strconv.FormatBool(p.Married))
 	if err != nil {
-		panic(fmt.Errorf("failed to submit transaction: %w", err))
+		if !printChaincodeError(err) {
+			printGatewayError(err)
+		}
+		return nil
 	}
+	fmt.Printf("*** Transaction %s, person created with id %s\n", result, result.Result)
 
-	fmt.Printf("*** Transaction committed successfully\n")
+	// CreatePersonAutoID takes no optional details, they are set by updating the created person
+	if !hasOptionalDetails(&p) {
+		return nil
+	}
+	p.ID = string(result.Result)
+	created, err := readVersionedPerson(contract, p.ID)
+	if err == nil {
+		result, err = submitPersonUpdate(contract, created.ETag, &p)
+	}
+	if err != nil {
+		fmt.Printf("Person %s was created without its optional details, update it to set them\n", p.ID)
+		if !printChaincodeError(err) {
+			printGatewayError(err)
+		}
+		return nil
+	}
+	fmt.Printf("*** Transaction %s, optional details of person %s set\n", result, p.ID)
+	return nil
 }
-func updatePerson(contract *client.Contract, personId string) {
+func updatePerson(contract *client.Contract, personId string) error {
+	fmt.Printf("Evaluate Transaction: ReadPersonWithETag, function returns person attributes and their ETag\n")
 
-	var person Person
-	personBytes := readPersonByID(contract, personId)
-	if len(personBytes) == 0 {
-		return
+	person, err := readVersionedPerson(contract, personId)
+	if err != nil {
+		if !printChaincodeError(err) {
+			printGatewayError(err)
+		}
+		return nil
 	}
 
-	json.Unmarshal(personBytes, &person)
-	p := parsePersonInputUpdate(person)
+	p, err := parsePersonInputUpdate(person.Person)
+	if err != nil {
+		return err
+	}
 
+	// the ETag captured on read makes the update fail if someone else changed the person meanwhile, while a change
+	// committed between endorsement and commit is an MVCC conflict the edits are simply applied again after
 	fmt.Println("Committing to blockchain...")
-	_, err := contract.SubmitTransaction("UpdatePerson", p.ID, p.Serial, p.Name, p.Surname, p.City, p.Address, p.Phone, strconv.FormatBool(p.Married))
+	result, err := updatePersonWithRetry(contract, person, editedFields(person.Person, p))
 	if err != nil {
-		panic(fmt.Errorf("failed to submit transaction: %w", err))
+		if !printChaincodeError(err) {
+			printGatewayError(err)
+		} else if code, _ := chaincodeError(err); code == errCodeConflict {
+			fmt.Printf("Read person %s again before retrying the update.\n", p.ID)
+		}
+		return nil
 	}
 
-	fmt.Printf("*** Transaction committed successfully\n")
+	fmt.Printf("*** Transaction %s\n", result)
+	return nil
 }
 
 // Evaluate a transaction to query ledger state.
 func getAllPersons(contract *client.Contract) {
 	fmt.Println("Evaluate Transaction: GetAllPersons, function returns all the current assets on the ledger")
 
-	evaluateResult, err := contract.EvaluateTransaction("GetAllPersons")
+	persons, err := listPersons(contract)
 	if err != nil {
-		panic(fmt.Errorf("failed to evaluate transaction: %w", err))
+		printGatewayError(err)
+		return
 	}
 
-	if len(evaluateResult) == 0 {
+	if len(persons) == 0 {
 		fmt.Println("database is empty!")
 	} else {
-		fmt.Printf("*** Result:%s", formatJSON(evaluateResult))
+		fmt.Printf("*** Result:%s", formatValue(persons))
 	}
 }
 
-// Evaluate a transaction by assetID to query ledger state.
-func readPersonByID(contract *client.Contract, personId string) []byte {
+// Evaluate a transaction by assetID to query ledger state. The error is printed and nil returned when the person
+// cannot be read.
+func readPersonByID(contract *client.Contract, personId string) *Person {
 	fmt.Printf("Evaluate Transaction: ReadPerson, function returns person attributes\n")
 
-	evaluateResult, err := contract.EvaluateTransaction("ReadPerson", personId)
+	person, err := readPerson(contract, personId)
 	if err != nil {
-		fmt.Printf("failed to evaluate transaction: %s\n", err)
+		if !printChaincodeError(err) {
+			printGatewayError(err)
+		}
+		return nil
 	}
 
-	return evaluateResult
+	return person
 }
 
 func getPersonHistory(contract *client.Contract, personId string) {
-	fmt.Println("Evaluate Transaction: GetPersonHistory, function returns all the current assets on the ledger")
+	fmt.Println("Evaluate Transaction: GetRecentPersonHistory, function returns the most recent updates of the person")
 
-	evaluateResult, err := contract.EvaluateTransaction("GetPersonHistory", personId)
+	history, err := readPersonHistory(contract, personId)
 	if err != nil {
-		fmt.Printf("failed to evaluate transaction: \n%s\n", err)
+		if !printChaincodeError(err) {
+			printGatewayError(err)
+		}
 		return
 	}
-	fmt.Println("*** Result:%s", formatJSON(evaluateResult))
+	fmt.Println("*** Result:")
+	printUpdates(history.Updates)
+
+	if history.Truncated {
+		fmt.Printf("Only the %d most recent updates are shown: %s\n", len(history.Updates), history.Suggestion)
+	}
 }
 
-// Submit transaction, passing in the wrong number of arguments ,expected to throw an error containing details of any error responses from the smart contract.
-func exampleErrorHandling(contract *client.Contract) {
-	fmt.Println("Submit Transaction: UpdateAsset asset70, asset70 does not exist and should return an error")
+// printUpdates prints history updates one by one, each deletion as a line of its own so that it stands out from the
+// versions around it.
+func printUpdates(updates []Update) {
+	for _, update := range updates {
+		when := update.Timestamp.Format(time.RFC3339)
+		if update.IsDelete {
+			fmt.Printf("%s  tx %s  DELETED\n", when, update.Tx)
+			continue
+		}
+		fmt.Printf("%s  tx %s\n%s\n", when, update.Tx, formatValue(update.Data))
+	}
+}
 
-	_, err := contract.SubmitTransaction("UpdateAsset")
+// deletePerson deletes a person after a yes/no confirmation. A person linked to a spouse is only deleted, unlinking the
+// spouse, once that is confirmed too.
+func deletePerson(contract *client.Contract, personId string) error {
+	exists, err := checkPersonExists(contract, personId)
 	if err != nil {
-		switch err := err.(type) {
-		case *client.EndorseError:
-			fmt.Printf("Endorse error with gRPC status %v: %s\n", status.Code(err), err)
-		case *client.SubmitError:
-			fmt.Printf("Submit error with gRPC status %v: %s\n", status.Code(err), err)
-		case *client.CommitStatusError:
-			if errors.Is(err, context.DeadlineExceeded) {
-				fmt.Printf("Timeout waiting for transaction %s commit status: %s", err.TransactionID, err)
-			} else {
-				fmt.Printf("Error obtaining commit status with gRPC status %v: %s\n", status.Code(err), err)
-			}
-		case *client.CommitError:
-			fmt.Printf("Transaction %s failed to commit with status %d: %s\n", err.TransactionID, int32(err.Code), err)
+		printGatewayError(err)
+		return nil
+	}
+	if !exists {
+		fmt.Printf("Person %s does not exist\n", personId)
+		return nil
+	}
+	confirmed, err := confirm(fmt.Sprintf("Delete person %s? This cannot be undone", personId))
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fmt.Println("Cancelled")
+		return nil
+	}
+
+	fmt.Println("Committing to blockchain...")
+	result, err := submitTransaction(contract, "DeletePerson", personId)
+	// the only conflict DeletePerson reports is a spouse link
+	if code, _ := chaincodeError(err); code == errCodeConflict {
+		confirmed, err = confirm(fmt.Sprintf("Person %s is linked to a spouse. Unlink the spouse and delete anyway?", personId))
+		if err != nil {
+			return err
 		}
-		/*
-		 Any error that originates from a peer or orderer node external to the gateway will have its details
-		 embedded within the gRPC status error. The following code shows how to extract that.
-		*/
-		statusErr := status.Convert(err)
-		for _, detail := range statusErr.Details() {
-			errDetail := detail.(*gwproto.ErrorDetail)
-			fmt.Printf("Error from endpoint: %s, mspId: %s, message: %s\n", errDetail.Address, errDetail.MspId, errDetail.Message)
+		if !confirmed {
+			fmt.Println("Cancelled")
+			return nil
 		}
+		result, err = submitTransaction(contract, "DeletePersonForce", personId)
 	}
+	if err != nil {
+		if !printChaincodeError(err) {
+			printGatewayError(err)
+		}
+		return nil
+	}
+
+	fmt.Printf("*** Transaction %s, person %s deleted\n", result, personId)
+	return nil
+}
+
+func addPersonTag(contract *client.Contract, personId string, tag string) {
+	fmt.Println("Committing to blockchain...")
+	result, err := submitTransaction(contract, "AddPersonTag", personId, tag)
+	if err != nil {
+		printGatewayError(err)
+		return
+	}
+
+	fmt.Printf("*** Transaction %s\n", result)
+}
+
+func removePersonTag(contract *client.Contract, personId string, tag string) {
+	fmt.Println("Committing to blockchain...")
+	result, err := submitTransaction(contract, "RemovePersonTag", personId, tag)
+	if err != nil {
+		printGatewayError(err)
+		return
+	}
+
+	fmt.Printf("*** Transaction %s\n", result)
+}
+
+// Evaluate a rich query for all persons carrying the given tag.
+func getPersonsByTag(contract *client.Contract, tag string) {
+	fmt.Println("Evaluate Transaction: GetPersonsByTag, function returns all persons with the given tag")
+
+	evaluateResult, err := evaluateTransaction(contract, "GetPersonsByTag", tag)
+	if err != nil {
+		printGatewayError(err)
+		return
+	}
+
+	if len(evaluateResult) == 0 {
+		fmt.Println("no persons found!")
+	} else {
+		fmt.Printf("*** Result:%s\n", formatJSON(evaluateResult))
+	}
+}
+
+// Evaluate a compound rich query for persons in a city with the given marital status.
+func queryMarriedInCity(contract *client.Contract, city string, married bool) {
+	fmt.Println("Evaluate Transaction: QueryMarriedInCity, function returns persons in a city by marital status")
+
+	evaluateResult, err := evaluateTransaction(contract, "QueryMarriedInCity", city, strconv.FormatBool(married))
+	if err != nil {
+		printGatewayError(err)
+		return
+	}
+
+	if len(evaluateResult) == 0 {
+		fmt.Println("no persons found!")
+	} else {
+		fmt.Printf("*** Result:%s\n", formatJSON(evaluateResult))
+	}
+}
+
+// Evaluate a rich query for all persons with the given phone number.
+func getPersonsByPhone(contract *client.Contract, phone string) {
+	fmt.Println("Evaluate Transaction: ReadPersonByPhone, function returns all persons with the given phone number")
+
+	evaluateResult, err := evaluateTransaction(contract, "ReadPersonByPhone", phone)
+	if err != nil {
+		printGatewayError(err)
+		return
+	}
+
+	fmt.Printf("*** Result:%s\n", formatJSON(evaluateResult))
 }
 
-func readLine(scanner *bufio.Scanner) string {
-	scanner.Scan()
-	return scanner.Text()
+// Evaluate a transaction returning the person as it was at the given date. A plain date refers to the end of that
+// day in UTC, so the result includes every change made during the day.
+func getPersonAtTime(contract *client.Contract, personId string, date string) {
+	fmt.Println("Evaluate Transaction: GetPersonAtTime, function returns the person as it was at the given time")
+
+	at, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		day, dayErr := time.Parse("2006-01-02", date)
+		if dayErr != nil {
+			fmt.Printf("invalid date %q, expected YYYY-MM-DD or RFC3339\n", date)
+			return
+		}
+		at = day.Add(24*time.Hour - time.Second)
+	}
+
+	evaluateResult, err := evaluateTransaction(contract, "GetPersonAtTime", personId, at.Format(time.RFC3339))
+	if err != nil {
+		if !printChaincodeError(err) {
+			printGatewayError(err)
+		}
+		return
+	}
+	fmt.Printf("*** Result:%s\n", formatJSON(evaluateResult))
+}
+
+func setPassportExpiry(contract *client.Contract, personId string, expiry string) {
+	fmt.Println("Committing to blockchain...")
+	result, err := submitTransaction(contract, "SetPassportExpiry", personId, expiry)
+	if err != nil {
+		printGatewayError(err)
+		return
+	}
+
+	fmt.Printf("*** Transaction %s\n", result)
 }
 
-//Format JSON data
+// Evaluate a range query for persons whose passport expired before the given date. An empty date lets the chaincode
+// use the transaction timestamp.
+func getExpiredPersons(contract *client.Contract, asOf string) {
+	fmt.Println("Evaluate Transaction: GetExpiredPersons, function returns persons with an expired passport")
+
+	if asOf != "" {
+		if _, err := time.Parse(time.RFC3339, asOf); err != nil {
+			if _, err := time.Parse("2006-01-02", asOf); err != nil {
+				fmt.Printf("invalid date %q, expected YYYY-MM-DD or RFC3339\n", asOf)
+				return
+			}
+		}
+	}
+
+	evaluateResult, err := evaluateTransaction(contract, "GetExpiredPersons", asOf)
+	if err != nil {
+		printGatewayError(err)
+		return
+	}
+
+	if len(evaluateResult) == 0 {
+		fmt.Println("no persons found!")
+	} else {
+		fmt.Printf("*** Result:%s\n", formatJSON(evaluateResult))
+	}
+}
+
+var compactOutput = flag.Bool("compact", false, "print JSON results on a single line instead of indented")
+
+//Format JSON data, compacted to a single line when -compact is set
 func formatJSON(data []byte) string {
 	var prettyJSON bytes.Buffer
+	if *compactOutput {
+		if err := json.Compact(&prettyJSON, data); err != nil {
+			panic(fmt.Errorf("failed to parse JSON: %w", err))
+		}
+		return prettyJSON.String()
+	}
 	if err := json.Indent(&prettyJSON, data, " ", ""); err != nil {
 		panic(fmt.Errorf("failed to parse JSON: %w", err))
 	}
 	return prettyJSON.String()
 }
+
+// Format a value as JSON the way formatJSON formats a transaction result
+func formatValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		panic(fmt.Errorf("failed to format JSON: %w", err))
+	}
+	return formatJSON(data)
+}
@@ -0,0 +1,131 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// readVersionedPerson reads the person with given id together with the ETag UpdatePersonWithDetails expects.
+func readVersionedPerson(contract *client.Contract, id string) (*VersionedPerson, error) {
+	personBytes, err := evaluateTransaction(contract, "ReadPersonWithETag", id)
+	if err != nil {
+		return nil, err
+	}
+
+	var person VersionedPerson
+	if err := json.Unmarshal(personBytes, &person); err != nil {
+		return nil, fmt.Errorf("failed to parse person %s: %w", id, err)
+	}
+	return &person, nil
+}
+
+// readPerson reads the person with given id.
+func readPerson(contract *client.Contract, id string) (*Person, error) {
+	personBytes, err := evaluateTransaction(contract, "ReadPerson", id)
+	if err != nil {
+		return nil, err
+	}
+
+	var person Person
+	if err := json.Unmarshal(personBytes, &person); err != nil {
+		return nil, fmt.Errorf("failed to parse person %s: %w", id, err)
+	}
+	return &person, nil
+}
+
+// listPersons reads every person of the ledger in a single call. Use streamAllPersons for ledgers too large for that.
+func listPersons(contract *client.Contract) ([]Person, error) {
+	personsBytes, err := evaluateTransaction(contract, "GetAllPersons")
+	if err != nil {
+		return nil, err
+	}
+	// the chaincode returns an empty payload rather than an empty array for an empty ledger
+	if len(personsBytes) == 0 {
+		return nil, nil
+	}
+
+	var persons []Person
+	if err := json.Unmarshal(personsBytes, &persons); err != nil {
+		return nil, fmt.Errorf("failed to parse persons: %w", err)
+	}
+	return persons, nil
+}
+
+// readPersonHistory reads the updates of the person with given id, most recent first.
+func readPersonHistory(contract *client.Contract, id string) (*PersonHistory, error) {
+	historyBytes, err := evaluateTransaction(contract, "GetRecentPersonHistory", id)
+	if err != nil {
+		return nil, err
+	}
+
+	var history PersonHistory
+	if err := json.Unmarshal(historyBytes, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse history of person %s: %w", id, err)
+	}
+	return &history, nil
+}
+
+// personDetails is the JSON object CreatePersonWithDetails and UpdatePersonWithDetails take: the attributes of a person
+// a client sets, the optional ones included.
+type personDetails struct {
+	ID        string `json:"id"`
+	Serial    string `json:"passport"`
+	Name      string `json:"name"`
+	Surname   string `json:"surname"`
+	City      string `json:"city"`
+	Address   string `json:"address"`
+	Phone     string `json:"phone"`
+	Married   bool   `json:"married"`
+	Reference string `json:"reference"`
+	Gender    string `json:"gender"`
+	Birthdate string `json:"birthdate"`
+}
+
+// personDetailsJSON returns p as the JSON object of CreatePersonWithDetails and UpdatePersonWithDetails, with address
+// as it is to be stored. Every optional attribute is sent, so an empty one removes the value recorded so far.
+func personDetailsJSON(p *Person, address string) (string, error) {
+	detailsJSON, err := json.Marshal(personDetails{
+		ID:        p.ID,
+		Serial:    p.Serial,
+		Name:      p.Name,
+		Surname:   p.Surname,
+		City:      p.City,
+		Address:   address,
+		Phone:     p.Phone,
+		Married:   p.Married,
+		Reference: p.Reference,
+		Gender:    p.Gender,
+		Birthdate: p.Birthdate,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode person %s: %w", p.ID, err)
+	}
+	return string(detailsJSON), nil
+}
+
+// hasOptionalDetails reports whether p sets any of the details only CreatePersonWithDetails and
+// UpdatePersonWithDetails take: the reference, the gender and the birthdate.
+func hasOptionalDetails(p *Person) bool {
+	return p.Reference != "" || p.Gender != "" || p.Birthdate != ""
+}
+
+// createPersonTransaction returns the transaction creating p, with address as it is to be stored, and its arguments.
+// A person without optional details is created with CreatePerson, which is also available within a namespace, any
+// other with CreatePersonWithDetails.
+func createPersonTransaction(p *Person, address string) (string, []string, error) {
+	if !hasOptionalDetails(p) {
+		return "CreatePerson", []string{p.ID, p.Serial, p.Name, p.Surname, p.City, address, p.Phone, strconv.FormatBool(p.Married)}, nil
+	}
+	detailsJSON, err := personDetailsJSON(p, address)
+	if err != nil {
+		return "", nil, err
+	}
+	return "CreatePersonWithDetails", []string{detailsJSON}, nil
+}
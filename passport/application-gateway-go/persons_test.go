@@ -0,0 +1,63 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCreatePersonTransactionKeepsPlainCreateWithoutOptionalDetails(t *testing.T) {
+	p := &Person{ID: "person1", Serial: "0510 228148", Name: "Ivan", Surname: "Petrov", City: "Moscow", Phone: "88005553535"}
+
+	transaction, args, err := createPersonTransaction(p, "encrypted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transaction != "CreatePerson" || len(args) != 8 || args[5] != "encrypted" {
+		t.Errorf("created with %s%q, want CreatePerson with the stored address", transaction, args)
+	}
+}
+
+func TestCreatePersonTransactionSendsOptionalDetails(t *testing.T) {
+	p := &Person{ID: "person1", Address: "Lenina 1", Gender: "F", SpouseID: "person2"}
+
+	transaction, args, err := createPersonTransaction(p, "encrypted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transaction != "CreatePersonWithDetails" || len(args) != 1 {
+		t.Fatalf("created with %s%q, want CreatePersonWithDetails with a single argument", transaction, args)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(args[0]), &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["gender"] != "F" || fields["address"] != "encrypted" {
+		t.Errorf("details %s lack the gender or the stored address", args[0])
+	}
+	if _, ok := fields["spouseId"]; ok {
+		t.Errorf("details %s hold the spouse link, which the chaincode rejects", args[0])
+	}
+}
+
+func TestCreatePersonTransactionSendsBirthdateAsDetail(t *testing.T) {
+	p := &Person{ID: "person1", Address: "Lenina 1", Birthdate: "1990-05-17"}
+
+	transaction, args, err := createPersonTransaction(p, "encrypted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transaction != "CreatePersonWithDetails" || len(args) != 1 {
+		t.Fatalf("created with %s%q, want CreatePersonWithDetails with a single argument", transaction, args)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(args[0]), &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["birthdate"] != "1990-05-17" {
+		t.Errorf("details %s lack the birthdate", args[0])
+	}
+}
@@ -0,0 +1,111 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
+)
+
+// planCommand compares the persons of a JSON file with the ledger without writing anything, listing the ids that
+// are new, the ones whose ledger record differs and how, and the ones already up to date: plan <file>
+func planCommand(contract *client.Contract, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: plan <file>")
+	}
+
+	personsJSON, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read persons file: %w", err)
+	}
+	var persons []Person
+	if err := json.Unmarshal(personsJSON, &persons); err != nil {
+		return fmt.Errorf("failed to parse persons file: %w", err)
+	}
+
+	var added, unchanged []string
+	changed := make(map[string][]fieldDiff)
+	var changedIds []string
+	for _, person := range persons {
+		// the chaincode stores values trimmed, so surrounding whitespace in the file is not a change
+		normalizePerson(&person)
+
+		exists, err := checkPersonExists(contract, person.ID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			added = append(added, person.ID)
+			continue
+		}
+
+		current, err := readPerson(contract, person.ID)
+		if err != nil {
+			return fmt.Errorf("failed to read person %s: %w", person.ID, err)
+		}
+
+		diffs := diffPersons(*current, person)
+		if len(diffs) == 0 {
+			unchanged = append(unchanged, person.ID)
+			continue
+		}
+		changed[person.ID] = diffs
+		changedIds = append(changedIds, person.ID)
+	}
+
+	fmt.Printf("New (%d):\n", len(added))
+	for _, id := range added {
+		fmt.Printf("  %s\n", id)
+	}
+
+	fmt.Printf("Differ (%d):\n", len(changedIds))
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, id := range changedIds {
+		fmt.Fprintf(writer, "  %s\t\t\t\n", id)
+		for _, diff := range changed[id] {
+			fmt.Fprintf(writer, "    %s\t%s\t->\t%s\n", diff.Field, diff.Left, diff.Right)
+		}
+	}
+	writer.Flush()
+
+	fmt.Printf("Unchanged (%d):\n", len(unchanged))
+	for _, id := range unchanged {
+		fmt.Printf("  %s\n", id)
+	}
+
+	// bulk-create asks for this digest, so the operator can check it is submitting the file planned here
+	fmt.Printf("Batch digest: %s\n", batchDigest(persons))
+	return nil
+}
+
+// normalizePerson trims the attributes of a person the way the chaincode does before storing them.
+func normalizePerson(person *Person) {
+	for _, value := range []*string{&person.ID, &person.Serial, &person.Name, &person.Surname, &person.City, &person.Address, &person.Phone, &person.Reference, &person.Gender, &person.Birthdate} {
+		*value = validation.Normalize(*value)
+	}
+}
+
+// validationFields returns the text attributes of a person in the form the validation package checks.
+func validationFields(person Person) validation.PersonFields {
+	return validation.PersonFields{
+		ID:        person.ID,
+		Serial:    person.Serial,
+		Name:      person.Name,
+		Surname:   person.Surname,
+		City:      person.City,
+		Address:   person.Address,
+		Phone:     person.Phone,
+		Reference: person.Reference,
+		Gender:    person.Gender,
+		Birthdate: person.Birthdate,
+	}
+}
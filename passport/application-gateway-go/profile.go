@@ -0,0 +1,177 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+)
+
+// genProfileCommand writes a connection profile for an organization's crypto material directory, laid out the way
+// cryptogen and the Fabric CA scripts of the test network lay it out:
+// gen-profile [-user name] [-peer name] [-msp-id id] [-endpoint host:port] [-o file] <crypto-dir>
+//
+// The certificate, keystore and TLS CA certificate are located by convention and checked to parse before the profile
+// is written. The profile is a configuration file -config reads.
+func genProfileCommand(config *Config, args []string) error {
+	flags := flag.NewFlagSet("gen-profile", flag.ContinueOnError)
+	user := flags.String("user", "User1", "name of the user whose credentials the profile uses")
+	peer := flags.String("peer", "", "peer directory name, such as peer0.org1.example.com, by default the first one")
+	profileMSPID := flags.String("msp-id", "", "MSP ID of the organization, by default derived from the directory name the way the test network names it")
+	endpoint := flags.String("endpoint", config.PeerEndpoint, "gateway peer endpoint")
+	channel := flags.String("channel", config.Channel, "channel name")
+	chaincode := flags.String("chaincode", config.Chaincode, "chaincode name")
+	output := flags.String("o", "", "write the profile to this file instead of standard output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: gen-profile [-user name] [-peer name] [-msp-id id] [-endpoint host:port] [-o file] <crypto-dir>")
+	}
+	cryptoDir := flags.Arg(0)
+
+	profile, err := discoverProfile(cryptoDir, *user, *peer)
+	if err != nil {
+		return err
+	}
+	profile.MSPID = *profileMSPID
+	if profile.MSPID == "" {
+		profile.MSPID = defaultMSPID(cryptoDir)
+	}
+	profile.PeerEndpoint = *endpoint
+	profile.Channel = *channel
+	profile.Chaincode = *chaincode
+
+	if err := checkProfile(profile); err != nil {
+		return err
+	}
+
+	profileJSON, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return err
+	}
+	profileJSON = append(profileJSON, '\n')
+	if *output == "" {
+		_, err := os.Stdout.Write(profileJSON)
+		return err
+	}
+	if err := ioutil.WriteFile(*output, profileJSON, 0644); err != nil {
+		return fmt.Errorf("failed to write profile: %w", err)
+	}
+	fmt.Printf("Profile for %s written to %s\n", profile.MSPID, *output)
+	return nil
+}
+
+// discoverProfile locates the credentials of user and the TLS CA certificate of peer within an organization's crypto
+// material directory. An empty peer selects the first peer directory.
+func discoverProfile(cryptoDir string, user string, peer string) (*Config, error) {
+	userDir, err := findEntry(filepath.Join(cryptoDir, "users"), user+"@")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user %s: %w", user, err)
+	}
+	mspDir := filepath.Join(cryptoDir, "users", userDir, "msp")
+
+	certFile, err := findEntry(filepath.Join(mspDir, "signcerts"), "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the certificate of %s: %w", user, err)
+	}
+
+	peerDir, err := findEntry(filepath.Join(cryptoDir, "peers"), peer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find peer: %w", err)
+	}
+
+	return &Config{
+		CertPath:    filepath.Join(mspDir, "signcerts", certFile),
+		KeyPath:     filepath.Join(mspDir, "keystore"),
+		TLSCertPath: filepath.Join(cryptoDir, "peers", peerDir, "tls", "ca.crt"),
+		GatewayPeer: peerDir,
+	}, nil
+}
+
+// findEntry returns the first entry of dir, in name order, whose name starts with prefix.
+func findEntry(dir string, prefix string) (string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), prefix) {
+			names = append(names, entry.Name())
+		}
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("no entry starting with %q in %s", prefix, dir)
+	}
+	sort.Strings(names)
+	return names[0], nil
+}
+
+// defaultMSPID derives the MSP ID the test network gives an organization from its directory name, such as Org1MSP
+// for org1.example.com.
+func defaultMSPID(cryptoDir string) string {
+	org := strings.SplitN(filepath.Base(filepath.Clean(cryptoDir)), ".", 2)[0]
+	if org == "" {
+		return ""
+	}
+	return strings.ToUpper(org[:1]) + org[1:] + "MSP"
+}
+
+// checkProfile verifies that the credentials a profile points at parse and belong together.
+func checkProfile(profile *Config) error {
+	if err := requireSetting("MSP ID", profile.MSPID); err != nil {
+		return err
+	}
+
+	certificatePEM, err := ioutil.ReadFile(profile.CertPath)
+	if err != nil {
+		return fmt.Errorf("failed to read certificate file: %w", err)
+	}
+	certificate, err := identity.CertificateFromPEM(certificatePEM)
+	if err != nil {
+		return fmt.Errorf("certificate %s does not parse: %w", profile.CertPath, err)
+	}
+
+	keyFile, err := findEntry(profile.KeyPath, "")
+	if err != nil {
+		return fmt.Errorf("failed to find private key: %w", err)
+	}
+	privateKeyPEM, err := ioutil.ReadFile(filepath.Join(profile.KeyPath, keyFile))
+	if err != nil {
+		return fmt.Errorf("failed to read private key file: %w", err)
+	}
+	privateKey, err := identity.PrivateKeyFromPEM(privateKeyPEM)
+	if err != nil {
+		return fmt.Errorf("private key in %s does not parse: %w", profile.KeyPath, err)
+	}
+	if err := checkKeyPair(certificate, privateKey); err != nil {
+		return err
+	}
+
+	tlsCertificatePEM, err := ioutil.ReadFile(profile.TLSCertPath)
+	if err != nil {
+		return fmt.Errorf("failed to read TLS CA certificate file: %w", err)
+	}
+	tlsCertificate, err := identity.CertificateFromPEM(tlsCertificatePEM)
+	if err != nil {
+		return fmt.Errorf("TLS CA certificate %s does not parse: %w", profile.TLSCertPath, err)
+	}
+	if err := checkValidity(tlsCertificate, time.Now()); err != nil {
+		return err
+	}
+
+	return checkEndpoint(profile.PeerEndpoint)
+}
@@ -0,0 +1,78 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/personpb"
+)
+
+// protoFormat makes person reads fetch the protobuf form of the person rather than the default JSON one.
+var protoFormat = flag.Bool("proto", false, "read persons in their protobuf form (ReadPersonProto) instead of JSON")
+
+// readPersonProto reads a person through ReadPersonProto and decodes the protobuf message.
+func readPersonProto(contract *client.Contract, personId string) (*Person, error) {
+	evaluateResult, err := evaluateTransaction(contract, "ReadPersonProto", personId)
+	if err != nil {
+		return nil, err
+	}
+
+	// the message comes as a JSON array of its byte values
+	var personProto []byte
+	if err := json.Unmarshal(evaluateResult, &personProto); err != nil {
+		return nil, fmt.Errorf("failed to parse person %s: %w", personId, err)
+	}
+	message := &personpb.Person{}
+	if err := proto.Unmarshal(personProto, message); err != nil {
+		return nil, fmt.Errorf("failed to decode person %s: %w", personId, err)
+	}
+
+	// addresses inside a binary result are not reached by the JSON decryption of evaluateTransaction
+	address, err := decryptField(message.Address)
+	if err != nil {
+		address = message.Address
+	}
+
+	return &Person{
+		ID:        message.Id,
+		Serial:    message.Serial,
+		Name:      message.Name,
+		Surname:   message.Surname,
+		City:      message.City,
+		Address:   address,
+		Phone:     message.Phone,
+		Married:   message.Married,
+		Expiry:    message.Expiry,
+		Tags:      message.Tags,
+		Reference: message.Reference,
+		SpouseID:  message.SpouseId,
+		Gender:    message.Gender,
+		ExpiresAt: message.ExpiresAt,
+		Birthdate: message.Birthdate,
+	}, nil
+}
+
+// printPersonProto reads a person in its protobuf form and prints it as JSON.
+func printPersonProto(contract *client.Contract, personId string) {
+	fmt.Printf("Evaluate Transaction: ReadPersonProto, function returns person attributes as protobuf\n")
+
+	person, err := readPersonProto(contract, personId)
+	if err != nil {
+		printGatewayError(err)
+		return
+	}
+
+	personJSON, err := json.Marshal(person)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(formatJSON(personJSON))
+}
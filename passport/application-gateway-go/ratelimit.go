@@ -0,0 +1,38 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"flag"
+
+	"golang.org/x/time/rate"
+)
+
+// The limits below are a client-side courtesy to avoid overwhelming a shared orderer or peer when scripting bulk
+// operations. They are not enforced by the network: any other client, or this one started without the flags, can
+// still send as fast as it likes.
+var (
+	maxSubmitTPS   = flag.Float64("max-tps", 0, "maximum submitted transactions per second (0 means unlimited)")
+	maxEvaluateTPS = flag.Float64("max-eval-tps", 0, "maximum evaluated transactions per second (0 means unlimited)")
+)
+
+// Limiters shared by every submit and evaluate call made by the client, so concurrent helpers draw from the same budget.
+var (
+	submitLimiter   = rate.NewLimiter(rate.Inf, 0)
+	evaluateLimiter = rate.NewLimiter(rate.Inf, 0)
+)
+
+// initRateLimiters configures the shared limiters from the parsed command line flags.
+func initRateLimiters() {
+	submitLimiter = newLimiter(*maxSubmitTPS)
+	evaluateLimiter = newLimiter(*maxEvaluateTPS)
+}
+
+func newLimiter(tps float64) *rate.Limiter {
+	if tps <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(tps), 1)
+}
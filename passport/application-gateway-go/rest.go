@@ -0,0 +1,129 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
+	"passport/server"
+)
+
+// restPersons implements server.Persons with the transactions of the contract, encrypting and decrypting addresses
+// like the interactive client does.
+type restPersons struct {
+	contract *client.Contract
+}
+
+func (persons restPersons) List() ([]byte, error) {
+	return evaluateTransaction(persons.contract, "GetAllPersons")
+}
+
+func (persons restPersons) Read(id string) ([]byte, error) {
+	return evaluateTransaction(persons.contract, "ReadPersonWithETag", id)
+}
+
+func (persons restPersons) Create(personJSON []byte) ([]byte, error) {
+	p, err := decodePerson(personJSON)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateBodyPerson(p); err != nil {
+		return nil, err
+	}
+	address, err := encryptField(p.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction, args, err := createPersonTransaction(p, address)
+	if err != nil {
+		return nil, err
+	}
+	_, err = submitTransaction(persons.contract, transaction, args...)
+	if err != nil {
+		return nil, err
+	}
+	return persons.Read(p.ID)
+}
+
+func (persons restPersons) Update(id string, etag string, personJSON []byte) ([]byte, error) {
+	p, err := decodePerson(personJSON)
+	if err != nil {
+		return nil, err
+	}
+	if p.ID != "" && p.ID != id {
+		return nil, fmt.Errorf("%w: id %s does not match the person %s of the URL", server.ErrInvalidBody, p.ID, id)
+	}
+	p.ID = id
+	if err := validateBodyPerson(p); err != nil {
+		return nil, err
+	}
+	address, err := encryptField(p.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	detailsJSON, err := personDetailsJSON(p, address)
+	if err != nil {
+		return nil, err
+	}
+
+	// without an ETag from the request the update applies to whatever is stored now
+	if etag == "" {
+		current, err := readVersionedPerson(persons.contract, id)
+		if err != nil {
+			return nil, err
+		}
+		etag = current.ETag
+	}
+	_, err = submitTransaction(persons.contract, "UpdatePersonWithDetails", detailsJSON, etag)
+	if err != nil {
+		return nil, err
+	}
+	return persons.Read(id)
+}
+
+func (persons restPersons) Delete(id string) error {
+	_, err := submitTransaction(persons.contract, "DeletePerson", id)
+	return err
+}
+
+// decodePerson parses the person of a request body. Only the attributes a client sets are accepted: the ones managed
+// by their own transactions, such as the spouse link, the tags or the expiry, are rejected rather than ignored.
+func decodePerson(personJSON []byte) (*Person, error) {
+	decoder := json.NewDecoder(bytes.NewReader(personJSON))
+	decoder.DisallowUnknownFields()
+	var details personDetails
+	if err := decoder.Decode(&details); err != nil {
+		return nil, fmt.Errorf("%w: %v", server.ErrInvalidBody, err)
+	}
+	return &Person{
+		ID:        details.ID,
+		Serial:    details.Serial,
+		Name:      details.Name,
+		Surname:   details.Surname,
+		City:      details.City,
+		Address:   details.Address,
+		Phone:     details.Phone,
+		Married:   details.Married,
+		Reference: details.Reference,
+		Gender:    details.Gender,
+		Birthdate: details.Birthdate,
+	}, nil
+}
+
+// validateBodyPerson normalizes the person of a request body and rejects it with every validation problem it has at
+// once, so a client fixes them all before a single transaction is submitted.
+func validateBodyPerson(p *Person) error {
+	normalizePerson(p)
+	if err := validation.PersonAll(validationFields(*p)); err != nil {
+		return fmt.Errorf("%w: %v", server.ErrInvalidBody, err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"passport/server"
+)
+
+func TestRestPersonsReportEveryValidationProblem(t *testing.T) {
+	body := []byte(`{"id":"person1","passport":"12345","name":"Ivan2","surname":"Petrov","city":"","address":"Lenina 1","phone":"88005553535"}`)
+	persons := restPersons{}
+
+	_, createErr := persons.Create(body)
+	_, updateErr := persons.Update("person1", "", body)
+
+	for _, err := range []error{createErr, updateErr} {
+		if !errors.Is(err, server.ErrInvalidBody) {
+			t.Fatalf("got %v, want an invalid body rejected before anything is submitted", err)
+		}
+		for _, problem := range []string{"3 validation errors", "serial", "name", "city"} {
+			if !strings.Contains(err.Error(), problem) {
+				t.Errorf("%q does not report %q", err, problem)
+			}
+		}
+	}
+}
+
+func TestDecodePersonRejectsAttributesManagedElsewhere(t *testing.T) {
+	for _, attribute := range []string{`"spouseId":"person2"`, `"tags":["vip"]`, `"expiry":"2030-01-01T00:00:00Z"`, `"expiresAt":"2030-01-01T00:00:00Z"`} {
+		_, err := decodePerson([]byte(`{"id":"person1",` + attribute + `}`))
+		if !errors.Is(err, server.ErrInvalidBody) {
+			t.Errorf("a body with %s gave %v, want it rejected", attribute, err)
+		}
+	}
+}
+
+func TestDecodePersonKeepsOptionalDetails(t *testing.T) {
+	p, err := decodePerson([]byte(`{"id":"person1","passport":"0510 228148","reference":"crm-42","gender":"F","birthdate":"1990-05-17"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Serial != "0510 228148" || p.Reference != "crm-42" || p.Gender != "F" || p.Birthdate != "1990-05-17" {
+		t.Errorf("decoded %+v, want every attribute of the body", p)
+	}
+}
+
+func TestIsLoopbackAddress(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1:8080": true,
+		"localhost:8080": true,
+		"[::1]:8080":     true,
+		":8080":          false,
+		"0.0.0.0:8080":   false,
+		"10.0.0.5:8080":  false,
+		"8080":           false,
+	}
+	for addr, want := range tests {
+		if got := isLoopbackAddress(addr); got != want {
+			t.Errorf("isLoopbackAddress(%q) = %t, want %t", addr, got, want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
+)
+
+// retryBatchCommand resubmits the persons whose creation failed according to an audit log written with -audit-log,
+// taking them from the source file of the failed batch: retry-batch <audit-log> <source-file>
+//
+// The source file is a JSON array as bulk-create reads, or a JSON document per line as import-ndjson reads. A person
+// counts as failed when the last create that included it failed; persons that exist by now, created by someone else
+// or by a submit that was not logged, are skipped. The persons left are created in one atomic transaction, addresses
+// being encrypted like bulk-create does when -encryption-key is set.
+func retryBatchCommand(contract *client.Contract, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: retry-batch <audit-log> <source-file>")
+	}
+
+	failed, err := failedCreates(args[0])
+	if err != nil {
+		return err
+	}
+	if len(failed) == 0 {
+		fmt.Println("The audit log records no failed creates")
+		return nil
+	}
+
+	source, err := readPersonsSource(args[1])
+	if err != nil {
+		return err
+	}
+
+	var persons []Person
+	for _, person := range source {
+		id := validation.Normalize(person.ID)
+		if !failed[id] {
+			continue
+		}
+		delete(failed, id)
+
+		exists, err := checkPersonExists(contract, id)
+		if err != nil {
+			return err
+		}
+		if exists {
+			fmt.Printf("Skipping %s, it exists by now\n", id)
+			continue
+		}
+		persons = append(persons, person)
+	}
+	missing := make([]string, 0, len(failed))
+	for id := range failed {
+		missing = append(missing, id)
+	}
+	sort.Strings(missing)
+	for _, id := range missing {
+		fmt.Printf("Skipping %s, it is not in the source file\n", id)
+	}
+
+	if len(persons) == 0 {
+		fmt.Println("Nothing left to retry")
+		return nil
+	}
+	if err := validatePersons(persons); err != nil {
+		return err
+	}
+	confirmed, err := confirmDigest(batchDigest(persons))
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fmt.Println("Cancelled")
+		return nil
+	}
+	if fieldCipher != nil {
+		if err := encryptAddresses(persons); err != nil {
+			return err
+		}
+	}
+	personsJSON, err := json.Marshal(persons)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Submit Transaction: CreatePersonsBulk, retrying %d persons\n", len(persons))
+	result, err := submitWithEndorseTimeout(contract, *bulkEndorseTimeout, "CreatePersonsBulk", string(personsJSON))
+	if err != nil {
+		return fmt.Errorf("failed to submit transaction: %w", err)
+	}
+
+	fmt.Printf("*** Transaction %s, %s persons created\n", result, result.Result)
+	return nil
+}
+
+// failedCreates returns the ids of the persons whose last logged create, alone or in a batch, failed.
+func failedCreates(auditLogPath string) (map[string]bool, error) {
+	file, err := os.Open(auditLogPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	failed := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	// a bulk create is logged with the ids of the whole batch
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record auditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("audit log line %d: %w", line, err)
+		}
+
+		for _, id := range createdIDs(record) {
+			failed[id] = record.Error != ""
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	for id, idFailed := range failed {
+		if !idFailed {
+			delete(failed, id)
+		}
+	}
+	return failed, nil
+}
+
+// createdIDs returns the ids of the persons an audit record creates, none for transactions that create no persons.
+func createdIDs(record auditRecord) []string {
+	switch record.Transaction {
+	case "CreatePerson", "CreatePersonWithDetails", "CreatePersonsBulk":
+		return record.IDs
+	default:
+		return nil
+	}
+}
+
+// readPersonsSource reads persons from a JSON array file, or from a file holding a JSON document per line.
+func readPersonsSource(path string) ([]Person, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source file: %w", err)
+	}
+
+	var persons []Person
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("[")) {
+		if err := json.Unmarshal(content, &persons); err != nil {
+			return nil, fmt.Errorf("failed to parse source file: %w", err)
+		}
+		return persons, nil
+	}
+
+	for i, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var person Person
+		if err := json.Unmarshal([]byte(line), &person); err != nil {
+			return nil, fmt.Errorf("source file line %d: failed to parse person: %w", i+1, err)
+		}
+		persons = append(persons, person)
+	}
+	return persons, nil
+}
@@ -0,0 +1,280 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package server exposes the passport contract over HTTP for running the client as a long-lived daemon.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Evaluator evaluates a transaction against the ledger and returns its result.
+type Evaluator func(name string, args ...string) ([]byte, error)
+
+// Persons is the contract as the /persons endpoints use it. Persons are exchanged as JSON documents, in the form the
+// chaincode reads and writes them.
+type Persons interface {
+	// List returns every person as a JSON array.
+	List() ([]byte, error)
+	// Read returns the person with given id together with its ETag, in an "etag" attribute.
+	Read(id string) ([]byte, error)
+	// Create submits a new person and returns it as stored, together with its ETag.
+	Create(personJSON []byte) ([]byte, error)
+	// Update replaces the person with given id and returns it as stored, together with its ETag. A non-empty etag
+	// makes the update fail with a conflict when the person has changed since it was read.
+	Update(id string, etag string, personJSON []byte) ([]byte, error)
+	// Delete deletes the person with given id.
+	Delete(id string) error
+}
+
+// ErrorCode returns the code the chaincode prefixed to a failed transaction and the message that follows it, or an
+// empty code for any other error.
+type ErrorCode func(err error) (code string, message string)
+
+// Codes of the chaincode errors mapped to client error statuses, as ErrorCode reports them.
+const (
+	CodeNotFound   = "NOT_FOUND"
+	CodeValidation = "VALIDATION"
+	CodeConflict   = "CONFLICT"
+)
+
+// ErrInvalidBody is wrapped by Persons implementations rejecting a request body that is not a valid person.
+var ErrInvalidBody = errors.New("invalid request body")
+
+// maxBodySize bounds the size of a person document accepted in a request.
+const maxBodySize = 1 << 20
+
+// readinessTTL bounds how long a readiness probe result is reused before the gateway is queried again.
+const readinessTTL = 5 * time.Second
+
+// Server serves the HTTP endpoints of the daemon mode.
+type Server struct {
+	httpServer *http.Server
+	readiness  *readinessCache
+	persons    Persons
+	errorCode  ErrorCode
+	token      string
+}
+
+// New creates a server listening on addr that uses evaluate to reach the gateway and serves the persons of the
+// contract, telling the failures of their transactions apart with errorCode. The /persons endpoints run their
+// transactions with the identity of the daemon: when token is set, they require it as a bearer token in the
+// Authorization header. The health probes never do.
+func New(addr string, token string, evaluate Evaluator, persons Persons, errorCode ErrorCode) *Server {
+	server := &Server{
+		readiness: &readinessCache{evaluate: evaluate, ttl: readinessTTL},
+		persons:   persons,
+		errorCode: errorCode,
+		token:     token,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", server.handleHealthz)
+	mux.HandleFunc("/readyz", server.handleReadyz)
+	mux.HandleFunc("/persons", server.authorized(server.handlePersons))
+	mux.HandleFunc("/persons/", server.authorized(server.handlePerson))
+
+	server.httpServer = &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+	return server
+}
+
+// ListenAndServe serves requests until Shutdown is called, in which case it returns nil.
+func (server *Server) ListenAndServe() error {
+	if err := server.httpServer.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests to complete.
+func (server *Server) Shutdown(ctx context.Context) error {
+	return server.httpServer.Shutdown(ctx)
+}
+
+// authorized answers requests without the bearer token of the server with 401, and passes the others to handler.
+func (server *Server) authorized(handler http.HandlerFunc) http.HandlerFunc {
+	if server.token == "" {
+		return handler
+	}
+	want := []byte("Bearer " + server.token)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// handleHealthz reports that the process is up. It never touches the network.
+func (server *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok\n"))
+}
+
+// handleReadyz reports whether the gateway answered a lightweight query recently.
+func (server *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if err := server.readiness.check(); err != nil {
+		http.Error(w, "not ready: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok\n"))
+}
+
+// handlePersons serves the collection: GET lists every person, POST creates one.
+func (server *Server) handlePersons(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		personsJSON, err := server.persons.List()
+		if err != nil {
+			server.writeError(w, err)
+			return
+		}
+		// the chaincode returns an empty payload rather than an empty array for an empty ledger
+		if len(personsJSON) == 0 {
+			personsJSON = []byte("[]")
+		}
+		writeJSON(w, http.StatusOK, personsJSON)
+	case http.MethodPost:
+		body, ok := readBody(w, r)
+		if !ok {
+			return
+		}
+		personJSON, err := server.persons.Create(body)
+		if err != nil {
+			server.writeError(w, err)
+			return
+		}
+		writePerson(w, http.StatusCreated, personJSON)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handlePerson serves a single person: GET reads it, PUT replaces it, honouring If-Match, DELETE deletes it.
+func (server *Server) handlePerson(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/persons/")
+	if id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		personJSON, err := server.persons.Read(id)
+		if err != nil {
+			server.writeError(w, err)
+			return
+		}
+		writePerson(w, http.StatusOK, personJSON)
+	case http.MethodPut:
+		body, ok := readBody(w, r)
+		if !ok {
+			return
+		}
+		etag := strings.Trim(r.Header.Get("If-Match"), `"`)
+		personJSON, err := server.persons.Update(id, etag, body)
+		if err != nil {
+			server.writeError(w, err)
+			return
+		}
+		writePerson(w, http.StatusOK, personJSON)
+	case http.MethodDelete:
+		if err := server.persons.Delete(id); err != nil {
+			server.writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// readBody reads the request body, answering the request itself when the body cannot be read.
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, "failed to read request body: "+err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return body, true
+}
+
+// writePerson answers with a person document, setting the ETag header from its etag attribute, so that a client can
+// send it back in If-Match.
+func writePerson(w http.ResponseWriter, status int, personJSON []byte) {
+	var versioned struct {
+		ETag string `json:"etag"`
+	}
+	if json.Unmarshal(personJSON, &versioned) == nil && versioned.ETag != "" {
+		w.Header().Set("ETag", `"`+versioned.ETag+`"`)
+	}
+	writeJSON(w, status, personJSON)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// writeError answers a failed request with the status matching the chaincode error code: 404 for NOT_FOUND, 400 for
+// VALIDATION and an invalid body, 409 for CONFLICT. Any other failure, an unreachable peer or a chaincode error
+// without a code, is answered with 502.
+func (server *Server) writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrInvalidBody) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	code, message := server.errorCode(err)
+	switch code {
+	case CodeNotFound:
+		http.Error(w, message, http.StatusNotFound)
+	case CodeValidation:
+		http.Error(w, message, http.StatusBadRequest)
+	case CodeConflict:
+		http.Error(w, message, http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	}
+}
+
+// readinessCache remembers the outcome of the last gateway probe so that frequent probes do not hammer the peer.
+type readinessCache struct {
+	evaluate Evaluator
+	ttl      time.Duration
+
+	mutex     sync.Mutex
+	checkedAt time.Time
+	err       error
+}
+
+func (cache *readinessCache) check() error {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if !cache.checkedAt.IsZero() && time.Since(cache.checkedAt) < cache.ttl {
+		return cache.err
+	}
+
+	_, cache.err = cache.evaluate("PersonExists", "readiness-probe")
+	cache.checkedAt = time.Now()
+	return cache.err
+}
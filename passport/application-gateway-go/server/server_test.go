@@ -0,0 +1,116 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// invalidPersons rejects every person it is given as an invalid body with the given problems.
+type invalidPersons struct {
+	problems string
+}
+
+func (persons invalidPersons) List() ([]byte, error) { return []byte("[]"), nil }
+func (persons invalidPersons) Read(string) ([]byte, error) {
+	return nil, fmt.Errorf("%s: not found", CodeNotFound)
+}
+func (persons invalidPersons) Create([]byte) ([]byte, error) {
+	return nil, fmt.Errorf("%w: %s", ErrInvalidBody, persons.problems)
+}
+func (persons invalidPersons) Update(string, string, []byte) ([]byte, error) {
+	return nil, fmt.Errorf("%w: %s", ErrInvalidBody, persons.problems)
+}
+func (persons invalidPersons) Delete(string) error { return nil }
+
+func TestInvalidPersonIsAnsweredWithEveryProblem(t *testing.T) {
+	problems := "2 validation errors: name may only contain letters; city is a required field"
+	server := New("", "", nil, invalidPersons{problems: problems}, func(error) (string, string) { return "", "" })
+
+	requests := []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/persons", strings.NewReader("{}")),
+		httptest.NewRequest(http.MethodPut, "/persons/person1", strings.NewReader("{}")),
+	}
+	for _, request := range requests {
+		response := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(response, request)
+
+		if response.Code != http.StatusBadRequest {
+			t.Errorf("%s %s answered %d, want 400", request.Method, request.URL, response.Code)
+		}
+		if !strings.Contains(response.Body.String(), problems) {
+			t.Errorf("%s %s answered %q, want every problem listed", request.Method, request.URL, response.Body)
+		}
+	}
+}
+
+// storedPersons answers every read and write with the same person, versioned by etag.
+type storedPersons struct {
+	etag string
+}
+
+func (persons storedPersons) person() []byte {
+	return []byte(`{"id":"person1","name":"Ivan","etag":"` + persons.etag + `"}`)
+}
+
+func (persons storedPersons) List() ([]byte, error)         { return []byte("[]"), nil }
+func (persons storedPersons) Read(string) ([]byte, error)   { return persons.person(), nil }
+func (persons storedPersons) Create([]byte) ([]byte, error) { return persons.person(), nil }
+func (persons storedPersons) Delete(string) error           { return nil }
+func (persons storedPersons) Update(string, string, []byte) ([]byte, error) {
+	return persons.person(), nil
+}
+
+func TestPersonResponsesCarryTheETag(t *testing.T) {
+	server := New("", "", nil, storedPersons{etag: "abc123"}, func(error) (string, string) { return "", "" })
+
+	requests := []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/persons/person1", nil),
+		httptest.NewRequest(http.MethodPost, "/persons", strings.NewReader("{}")),
+		httptest.NewRequest(http.MethodPut, "/persons/person1", strings.NewReader("{}")),
+	}
+	for _, request := range requests {
+		response := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(response, request)
+
+		if got := response.Header().Get("ETag"); got != `"abc123"` {
+			t.Errorf("%s %s answered with ETag %q, want %q", request.Method, request.URL, got, `"abc123"`)
+		}
+	}
+}
+
+func TestPersonsRequireTheToken(t *testing.T) {
+	server := New("", "secret", nil, storedPersons{}, func(error) (string, string) { return "", "" })
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		want          int
+	}{
+		{"persons without a token", "/persons", "", http.StatusUnauthorized},
+		{"person with a wrong token", "/persons/person1", "Bearer wrong", http.StatusUnauthorized},
+		{"person with the token", "/persons/person1", "Bearer secret", http.StatusOK},
+		{"health probe without a token", "/healthz", "", http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, test.path, nil)
+			if test.authorization != "" {
+				request.Header.Set("Authorization", test.authorization)
+			}
+			response := httptest.NewRecorder()
+			server.httpServer.Handler.ServeHTTP(response, request)
+
+			if response.Code != test.want {
+				t.Errorf("answered %d, want %d", response.Code, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// stateDigestCommand prints the digest of all persons computed by ComputeStateDigest, by default on a peer the
+// gateway chooses, with -orgs on a peer of each listed organization, reporting whether they agree. With -expect the
+// digest is compared with one obtained elsewhere, such as from another organization's operator:
+// state-digest [-orgs msp,msp...] [-expect digest]
+func stateDigestCommand(contract *client.Contract, args []string) error {
+	flags := flag.NewFlagSet("state-digest", flag.ContinueOnError)
+	orgs := flags.String("orgs", "", "comma-separated MSP IDs of the organizations whose peers compute the digest")
+	expect := flags.String("expect", "", "digest the computed ones must equal")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: state-digest [-orgs msp,msp...] [-expect digest]")
+	}
+
+	var targets []string
+	for _, org := range strings.Split(*orgs, ",") {
+		if org = strings.TrimSpace(org); org != "" {
+			targets = append(targets, org)
+		}
+	}
+	if len(targets) == 0 {
+		// an empty organization leaves the choice to the gateway, or to -query-org
+		targets = []string{""}
+	}
+
+	digests := make(map[string]bool)
+	for _, org := range targets {
+		digest, err := evaluateStateDigest(contract, org)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate transaction: %w", err)
+		}
+		digests[digest] = true
+		if org == "" {
+			fmt.Println(digest)
+		} else {
+			fmt.Printf("%s  %s\n", digest, org)
+		}
+	}
+
+	if len(digests) > 1 {
+		return errors.New("the organizations hold different states")
+	}
+	if *expect != "" && !digests[strings.ToLower(*expect)] {
+		return fmt.Errorf("the state digest does not match the expected %s", *expect)
+	}
+	if len(targets) > 1 || *expect != "" {
+		fmt.Println("Digests match")
+	}
+	return nil
+}
+
+// evaluateStateDigest evaluates ComputeStateDigest on a peer of the given organization, or of the usual query
+// organization when org is empty.
+func evaluateStateDigest(contract *client.Contract, org string) (string, error) {
+	if org == "" {
+		result, err := evaluateTransaction(contract, "ComputeStateDigest")
+		return string(result), err
+	}
+
+	evaluate := func(name string, args ...string) ([]byte, error) {
+		return contract.Evaluate(name, client.WithArguments(args...), client.WithEndorsingOrganizations(org))
+	}
+	result, err := chain(evaluateMiddleware, evaluate)("ComputeStateDigest")
+	return string(result), err
+}
@@ -0,0 +1,86 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// personsPage is the result of GetAllPersonsWithPagination.
+type personsPage struct {
+	Persons      []*Person `json:"persons"`
+	Bookmark     string    `json:"bookmark"`
+	FetchedCount int32     `json:"fetchedCount"`
+}
+
+// streamAllPersons lists every person page by page, calling fn for each one as soon as its page arrives, so neither
+// side has to hold the whole ledger in memory.
+func streamAllPersons(contract *client.Contract, pageSize int, fn func(*Person)) error {
+	if pageSize <= 0 {
+		return fmt.Errorf("page size must be positive, got %d", pageSize)
+	}
+
+	bookmark := ""
+	for {
+		pageBytes, err := evaluateTransaction(contract, "GetAllPersonsWithPagination", strconv.Itoa(pageSize), bookmark)
+		if err != nil {
+			return fmt.Errorf("failed to read persons page: %w", err)
+		}
+
+		var page personsPage
+		if err := json.Unmarshal(pageBytes, &page); err != nil {
+			return fmt.Errorf("failed to parse persons page: %w", err)
+		}
+		for _, person := range page.Persons {
+			fn(person)
+		}
+
+		if page.Bookmark == "" || page.Bookmark == bookmark {
+			return nil
+		}
+		bookmark = page.Bookmark
+	}
+}
+
+// listCommand prints every person as it is received, one JSON document per line: list [-page-size n]
+func listCommand(contract *client.Contract, args []string) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	pageSize := flags.Int("page-size", 100, "number of persons fetched per request")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: list [-page-size n]")
+	}
+
+	count := 0
+	var encodeErr error
+	err := streamAllPersons(contract, *pageSize, func(person *Person) {
+		personJSON, err := json.Marshal(person)
+		if err != nil {
+			encodeErr = err
+			return
+		}
+		fmt.Println(string(personJSON))
+		count++
+	})
+	if err != nil {
+		return err
+	}
+	if encodeErr != nil {
+		return encodeErr
+	}
+
+	// the summary goes to stderr so that stdout stays a clean stream of persons
+	fmt.Fprintf(os.Stderr, "*** %d persons listed\n", count)
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// Chaincode events whose payload is the person written by the transaction.
+const (
+	eventPersonCreated = "PersonCreated"
+	eventPersonUpdated = "PersonUpdated"
+)
+
+// The single events of CreatePersonsBulk and UpdatePersonsBulk transactions, whose payload is a bulkPersonsEvent.
+const (
+	eventPersonsCreated = "PersonsCreated"
+	eventPersonsUpdated = "PersonsUpdated"
+)
+
+// bulkPersonsEvent is the payload of the PersonsCreated and PersonsUpdated events: every person the batch wrote, as
+// stored.
+type bulkPersonsEvent struct {
+	Persons []Person `json:"persons"`
+}
+
+// eventPersons returns the persons written by the transaction of a person event, and false for the other events.
+func eventPersons(event *client.ChaincodeEvent) ([]Person, bool, error) {
+	switch event.EventName {
+	case eventPersonCreated, eventPersonUpdated:
+		var person Person
+		if err := json.Unmarshal(event.Payload, &person); err != nil {
+			return nil, true, err
+		}
+		return []Person{person}, true, nil
+	case eventPersonsCreated, eventPersonsUpdated:
+		var payload bulkPersonsEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return nil, true, err
+		}
+		return payload.Persons, true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+// tailCommand prints a line for every person created or updated from now on, in bulk or not, until interrupted:
+// tail [-filter-city city] [-filter-gender gender]
+func tailCommand(network *client.Network, contract *client.Contract, args []string) error {
+	flags := flag.NewFlagSet("tail", flag.ContinueOnError)
+	filterCity := flags.String("filter-city", "", "only show persons living in this city")
+	filterGender := flags.String("filter-gender", "", "only show persons of this gender")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: tail [-filter-city city] [-filter-gender gender]")
+	}
+
+	// an interrupt cancels the root context, which ends the event stream
+	ctx, cancel := context.WithCancel(appLifecycle.Context())
+	cancel = appLifecycle.AddCancel("person event listener", cancel)
+	defer cancel()
+
+	events, err := network.ChaincodeEvents(ctx, contract.ChaincodeName())
+	if err != nil {
+		return fmt.Errorf("failed to listen for chaincode events: %w", err)
+	}
+
+	fmt.Println("Waiting for persons to be created or updated, press Ctrl-C to stop")
+	for event := range events {
+		persons, ok, err := eventPersons(event)
+		if !ok {
+			continue
+		}
+		if err != nil {
+			fmt.Printf("%s %s malformed payload in tx %s: %s\n", time.Now().UTC().Format(time.RFC3339), event.EventName, event.TransactionID, err)
+			continue
+		}
+
+		for _, person := range persons {
+			if *filterCity != "" && person.City != *filterCity {
+				continue
+			}
+			if *filterGender != "" && person.Gender != *filterGender {
+				continue
+			}
+
+			// events carry no timestamp, the time of arrival is close enough for watching
+			fmt.Printf("%s %s %s %s %s (%s) block %d\n", time.Now().UTC().Format(time.RFC3339), event.EventName, person.ID, person.Name, person.Surname, person.City, event.BlockNumber)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+	return errors.New("the chaincode event stream ended unexpectedly")
+}
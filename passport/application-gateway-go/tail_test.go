@@ -0,0 +1,52 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+func TestEventPersons(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   client.ChaincodeEvent
+		wantIDs []string
+		wantOK  bool
+	}{
+		{"created", client.ChaincodeEvent{EventName: eventPersonCreated, Payload: []byte(`{"id":"person1"}`)}, []string{"person1"}, true},
+		{"updated", client.ChaincodeEvent{EventName: eventPersonUpdated, Payload: []byte(`{"id":"person1"}`)}, []string{"person1"}, true},
+		{"bulk created", client.ChaincodeEvent{EventName: eventPersonsCreated, Payload: []byte(`{"persons":[{"id":"person1"},{"id":"person2"}]}`)}, []string{"person1", "person2"}, true},
+		{"bulk updated", client.ChaincodeEvent{EventName: eventPersonsUpdated, Payload: []byte(`{"persons":[{"id":"person1"},{"id":"person2"}]}`)}, []string{"person1", "person2"}, true},
+		{"deleted", client.ChaincodeEvent{EventName: "PersonDeleted", Payload: []byte(`{"id":"person1"}`)}, nil, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			persons, ok, err := eventPersons(&test.event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != test.wantOK {
+				t.Fatalf("got ok %t, want %t", ok, test.wantOK)
+			}
+			var ids []string
+			for _, person := range persons {
+				ids = append(ids, person.ID)
+			}
+			if !reflect.DeepEqual(ids, test.wantIDs) {
+				t.Errorf("got persons %v, want %v", ids, test.wantIDs)
+			}
+		})
+	}
+}
+
+func TestEventPersonsReportsMalformedPayload(t *testing.T) {
+	_, ok, err := eventPersons(&client.ChaincodeEvent{EventName: eventPersonsUpdated, Payload: []byte(`[]`)})
+	if !ok || err == nil {
+		t.Errorf("got ok %t and error %v, want the malformed payload reported", ok, err)
+	}
+}
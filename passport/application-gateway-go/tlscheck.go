@@ -0,0 +1,110 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// tlsCheckCommand performs a bare TLS handshake with the peer endpoint and describes the certificate the peer
+// presents, checking it against the configured TLS CA certificate and gateway peer host name the gRPC connection
+// expects: tls-check [-timeout duration]
+//
+// The handshake accepts any certificate, so that a mismatch is reported rather than failing the way a gRPC connection
+// would with little more than "certificate is valid for ..., not ...".
+func tlsCheckCommand(config *Config, args []string) error {
+	flags := flag.NewFlagSet("tls-check", flag.ContinueOnError)
+	timeout := flags.Duration("timeout", 5*time.Second, "time allowed to connect and complete the handshake")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: tls-check [-timeout duration]")
+	}
+
+	// peers requiring mutual TLS abort the handshake of a client presenting no certificate
+	clientCertificates, err := loadTLSClientCertificates(config)
+	if err != nil {
+		return err
+	}
+
+	dialer := &net.Dialer{Timeout: *timeout}
+	// verification is done below, against the configured CA, once the certificate has been printed
+	connection, err := tls.DialWithDialer(dialer, "tcp", config.PeerEndpoint, &tls.Config{InsecureSkipVerify: true, ServerName: config.GatewayPeer, Certificates: clientCertificates})
+	if err != nil {
+		return fmt.Errorf("TLS handshake with %s failed: %w", config.PeerEndpoint, err)
+	}
+	defer connection.Close()
+
+	state := connection.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return fmt.Errorf("%s presented no certificate", config.PeerEndpoint)
+	}
+	certificate := state.PeerCertificates[0]
+
+	fmt.Printf("Endpoint:    %s (%s)\n", config.PeerEndpoint, tls.CipherSuiteName(state.CipherSuite))
+	fmt.Printf("Subject:     %s\n", certificate.Subject)
+	fmt.Printf("Issuer:      %s\n", certificate.Issuer)
+	fmt.Printf("SANs:        %s\n", strings.Join(subjectAltNames(certificate), ", "))
+	fmt.Printf("Valid:       %s to %s\n", certificate.NotBefore.UTC().Format(time.RFC3339), certificate.NotAfter.UTC().Format(time.RFC3339))
+
+	problems := 0
+	if err := checkValidity(certificate, time.Now()); err != nil {
+		problems++
+		fmt.Printf("WARNING: %s\n", err)
+	}
+	if err := certificate.VerifyHostname(config.GatewayPeer); err != nil {
+		problems++
+		fmt.Printf("WARNING: the certificate does not match the gateway peer host name %s, set gatewayPeer in the configuration to one of its SANs\n", config.GatewayPeer)
+	}
+	if err := verifyPeerChain(config, state.PeerCertificates); err != nil {
+		problems++
+		fmt.Printf("WARNING: the certificate is not signed by the TLS CA certificate (%s): %s\n", credentialSource(tlsCAPEMEnv, config.TLSCertPath), err)
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("%d TLS problems found", problems)
+	}
+	fmt.Println("The peer certificate matches the configuration")
+	return nil
+}
+
+// subjectAltNames lists the DNS names and IP addresses a certificate is valid for.
+func subjectAltNames(certificate *x509.Certificate) []string {
+	var names []string
+	names = append(names, certificate.DNSNames...)
+	for _, ip := range certificate.IPAddresses {
+		names = append(names, ip.String())
+	}
+	if len(names) == 0 {
+		names = append(names, "none")
+	}
+	return names
+}
+
+// verifyPeerChain checks the certificates presented by the peer against the configured TLS CA certificate, leaving the
+// host name aside.
+func verifyPeerChain(config *Config, certificates []*x509.Certificate) error {
+	caCertificate, err := loadCertificate(tlsCAPEMEnv, config.TLSCertPath)
+	if err != nil {
+		return err
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(caCertificate)
+	intermediates := x509.NewCertPool()
+	for _, certificate := range certificates[1:] {
+		intermediates.AddCert(certificate)
+	}
+
+	_, err = certificates[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+	return err
+}
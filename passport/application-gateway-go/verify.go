@@ -0,0 +1,107 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// verifyURLEnv names the environment variable giving the default verification service URL.
+const verifyURLEnv = "PASSPORT_VERIFY_URL"
+
+// serialVerification is the response expected from the verification service.
+type serialVerification struct {
+	Valid   bool   `json:"valid"`
+	Country string `json:"country"`
+	Reason  string `json:"reason"`
+}
+
+// verifyCommand checks the passport serial of a person with an external verification service:
+// verify [-url u] [-timeout d] <id>
+//
+// This is the oracle pattern done on the client side. The chaincode cannot call the service itself, since every
+// endorsing peer would have to get the same answer for the transaction to be valid, so the ledger record is read, the
+// service is asked, and the outcome is only reported, never written back. The service is called as
+// GET <url>?serial=<serial> and must answer 200 with a JSON body {"valid": bool, "country": "...", "reason": "..."}.
+func verifyCommand(contract *client.Contract, args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	serviceURL := flags.String("url", os.Getenv(verifyURLEnv), "URL of the verification service, defaults to $"+verifyURLEnv)
+	timeout := flags.Duration("timeout", 5*time.Second, "deadline for the verification service to answer")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: verify [-url u] [-timeout d] <id>")
+	}
+	if *serviceURL == "" {
+		return fmt.Errorf("no verification service configured, use -url or set %s", verifyURLEnv)
+	}
+
+	personId := flags.Arg(0)
+	person, err := readPerson(contract, personId)
+	if err != nil {
+		return fmt.Errorf("failed to read person %s: %w", personId, err)
+	}
+
+	verification, err := verifySerial(*serviceURL, *timeout, person.Serial)
+	if err != nil {
+		return err
+	}
+
+	if !verification.Valid {
+		return fmt.Errorf("passport %s of person %s is not valid: %s", person.Serial, personId, verification.Reason)
+	}
+	fmt.Printf("Passport %s of person %s is valid, issued by %s\n", person.Serial, personId, verification.Country)
+	return nil
+}
+
+// verifySerial asks the verification service about a passport serial.
+func verifySerial(serviceURL string, timeout time.Duration, serial string) (*serialVerification, error) {
+	requestURL, err := url.Parse(serviceURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid verification service URL: %w", err)
+	}
+	query := requestURL.Query()
+	query.Set("serial", serial)
+	requestURL.RawQuery = query.Encode()
+
+	httpClient := &http.Client{Timeout: timeout}
+	response, err := httpClient.Get(requestURL.String())
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) && urlErr.Timeout() {
+			return nil, fmt.Errorf("verification service did not answer within %s", timeout)
+		}
+		return nil, fmt.Errorf("failed to reach verification service: %w", err)
+	}
+	defer response.Body.Close()
+
+	// bound what is read from a service we do not control
+	body, err := ioutil.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read verification service response: %w", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("verification service answered %s: %s", response.Status, strings.TrimSpace(string(body)))
+	}
+
+	var verification serialVerification
+	if err := json.Unmarshal(body, &verification); err != nil {
+		return nil, fmt.Errorf("unexpected verification service response: %w", err)
+	}
+	return &verification, nil
+}
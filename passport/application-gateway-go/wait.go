@@ -0,0 +1,91 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// waitForCommand polls a person until one of its attributes has the expected value, so scripts can wait for another
+// party's transaction to land: wait-for [-interval d] [-timeout d] <id> <field>=<value>
+// A person that does not exist yet is waited for like any other mismatch.
+func waitForCommand(contract *client.Contract, args []string) error {
+	flags := flag.NewFlagSet("wait-for", flag.ContinueOnError)
+	interval := flags.Duration("interval", 2*time.Second, "time between two reads of the person")
+	timeout := flags.Duration("timeout", time.Minute, "give up after this long")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return errors.New("usage: wait-for [-interval d] [-timeout d] <id> <field>=<value>")
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %s", *interval)
+	}
+
+	personId := flags.Arg(0)
+	field, expected, ok := splitFieldValue(flags.Arg(1))
+	if !ok {
+		return fmt.Errorf("invalid condition %q, expected <field>=<value>", flags.Arg(1))
+	}
+
+	deadline := time.Now().Add(*timeout)
+	for {
+		actual, found, err := personFieldValue(contract, personId, field)
+		if err != nil {
+			return err
+		}
+		if found && actual == expected {
+			fmt.Printf("Person %s has %s=%s\n", personId, field, expected)
+			return nil
+		}
+
+		if !time.Now().Add(*interval).Before(deadline) {
+			if !found {
+				return fmt.Errorf("timed out after %s waiting for person %s to exist", *timeout, personId)
+			}
+			return fmt.Errorf("timed out after %s waiting for %s=%s, person %s has %s=%s", *timeout, field, expected, personId, field, actual)
+		}
+		time.Sleep(*interval)
+	}
+}
+
+// splitFieldValue parses a <field>=<value> condition and checks that the field is a person attribute.
+func splitFieldValue(condition string) (string, string, bool) {
+	parts := strings.SplitN(condition, "=", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	for _, field := range personFields(Person{}) {
+		if field.Name == parts[0] {
+			return parts[0], parts[1], true
+		}
+	}
+	return "", "", false
+}
+
+// personFieldValue reads the current value of one attribute of a person. found is false when the person does not exist.
+func personFieldValue(contract *client.Contract, personId string, name string) (string, bool, error) {
+	person, err := readPerson(contract, personId)
+	if code, _ := chaincodeError(err); code == errCodeNotFound {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read person %s: %w", personId, err)
+	}
+
+	for _, field := range personFields(*person) {
+		if field.Name == name {
+			return field.Value, true, nil
+		}
+	}
+	return "", true, nil
+}
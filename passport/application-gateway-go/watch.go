@@ -0,0 +1,72 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// watchPersonEvents prints every chaincode event of the passport chaincode until ctx is done: its name, transaction,
+// block and payload. Without options only events committed from now on are received, client.WithStartBlock replays
+// them from an earlier block.
+func watchPersonEvents(ctx context.Context, network *client.Network, chaincode string, options ...client.ChaincodeEventsOption) error {
+	events, err := network.ChaincodeEvents(ctx, chaincode, options...)
+	if err != nil {
+		return fmt.Errorf("failed to listen for chaincode events: %w", err)
+	}
+
+	for event := range events {
+		payload := string(event.Payload)
+		if json.Valid(event.Payload) {
+			payload = formatJSON(event.Payload)
+		}
+		fmt.Printf("\n%s in tx %s, block %d\n%s\n", event.EventName, event.TransactionID, event.BlockNumber, payload)
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+	return errors.New("the chaincode event stream ended unexpectedly")
+}
+
+// watchEventsInteractive prompts for a start block and watches chaincode events until Enter is pressed, returning to
+// the menu, or Ctrl-C ends the session.
+func watchEventsInteractive(network *client.Network, contract *client.Contract) error {
+	input, err := promptLine("Start block (empty for new events only): ")
+	if err != nil {
+		return err
+	}
+	var options []client.ChaincodeEventsOption
+	if input != "" {
+		startBlock, err := strconv.ParseUint(input, 10, 64)
+		if err != nil {
+			fmt.Printf("Invalid block number %q\n", input)
+			return nil
+		}
+		options = append(options, client.WithStartBlock(startBlock))
+	}
+
+	ctx, cancel := context.WithCancel(appLifecycle.Context())
+	cancel = appLifecycle.AddCancel("chaincode event watcher", cancel)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- watchPersonEvents(ctx, network, contract.ChaincodeName(), options...)
+	}()
+
+	fmt.Println("Watching chaincode events, press Enter to return to the menu")
+	_, inputErr := readLine()
+	cancel()
+	if err := <-done; err != nil {
+		printGatewayError(err)
+	}
+	return inputErr
+}
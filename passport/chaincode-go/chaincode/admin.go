@@ -0,0 +1,42 @@
+package chaincode
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// adminAttribute is the certificate attribute, issued by a Fabric CA, that grants administrative rights when "true".
+	adminAttribute = "passport.admin"
+	// adminOU is the organizational unit of admin identities on networks with NodeOUs enabled.
+	adminOU = "admin"
+)
+
+// requireAdmin fails unless the submitting identity is an administrator, i.e. its certificate carries the
+// passport.admin=true attribute or the admin organizational unit.
+func requireAdmin(ctx contractapi.TransactionContextInterface) error {
+	identity := ctx.GetClientIdentity()
+	if identity.AssertAttributeValue(adminAttribute, "true") == nil {
+		return nil
+	}
+
+	cert, err := identity.GetX509Certificate()
+	if err != nil {
+		return fmt.Errorf("failed to read the client certificate: %v", err)
+	}
+	mspID, err := identity.GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to read the client MSP ID: %v", err)
+	}
+	if cert == nil {
+		return fmt.Errorf("the client of %s is not an administrator", mspID)
+	}
+
+	for _, unit := range cert.Subject.OrganizationalUnit {
+		if unit == adminOU {
+			return nil
+		}
+	}
+	return fmt.Errorf("the client %s of %s is not an administrator", cert.Subject.CommonName, mspID)
+}
@@ -0,0 +1,152 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
+)
+
+// archiveIndex is the object type of the composite keys holding archived persons. Composite keys are excluded from
+// range scans, so archived persons drop out of every listing and query while their records stay on the ledger unchanged.
+const archiveIndex = "archive~id"
+
+// ArchivePersonsByCity moves every person living in the given city to the archive and returns how many were moved.
+// The spouse links of the archived persons are removed on both sides. Only administrators may archive.
+func (s *SmartContract) ArchivePersonsByCity(ctx contractapi.TransactionContextInterface, city string) (int, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return 0, err
+	}
+	city = validation.Normalize(city)
+	if city == "" {
+		return 0, fmt.Errorf("city must not be empty")
+	}
+
+	persons, err := s.getAllPersons(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	inCity := make(map[string]bool)
+	for _, person := range persons {
+		if person.City == city {
+			inCity[person.ID] = true
+		}
+	}
+
+	archived := 0
+	for _, person := range persons {
+		if !inCity[person.ID] {
+			continue
+		}
+		// an archived person is no one's spouse any more. A spouse archived in the same transaction is only unlinked
+		// on its own archived record, since the writes made here are not visible to the reads of unlinkDeletedSpouse.
+		if person.SpouseID != "" {
+			if !inCity[person.SpouseID] {
+				if err := s.unlinkDeletedSpouse(ctx, person); err != nil {
+					return 0, err
+				}
+			}
+			person.SpouseID = ""
+		}
+		if err := archivePerson(ctx, person); err != nil {
+			return 0, err
+		}
+		archived++
+	}
+	return archived, nil
+}
+
+// GetArchivedPerson returns the archived person with given id.
+func (s *SmartContract) GetArchivedPerson(ctx contractapi.TransactionContextInterface, id string) (*Person, error) {
+	id = validation.Normalize(id)
+	key, err := archiveKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	personJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if personJSON == nil {
+		return nil, fmt.Errorf("the person %s is not archived", id)
+	}
+
+	var person Person
+	if err := json.Unmarshal(personJSON, &person); err != nil {
+		return nil, err
+	}
+	return &person, nil
+}
+
+// RestoreArchivedPerson moves the archived person with given id back to the active persons. It fails when an active
+// person has taken the id in the meantime.
+func (s *SmartContract) RestoreArchivedPerson(ctx contractapi.TransactionContextInterface, id string) error {
+	id = validation.Normalize(id)
+	person, err := s.GetArchivedPerson(ctx, id)
+	if err != nil {
+		return err
+	}
+	exists, err := s.PersonExists(ctx, id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("the person %s already exists, delete it before restoring the archived one", id)
+	}
+
+	if err := putPersons(ctx, person); err != nil {
+		return err
+	}
+	if err := putPersonIndexes(ctx, person); err != nil {
+		return err
+	}
+
+	key, err := archiveKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().DelState(key)
+}
+
+// archivePerson moves an active person to the archive, dropping its index entries. The audit record stays in place.
+func archivePerson(ctx contractapi.TransactionContextInterface, person *Person) error {
+	personJSON, err := json.Marshal(person)
+	if err != nil {
+		return err
+	}
+	key, err := archiveKey(ctx, person.ID)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().PutState(key, personJSON); err != nil {
+		return fmt.Errorf("failed to archive %s: %v", person.ID, err)
+	}
+
+	if err := deletePersonIndexes(ctx, person); err != nil {
+		return err
+	}
+	return ctx.GetStub().DelState(person.ID)
+}
+
+// isArchived reports whether the archive holds a person with given id.
+func isArchived(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
+	key, err := archiveKey(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	personJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	return personJSON != nil, nil
+}
+
+func archiveKey(ctx contractapi.TransactionContextInterface, id string) (string, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(archiveIndex, []string{id})
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s key: %v", archiveIndex, err)
+	}
+	return key, nil
+}
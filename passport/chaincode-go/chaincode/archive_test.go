@@ -0,0 +1,70 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newArchiveStub returns a stub submitting as an administrator, with the married persons person1 of Moscow and person2
+// of the given city linked as spouses.
+func newArchiveStub(t *testing.T, spouseCity string) *testStub {
+	t.Helper()
+	stub := newTestStub(t)
+	stub.setCreator(t, "Org1MSP", "admin")
+	stub.mustInvoke(t, "CreatePerson", personArgs("person1")...)
+	args := personArgs("person2")
+	args[argCity] = spouseCity
+	stub.mustInvoke(t, "CreatePerson", args...)
+	stub.mustInvoke(t, "MarryPersons", "person1", "person2")
+	return stub
+}
+
+// archivedPerson returns the person with given id as the archive holds it.
+func archivedPerson(t *testing.T, stub *testStub, id string) Person {
+	t.Helper()
+	var person Person
+	require.NoError(t, json.Unmarshal(stub.mustInvoke(t, "GetArchivedPerson", id), &person))
+	return person
+}
+
+func TestArchivePersonsByCityUnlinksSpouse(t *testing.T) {
+	stub := newArchiveStub(t, "Kazan")
+
+	require.Equal(t, "1", string(stub.mustInvoke(t, "ArchivePersonsByCity", "Moscow")))
+
+	require.Empty(t, storedPerson(t, stub, "person2").SpouseID, "the active spouse is unlinked")
+	require.Empty(t, archivedPerson(t, stub, "person1").SpouseID, "the archived person is unlinked")
+}
+
+func TestArchivePersonsByCityUnlinksSpousesArchivedTogether(t *testing.T) {
+	stub := newArchiveStub(t, "Moscow")
+
+	require.Equal(t, "2", string(stub.mustInvoke(t, "ArchivePersonsByCity", "Moscow")))
+
+	require.Nil(t, stub.State["person1"])
+	require.Nil(t, stub.State["person2"])
+	require.Empty(t, archivedPerson(t, stub, "person1").SpouseID)
+	require.Empty(t, archivedPerson(t, stub, "person2").SpouseID)
+}
+
+func TestCreatePersonRejectsArchivedID(t *testing.T) {
+	stub := newArchiveStub(t, "Kazan")
+	stub.mustInvoke(t, "ArchivePersonsByCity", "Moscow")
+
+	message := stub.invokeError(t, "CreatePerson", personArgs("person1")...)
+	require.Contains(t, message, CodeConflict+": ")
+	require.Contains(t, message, "is archived")
+	require.Nil(t, stub.State["person1"])
+}
+
+func TestCreatePersonAutoIDSkipsArchivedIDs(t *testing.T) {
+	stub := newTestStub(t)
+	stub.setCreator(t, "Org1MSP", "admin")
+	stub.mustInvoke(t, "CreatePerson", personArgs(autoIDPrefix+"0")...)
+	stub.mustInvoke(t, "ArchivePersonsByCity", "Moscow")
+
+	id := stub.mustInvoke(t, "CreatePersonAutoID", personArgs("")[argSerial:]...)
+	require.Equal(t, autoIDPrefix+"1", string(id))
+}
@@ -0,0 +1,178 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// auditIndex is the object type of the composite keys holding the audit companion record of each person. A record
+// outlives the person it describes, so the trail of who created what survives deletions.
+const auditIndex = "audit~id"
+
+// AuditRecord tells when, by which organization and in which transaction a person was created.
+type AuditRecord struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	CreatedBy string    `json:"createdBy"`
+	TxID      string    `json:"txId"`
+}
+
+// putCreationAudit writes the audit companion record of a person created by the current transaction.
+func putCreationAudit(ctx contractapi.TransactionContextInterface, id string) error {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return err
+	}
+	createdAt, err := ptypes.Timestamp(timestamp)
+	if err != nil {
+		return err
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to read the client MSP ID: %v", err)
+	}
+
+	record := AuditRecord{
+		ID:        id,
+		CreatedAt: createdAt,
+		CreatedBy: mspID,
+		TxID:      ctx.GetStub().GetTxID(),
+	}
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey(auditIndex, []string{id})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, recordJSON)
+}
+
+// getAuditRecords returns every audit companion record in key order.
+func getAuditRecords(ctx contractapi.TransactionContextInterface) ([]*AuditRecord, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(auditIndex, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var records []*AuditRecord
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var record AuditRecord
+		err = json.Unmarshal(queryResponse.Value, &record)
+		if err != nil {
+			return nil, fmt.Errorf("malformed audit record %q: %v", queryResponse.Key, err)
+		}
+		records = append(records, &record)
+	}
+
+	return records, nil
+}
+
+// GetPersonsCreatedBetween returns the existing persons whose audit record shows they were created at or after start
+// and before end, both RFC3339 timestamps. Persons created before audit records were introduced have none and are
+// never returned.
+func (s *SmartContract) GetPersonsCreatedBetween(ctx contractapi.TransactionContextInterface, startRFC3339 string, endRFC3339 string) ([]*Person, error) {
+	start, err := time.Parse(time.RFC3339, startRFC3339)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start %q, expected RFC3339: %v", startRFC3339, err)
+	}
+	end, err := time.Parse(time.RFC3339, endRFC3339)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end %q, expected RFC3339: %v", endRFC3339, err)
+	}
+	if !start.Before(end) {
+		return nil, fmt.Errorf("start %s must be before end %s", startRFC3339, endRFC3339)
+	}
+
+	records, err := getAuditRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	persons := []*Person{}
+	for _, record := range records {
+		if record.CreatedAt.Before(start) || !record.CreatedAt.Before(end) {
+			continue
+		}
+
+		exists, err := s.PersonExists(ctx, record.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+		person, err := s.readPerson(ctx, record.ID)
+		if err != nil {
+			return nil, err
+		}
+		persons = append(persons, person)
+	}
+
+	return persons, nil
+}
+
+// FindOrphanedAudits returns the ids of the persons that have an audit record but no longer exist, neither active nor
+// archived, so operators can decide whether to keep those records for compliance or prune them. It does not modify
+// the world state.
+func (s *SmartContract) FindOrphanedAudits(ctx contractapi.TransactionContextInterface) ([]string, error) {
+	records, err := getAuditRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orphaned := []string{}
+	for _, record := range records {
+		exists, err := s.PersonExists(ctx, record.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			exists, err = isArchived(ctx, record.ID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if !exists {
+			orphaned = append(orphaned, record.ID)
+		}
+	}
+
+	return orphaned, nil
+}
+
+// PruneOrphanedAudits deletes the audit records FindOrphanedAudits reports and returns how many were deleted. Only
+// administrators may prune, since the records may have to be retained.
+func (s *SmartContract) PruneOrphanedAudits(ctx contractapi.TransactionContextInterface) (int, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return 0, err
+	}
+
+	orphaned, err := s.FindOrphanedAudits(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range orphaned {
+		key, err := ctx.GetStub().CreateCompositeKey(auditIndex, []string{id})
+		if err != nil {
+			return 0, err
+		}
+		if err := ctx.GetStub().DelState(key); err != nil {
+			return 0, fmt.Errorf("failed to delete the audit record of %s: %v", id, err)
+		}
+	}
+
+	return len(orphaned), nil
+}
@@ -0,0 +1,78 @@
+package chaincode
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// personCounterKey is the object type of the composite key holding the next number CreatePersonAutoID will try.
+	personCounterKey = "counter~person"
+	autoIDPrefix     = "person"
+)
+
+// CreatePersonAutoID creates a person under an id generated from a counter kept in the world state and returns that
+// id. Ids already taken, for instance by persons created with CreatePerson or archived since, are skipped.
+//
+// The id must be derived from the world state only, never from randomness or the clock, so that every endorsing peer
+// generates the same one. The counter is read and written within the transaction: two concurrent calls read the same
+// value and the second one to commit fails with an MVCC read conflict instead of reusing the id, so callers creating
+// many persons at once should expect and retry such failures.
+func (s *SmartContract) CreatePersonAutoID(ctx contractapi.TransactionContextInterface,
+	serial string,
+	name string,
+	surname string,
+	city string,
+	address string,
+	phone string,
+	married bool) (string, error) {
+
+	counterKey, err := ctx.GetStub().CreateCompositeKey(personCounterKey, []string{})
+	if err != nil {
+		return "", err
+	}
+	counterValue, err := ctx.GetStub().GetState(counterKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to read from world state: %v", err)
+	}
+
+	var next uint64
+	if counterValue != nil {
+		next, err = strconv.ParseUint(string(counterValue), 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("malformed person counter %q: %v", counterValue, err)
+		}
+	}
+
+	var id string
+	for {
+		id = autoIDPrefix + strconv.FormatUint(next, 10)
+		next++
+
+		exists, err := s.PersonExists(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		archived, err := isArchived(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		if !exists && !archived {
+			break
+		}
+	}
+
+	err = s.CreatePerson(ctx, id, serial, name, surname, city, address, phone, married)
+	if err != nil {
+		return "", err
+	}
+
+	err = ctx.GetStub().PutState(counterKey, []byte(strconv.FormatUint(next, 10)))
+	if err != nil {
+		return "", fmt.Errorf("failed to put to world state. %v", err)
+	}
+
+	return id, nil
+}
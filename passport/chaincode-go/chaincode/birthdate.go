@@ -0,0 +1,55 @@
+package chaincode
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
+)
+
+// checkBirthdateNotFuture rejects a birthdate later than the date of the transaction. The transaction timestamp is
+// used rather than the clock of the peer, so every endorser reaches the same verdict.
+func checkBirthdateNotFuture(ctx contractapi.TransactionContextInterface, birthdate string) error {
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	if err := validation.BirthdateNotAfter(birthdate, now.UTC()); err != nil {
+		return codedError(CodeValidation, err)
+	}
+	return nil
+}
+
+// GetPersonAge returns the age in whole years of the person with given id on the date of the transaction. It fails for
+// a person without a recorded birthdate.
+func (s *SmartContract) GetPersonAge(ctx contractapi.TransactionContextInterface, id string) (int, error) {
+	id = validation.Normalize(id)
+	person, err := s.readPerson(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if person.Birthdate == "" {
+		return 0, fmt.Errorf("%s: the person %s has no recorded birthdate", CodeNotFound, person.ID)
+	}
+	birthdate, err := time.Parse(validation.BirthdateLayout, person.Birthdate)
+	if err != nil {
+		return 0, fmt.Errorf("malformed birthdate %q of person %s: %v", person.Birthdate, person.ID, err)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return ageOn(birthdate, now.UTC()), nil
+}
+
+// ageOn returns the number of full years between birthdate and now. A person born on 29 February comes of a new age
+// on 1 March in common years.
+func ageOn(birthdate time.Time, now time.Time) int {
+	age := now.Year() - birthdate.Year()
+	if now.Month() < birthdate.Month() || (now.Month() == birthdate.Month() && now.Day() < birthdate.Day()) {
+		age--
+	}
+	return age
+}
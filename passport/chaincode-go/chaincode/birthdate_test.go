@@ -0,0 +1,40 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// createPersonBornOn creates a valid person with given id and birthdate.
+func createPersonBornOn(t *testing.T, stub *testStub, id string, birthdate string) {
+	t.Helper()
+	stub.mustInvoke(t, "CreatePersonWithDetails", `{"id":"`+id+`","passport":"0510 228148","name":"Ivan","surname":"Petrov","city":"Moscow","address":"Lenina 1","phone":"88005553535","birthdate":"`+birthdate+`"}`)
+}
+
+func TestGetPersonAge(t *testing.T) {
+	stub := newTestStub(t)
+	createPersonBornOn(t, stub, "person1", "1990-05-17")
+	createPersonBornOn(t, stub, "person2", "1990-02-01")
+
+	require.Equal(t, "33", string(stub.mustInvoke(t, "GetPersonAge", "person1")), "the birthday of this year is still to come")
+	require.Equal(t, "34", string(stub.mustInvoke(t, "GetPersonAge", "person2")))
+}
+
+func TestGetPersonAgeWithoutBirthdate(t *testing.T) {
+	stub := newTestStub(t)
+	stub.mustInvoke(t, "CreatePerson", personArgs("person1")...)
+
+	message := stub.invokeError(t, "GetPersonAge", "person1")
+	require.Contains(t, message, CodeNotFound+": ")
+	require.Contains(t, message, "has no recorded birthdate")
+}
+
+func TestCreatePersonRejectsFutureBirthdate(t *testing.T) {
+	stub := newTestStub(t)
+
+	message := stub.invokeError(t, "CreatePersonWithDetails", `{"id":"person1","passport":"0510 228148","name":"Ivan","surname":"Petrov","city":"Moscow","address":"Lenina 1","phone":"88005553535","birthdate":"2030-01-01"}`)
+	require.Contains(t, message, CodeValidation+": ")
+	require.Contains(t, message, "is in the future")
+	require.Nil(t, stub.State["person1"])
+}
@@ -0,0 +1,218 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
+)
+
+// CreatePersonsBulk creates every person of a JSON array in a single transaction and returns how many were created.
+// The batch is atomic: if any person is invalid or already exists, nothing is written and the error lists the problems
+// of every rejected person. The batch emits one PersonsCreated event listing every person it created, in place of a
+// PersonCreated event per person.
+func (s *SmartContract) CreatePersonsBulk(ctx contractapi.TransactionContextInterface, personsJSON string) (int, error) {
+	var persons []Person
+	err := json.Unmarshal([]byte(personsJSON), &persons)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse persons: %v", err)
+	}
+
+	// writes made earlier in this transaction are not visible to GetState, so duplicates within the batch are
+	// tracked here rather than relying on the existence check in CreatePerson
+	seen := make(map[string]bool, len(persons))
+	var problems []string
+	var event PersonsCreatedEvent
+	for i, person := range persons {
+		id := validation.Normalize(person.ID)
+		if seen[id] {
+			problems = append(problems, fmt.Sprintf("person %d: the person %s appears more than once in the batch", i, id))
+			continue
+		}
+		seen[id] = true
+
+		created, err := s.createPerson(ctx, person)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("person %d: %v", i, err))
+			continue
+		}
+		event.Persons = append(event.Persons, created)
+	}
+	// every person is checked before failing, so a batch can be fixed in a single pass
+	if len(problems) > 0 {
+		return 0, fmt.Errorf("%d of %d persons were rejected: %s", len(problems), len(persons), strings.Join(problems, "; "))
+	}
+
+	if len(event.Persons) > 0 {
+		if err := setPersonsCreatedEvent(ctx, event); err != nil {
+			return 0, err
+		}
+	}
+	if err := addOpCount(ctx, len(persons)); err != nil {
+		return 0, err
+	}
+	return len(persons), nil
+}
+
+// DeletePersonsBulk deletes every person of a JSON array of ids in a single transaction, together with their index
+// entries, and returns how many were deleted. The batch is atomic. An id that does not exist fails the whole batch,
+// unless skipMissing is set, in which case it is ignored and not counted. Ids are trimmed like everywhere else, and an
+// id listed twice is deleted once. A person linked to a spouse fails the batch too, spouses have to be unlinked before
+// being deleted in bulk.
+//
+// The batch emits one PersonsDeleted event listing the ids it deleted, in place of a PersonDeleted event per person. A
+// batch that deletes nobody emits no event.
+func (s *SmartContract) DeletePersonsBulk(ctx contractapi.TransactionContextInterface, idsJSON string, skipMissing bool) (int, error) {
+	var ids []string
+	err := json.Unmarshal([]byte(idsJSON), &ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse ids: %v", err)
+	}
+
+	// deletions made earlier in this transaction are not visible to GetState, so repeated ids are tracked here
+	var event PersonsDeletedEvent
+	seen := make(map[string]bool, len(ids))
+	for i, id := range ids {
+		id = validation.Normalize(id)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		exists, err := s.PersonExists(ctx, id)
+		if err != nil {
+			return 0, fmt.Errorf("id %d: %v", i, err)
+		}
+		if !exists {
+			if skipMissing {
+				continue
+			}
+			return 0, fmt.Errorf("id %d: the person %s does not exist", i, id)
+		}
+
+		err = s.DeletePerson(ctx, id)
+		if err != nil {
+			return 0, fmt.Errorf("id %d: %v", i, err)
+		}
+		event.IDs = append(event.IDs, id)
+	}
+
+	if len(event.IDs) > 0 {
+		err = setPersonsDeletedEvent(ctx, event)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(event.IDs), nil
+}
+
+// BulkResult reports the outcome of every update of an UpdatePersonsBulk batch.
+type BulkResult struct {
+	Updated []string      `json:"updated"`
+	Failed  []BulkFailure `json:"failed"`
+}
+
+// BulkFailure is an update of a batch that was skipped, and why.
+type BulkFailure struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// UpdatePersonsBulk applies every update of a JSON array of persons, each replacing the person with the same id the way
+// UpdatePerson does, and reports which ones were applied and why the others were skipped.
+//
+// Unlike CreatePersonsBulk the batch is not atomic, yet it is a single transaction: the valid updates are written and
+// commit together, the invalid ones are left out and listed in the result. An update is skipped when UpdatePerson
+// rejects it before writing anything, such as for an invalid field or a person that does not exist; an id listed
+// twice is applied once, its later updates are skipped. Failures of the world state itself still fail the whole
+// transaction, so nothing is written at all then.
+//
+// A transaction carries a single chaincode event, so the batch emits one PersonsUpdated event listing every person it
+// updated as stored, in place of a PersonUpdated event per person. A batch that updates nobody emits no event.
+func (s *SmartContract) UpdatePersonsBulk(ctx contractapi.TransactionContextInterface, updatesJSON string) (*BulkResult, error) {
+	var persons []Person
+	err := json.Unmarshal([]byte(updatesJSON), &persons)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse updates: %v", err)
+	}
+
+	result := &BulkResult{Updated: []string{}, Failed: []BulkFailure{}}
+	var event PersonsUpdatedEvent
+	// writes made earlier in this transaction are not visible to GetState, so a second update of an id would be
+	// applied on top of the stored person and leave the index entries of the first one behind
+	seen := make(map[string]bool, len(persons))
+	for _, person := range persons {
+		id := validation.Normalize(person.ID)
+		if seen[id] {
+			result.Failed = append(result.Failed, BulkFailure{ID: id, Error: fmt.Sprintf("the person %s appears more than once in the batch", id)})
+			continue
+		}
+		seen[id] = true
+
+		updated, err := s.updatePerson(ctx, person, carryAllOptional)
+		if err != nil {
+			result.Failed = append(result.Failed, BulkFailure{ID: id, Error: err.Error()})
+			continue
+		}
+		result.Updated = append(result.Updated, id)
+		event.Persons = append(event.Persons, updated)
+	}
+
+	if len(event.Persons) > 0 {
+		err = setPersonsUpdatedEvent(ctx, event)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// PersonsByIDs is the outcome of GetPersonsByIDs: the persons found and the ids of those that do not exist.
+type PersonsByIDs struct {
+	Persons []*Person `json:"persons"`
+	Missing []string  `json:"missing"`
+}
+
+// GetPersonsByIDs reads every person of a JSON array of ids in a single call. Persons are returned in the order of
+// their first occurrence, an id listed twice is read once, and ids that do not exist are listed as missing rather than
+// failing the call. An empty array gives empty lists.
+func (s *SmartContract) GetPersonsByIDs(ctx contractapi.TransactionContextInterface, idsJSON string) (*PersonsByIDs, error) {
+	var ids []string
+	err := json.Unmarshal([]byte(idsJSON), &ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ids: %v", err)
+	}
+
+	result := &PersonsByIDs{Persons: []*Person{}, Missing: []string{}}
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		id = validation.Normalize(id)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		// the state database refuses empty keys, and no person can have an empty id
+		if id == "" {
+			result.Missing = append(result.Missing, id)
+			continue
+		}
+
+		personJSON, err := ctx.GetStub().GetState(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read from world state: %v", err)
+		}
+		if personJSON == nil {
+			result.Missing = append(result.Missing, id)
+			continue
+		}
+		var person Person
+		if err := json.Unmarshal(personJSON, &person); err != nil {
+			return nil, fmt.Errorf("malformed person %s: %v", id, err)
+		}
+		result.Persons = append(result.Persons, &person)
+	}
+
+	return result, nil
+}
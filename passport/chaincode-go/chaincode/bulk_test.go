@@ -0,0 +1,148 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeletePersonsBulkNormalizesIDs(t *testing.T) {
+	stub := newTestStub(t)
+	stub.mustInvoke(t, "CreatePerson", personArgs("person1")...)
+	stub.mustInvoke(t, "CreatePerson", personArgs("person2")...)
+
+	deleted := stub.mustInvoke(t, "DeletePersonsBulk", `["person1\n", " person1", "\tperson2"]`, "false")
+
+	require.Equal(t, "2", string(deleted))
+	require.Nil(t, stub.State["person1"])
+	require.Nil(t, stub.State["person2"])
+}
+
+func TestDeletePersonsBulkEmitsOneEventForTheBatch(t *testing.T) {
+	stub := newTestStub(t)
+	stub.mustInvoke(t, "CreatePerson", personArgs("person1")...)
+	stub.mustInvoke(t, "CreatePerson", personArgs("person2")...)
+
+	stub.mustInvoke(t, "DeletePersonsBulk", `["person1", "person3", "person2", "person1"]`, "true")
+
+	require.NotNil(t, stub.event, "no event was emitted")
+	require.Equal(t, eventPersonsDeleted, stub.event.EventName)
+	var event PersonsDeletedEvent
+	require.NoError(t, json.Unmarshal(stub.event.Payload, &event))
+	require.Equal(t, []string{"person1", "person2"}, event.IDs)
+}
+
+func TestDeletePersonsBulkDeletingNobodyEmitsNoEvent(t *testing.T) {
+	stub := newTestStub(t)
+
+	require.Equal(t, "0", string(stub.mustInvoke(t, "DeletePersonsBulk", `["person1"]`, "true")))
+	require.Nil(t, stub.event)
+}
+
+func TestCreatePersonsBulkEmitsOneEventForTheBatch(t *testing.T) {
+	stub := newTestStub(t)
+
+	stub.mustInvoke(t, "CreatePersonsBulk", "["+bulkUpdate("person1", "Omsk")+","+bulkUpdate("person2", "Kazan")+"]")
+
+	require.NotNil(t, stub.event, "no event was emitted")
+	require.Equal(t, eventPersonsCreated, stub.event.EventName)
+	var event PersonsCreatedEvent
+	require.NoError(t, json.Unmarshal(stub.event.Payload, &event))
+	require.Len(t, event.Persons, 2)
+	require.Equal(t, "person1", event.Persons[0].ID)
+	require.Equal(t, "Omsk", event.Persons[0].City)
+	require.Equal(t, "person2", event.Persons[1].ID)
+	require.Equal(t, "Kazan", event.Persons[1].City)
+}
+
+func TestCreatePersonsBulkReportsEveryProblemOfEveryPerson(t *testing.T) {
+	stub := newTestStub(t)
+
+	message := stub.invokeError(t, "CreatePersonsBulk", `[
+		{"id":"person1","passport":"12345","name":"Ivan2","surname":"Petrov","city":"Moscow","address":"Lenina 1","phone":"88005553535"},
+		{"id":"person2","passport":"0510 228148","name":"Anna","surname":"Petrova","city":"Moscow","address":"Lenina 1","phone":"88005553536"},
+		{"id":"person3","passport":"0510 228149","name":"Oleg","surname":"","city":"Moscow","address":"Lenina 2","phone":"12-34"}
+	]`)
+
+	require.Contains(t, message, "2 of 3 persons were rejected")
+	require.Contains(t, message, "person 0: "+CodeValidation+": 2 validation errors")
+	require.Contains(t, message, "serial must be four digits")
+	require.Contains(t, message, "name may only contain letters")
+	require.Contains(t, message, "person 2: "+CodeValidation+": 2 validation errors")
+	require.Contains(t, message, "surname is a required field")
+	require.Contains(t, message, "phone must consist of digits only")
+}
+
+// bulkUpdate returns the JSON of an update of the person with given id moving it to city.
+func bulkUpdate(id string, city string) string {
+	return `{"id":"` + id + `","passport":"0510 228148","name":"Ivan","surname":"Petrov","city":"` + city + `","address":"Lenina 1","phone":"88005553535"}`
+}
+
+// updatedEventCities returns the city of every person of the PersonsUpdated event of the last transaction, by id.
+func updatedEventCities(t *testing.T, stub *testStub) map[string]string {
+	t.Helper()
+	require.NotNil(t, stub.event, "no event was emitted")
+	require.Equal(t, eventPersonsUpdated, stub.event.EventName)
+
+	var event PersonsUpdatedEvent
+	require.NoError(t, json.Unmarshal(stub.event.Payload, &event))
+	cities := map[string]string{}
+	for _, person := range event.Persons {
+		cities[person.ID] = person.City
+	}
+	return cities
+}
+
+func TestUpdatePersonsBulkUpdatesEveryPerson(t *testing.T) {
+	stub := newTestStub(t)
+	stub.mustInvoke(t, "CreatePerson", personArgs("person1")...)
+	stub.mustInvoke(t, "CreatePerson", personArgs("person2")...)
+
+	var result BulkResult
+	resultJSON := stub.mustInvoke(t, "UpdatePersonsBulk", "["+bulkUpdate("person1", "Omsk")+","+bulkUpdate("person2", "Kazan")+"]")
+	require.NoError(t, json.Unmarshal(resultJSON, &result))
+
+	require.Equal(t, []string{"person1", "person2"}, result.Updated)
+	require.Empty(t, result.Failed)
+	require.Equal(t, "Omsk", storedPerson(t, stub, "person1").City)
+	require.Equal(t, "Kazan", storedPerson(t, stub, "person2").City)
+	require.Equal(t, map[string]string{"person1": "Omsk", "person2": "Kazan"}, updatedEventCities(t, stub))
+}
+
+func TestUpdatePersonsBulkWritesTheValidUpdatesOfAMixedBatch(t *testing.T) {
+	stub := newTestStub(t)
+	stub.mustInvoke(t, "CreatePerson", personArgs("person1")...)
+	stub.mustInvoke(t, "CreatePerson", personArgs("person2")...)
+	invalid := `{"id":"person2","passport":"12345","name":"Ivan","surname":"Petrov","city":"Omsk","address":"Lenina 1","phone":"88005553535"}`
+
+	var result BulkResult
+	resultJSON := stub.mustInvoke(t, "UpdatePersonsBulk", "["+bulkUpdate("person1", "Omsk")+","+invalid+","+
+		bulkUpdate("person3", "Omsk")+","+bulkUpdate("person1", "Kazan")+"]")
+	require.NoError(t, json.Unmarshal(resultJSON, &result))
+
+	require.Equal(t, []string{"person1"}, result.Updated)
+	require.Len(t, result.Failed, 3)
+	require.Equal(t, "person2", result.Failed[0].ID)
+	require.Contains(t, result.Failed[0].Error, CodeValidation+": ")
+	require.Equal(t, "person3", result.Failed[1].ID)
+	require.Contains(t, result.Failed[1].Error, CodeNotFound+": ")
+	require.Equal(t, "person1", result.Failed[2].ID)
+	require.Contains(t, result.Failed[2].Error, "more than once")
+
+	require.Equal(t, "Omsk", storedPerson(t, stub, "person1").City)
+	require.Equal(t, "Moscow", storedPerson(t, stub, "person2").City)
+	require.Nil(t, stub.State["person3"])
+	require.Equal(t, map[string]string{"person1": "Omsk"}, updatedEventCities(t, stub), "only the applied update is in the event")
+}
+
+func TestUpdatePersonsBulkUpdatingNobodyEmitsNoEvent(t *testing.T) {
+	stub := newTestStub(t)
+
+	var result BulkResult
+	require.NoError(t, json.Unmarshal(stub.mustInvoke(t, "UpdatePersonsBulk", "["+bulkUpdate("person1", "Omsk")+"]"), &result))
+
+	require.Empty(t, result.Updated)
+	require.Len(t, result.Failed, 1)
+	require.Nil(t, stub.event)
+}
@@ -0,0 +1,156 @@
+package chaincode
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
+)
+
+// allowedCityIndex is the object type of the composite keys listing the allowed cities. While the check is enabled
+// and at least one city is listed, CreatePerson and UpdatePerson only accept the listed cities.
+const allowedCityIndex = "allowedcity~name"
+
+// allowedCitiesEnabledKey is the object type of the composite key switching the allowed-city check on. The check is
+// off while the key is absent, whatever cities are listed.
+const allowedCitiesEnabledKey = "config~allowedcities"
+
+// SetAllowedCitiesEnabled switches the allowed-city check of CreatePerson and UpdatePerson on or off. Only
+// administrators may switch it.
+func (s *SmartContract) SetAllowedCitiesEnabled(ctx contractapi.TransactionContextInterface, enabled bool) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey(allowedCitiesEnabledKey, []string{})
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return ctx.GetStub().DelState(key)
+	}
+	return ctx.GetStub().PutState(key, []byte{0x00})
+}
+
+// AreAllowedCitiesEnabled reports whether the allowed-city check is switched on.
+func (s *SmartContract) AreAllowedCitiesEnabled(ctx contractapi.TransactionContextInterface) (bool, error) {
+	return allowedCitiesEnabled(ctx)
+}
+
+func allowedCitiesEnabled(ctx contractapi.TransactionContextInterface) (bool, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(allowedCitiesEnabledKey, []string{})
+	if err != nil {
+		return false, err
+	}
+	value, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	return value != nil, nil
+}
+
+// AddAllowedCity adds a city to the set of cities persons may live in. Only administrators may change the set.
+func (s *SmartContract) AddAllowedCity(ctx contractapi.TransactionContextInterface, city string) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+
+	city = validation.Normalize(city)
+	if err := validation.Field(validation.FieldCity, city); err != nil {
+		return err
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey(allowedCityIndex, []string{city})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, []byte{0x00})
+}
+
+// RemoveAllowedCity removes a city from the set of allowed cities. Persons already living there keep their city
+// until they are updated. Removing the last city lifts the restriction even while the check is enabled.
+func (s *SmartContract) RemoveAllowedCity(ctx contractapi.TransactionContextInterface, city string) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+
+	city = validation.Normalize(city)
+	allowed, err := isAllowedCityListed(ctx, city)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("the city %s is not in the allowed set", city)
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey(allowedCityIndex, []string{city})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().DelState(key)
+}
+
+// GetAllowedCities returns the allowed cities in key order. An empty list means any city is accepted, as does a
+// disabled check.
+func (s *SmartContract) GetAllowedCities(ctx contractapi.TransactionContextInterface) ([]string, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(allowedCityIndex, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	cities := []string{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		if len(attributes) != 1 {
+			return nil, fmt.Errorf("malformed %s key %q", allowedCityIndex, queryResponse.Key)
+		}
+		cities = append(cities, attributes[0])
+	}
+
+	return cities, nil
+}
+
+// checkAllowedCity fails when the allowed-city check is enabled, the set of allowed cities is not empty and it does
+// not contain the given city.
+func checkAllowedCity(ctx contractapi.TransactionContextInterface, city string) error {
+	enabled, err := allowedCitiesEnabled(ctx)
+	if err != nil || !enabled {
+		return err
+	}
+
+	allowed, err := isAllowedCityListed(ctx, city)
+	if err != nil || allowed {
+		return err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(allowedCityIndex, []string{})
+	if err != nil {
+		return err
+	}
+	defer resultsIterator.Close()
+
+	if resultsIterator.HasNext() {
+		return fmt.Errorf("%s: the city %s is not in the allowed set", CodeValidation, city)
+	}
+	return nil
+}
+
+func isAllowedCityListed(ctx contractapi.TransactionContextInterface, city string) (bool, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(allowedCityIndex, []string{city})
+	if err != nil {
+		return false, err
+	}
+	value, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	return value != nil, nil
+}
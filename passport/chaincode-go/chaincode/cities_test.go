@@ -0,0 +1,70 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newCitiesStub returns a stub whose ledger allows Moscow only, submitting as an administrator.
+func newCitiesStub(t *testing.T) *testStub {
+	t.Helper()
+	stub := newTestStub(t)
+	stub.setCreator(t, "Org1MSP", "admin")
+	stub.mustInvoke(t, "AddAllowedCity", "Moscow")
+	return stub
+}
+
+func TestAllowedCitiesAreIgnoredWhileDisabled(t *testing.T) {
+	stub := newCitiesStub(t)
+	args := personArgs("person1")
+	args[argCity] = "Kazan"
+
+	stub.mustInvoke(t, "CreatePerson", args...)
+	require.Equal(t, "false", string(stub.mustInvoke(t, "AreAllowedCitiesEnabled")))
+}
+
+func TestAllowedCitiesRestrictCitiesWhileEnabled(t *testing.T) {
+	stub := newCitiesStub(t)
+	stub.mustInvoke(t, "SetAllowedCitiesEnabled", "true")
+	stub.mustInvoke(t, "CreatePerson", personArgs("person1")...)
+
+	args := personArgs("person2")
+	args[argCity] = "Kazan"
+	message := stub.invokeError(t, "CreatePerson", args...)
+	require.Contains(t, message, CodeValidation+": the city Kazan is not in the allowed set")
+
+	args = personArgs("person1")
+	args[argCity] = "Kazan"
+	message = stub.invokeError(t, "UpdatePerson", args...)
+	require.Contains(t, message, "the city Kazan is not in the allowed set")
+	require.Equal(t, "Moscow", storedPerson(t, stub, "person1").City)
+}
+
+func TestEnabledAllowedCitiesAcceptAnyCityWhileEmpty(t *testing.T) {
+	stub := newCitiesStub(t)
+	stub.mustInvoke(t, "SetAllowedCitiesEnabled", "true")
+	stub.mustInvoke(t, "RemoveAllowedCity", "Moscow")
+	args := personArgs("person1")
+	args[argCity] = "Kazan"
+
+	stub.mustInvoke(t, "CreatePerson", args...)
+}
+
+func TestAllowedCitiesCanBeDisabledAgain(t *testing.T) {
+	stub := newCitiesStub(t)
+	stub.mustInvoke(t, "SetAllowedCitiesEnabled", "true")
+	stub.mustInvoke(t, "SetAllowedCitiesEnabled", "false")
+	args := personArgs("person1")
+	args[argCity] = "Kazan"
+
+	stub.mustInvoke(t, "CreatePerson", args...)
+}
+
+func TestAllowedCitiesAreChangedByAdministratorsOnly(t *testing.T) {
+	stub := newTestStub(t)
+
+	stub.invokeError(t, "AddAllowedCity", "Moscow")
+	stub.invokeError(t, "SetAllowedCitiesEnabled", "true")
+	require.Equal(t, "false", string(stub.mustInvoke(t, "AreAllowedCitiesEnabled")))
+}
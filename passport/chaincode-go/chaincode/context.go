@@ -0,0 +1,36 @@
+package chaincode
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ContextInfo is the chaincode's view of the transaction invoking it.
+type ContextInfo struct {
+	ChannelID   string    `json:"channelId"`
+	TxID        string    `json:"txId"`
+	TxTimestamp time.Time `json:"txTimestamp"`
+	CreatorMSP  string    `json:"creatorMspId"`
+}
+
+// GetContextInfo returns the channel, transaction id and timestamp and the MSP ID of the client as the chaincode sees
+// them, so clients can confirm they reach the intended channel with the intended identity.
+func (s *SmartContract) GetContextInfo(ctx contractapi.TransactionContextInterface) (*ContextInfo, error) {
+	timestamp, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the client MSP ID: %v", err)
+	}
+
+	return &ContextInfo{
+		ChannelID:   ctx.GetStub().GetChannelID(),
+		TxID:        ctx.GetStub().GetTxID(),
+		TxTimestamp: timestamp,
+		CreatorMSP:  mspID,
+	}, nil
+}
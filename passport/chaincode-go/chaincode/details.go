@@ -0,0 +1,98 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// personDetails is the JSON object CreatePersonWithDetails and UpdatePersonWithDetails take. It holds only the fields
+// a client sets, so that a field managed by its own transactions, such as the spouse link, is rejected instead of
+// silently ignored. The optional fields are pointers, so that an update tells an absent field, carried over from the
+// stored person, from an empty one, which removes the value recorded so far.
+type personDetails struct {
+	ID        string  `json:"id"`
+	Serial    string  `json:"passport"`
+	Name      string  `json:"name"`
+	Surname   string  `json:"surname"`
+	City      string  `json:"city"`
+	Address   string  `json:"address"`
+	Phone     string  `json:"phone"`
+	Married   bool    `json:"married"`
+	Reference *string `json:"reference"`
+	Gender    *string `json:"gender"`
+	Birthdate *string `json:"birthdate"`
+}
+
+// parsePersonDetails decodes a personDetails object, rejecting unknown fields.
+func parsePersonDetails(personJSON string) (*personDetails, error) {
+	var details personDetails
+	decoder := json.NewDecoder(bytes.NewReader([]byte(personJSON)))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&details)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to parse person: %v", CodeValidation, err)
+	}
+	return &details, nil
+}
+
+// person returns the details as a person, with the absent optional fields empty.
+func (details *personDetails) person() Person {
+	person := Person{
+		ID:      details.ID,
+		Serial:  details.Serial,
+		Name:    details.Name,
+		Surname: details.Surname,
+		City:    details.City,
+		Address: details.Address,
+		Phone:   details.Phone,
+		Married: details.Married,
+	}
+	if details.Reference != nil {
+		person.Reference = *details.Reference
+	}
+	if details.Gender != nil {
+		person.Gender = *details.Gender
+	}
+	if details.Birthdate != nil {
+		person.Birthdate = *details.Birthdate
+	}
+	return person
+}
+
+// CreatePersonWithDetails issues a new person to the world state under the same rules as CreatePerson, taking the
+// person as a JSON object that may also set the optional details, the reference, the gender and the birthdate.
+func (s *SmartContract) CreatePersonWithDetails(ctx contractapi.TransactionContextInterface, personJSON string) error {
+	details, err := parsePersonDetails(personJSON)
+	if err != nil {
+		return err
+	}
+	_, err = s.createPerson(ctx, details.person())
+	if err != nil {
+		return err
+	}
+	return addOpCount(ctx, 1)
+}
+
+// UpdatePersonWithDetails updates an existing person from a JSON object, only if its stored representation still
+// matches the given ETag, like UpdatePersonIfMatch. An optional field absent from the object is carried over from the
+// stored person, an empty one removes the value recorded so far.
+func (s *SmartContract) UpdatePersonWithDetails(ctx contractapi.TransactionContextInterface, personJSON string, etag string) error {
+	details, err := parsePersonDetails(personJSON)
+	if err != nil {
+		return err
+	}
+	person := details.person()
+	err = checkETag(ctx, person.ID, etag)
+	if err != nil {
+		return err
+	}
+	_, err = s.updatePerson(ctx, person, carriedOver{
+		reference: details.Reference == nil,
+		gender:    details.Gender == nil,
+		birthdate: details.Birthdate == nil,
+	})
+	return err
+}
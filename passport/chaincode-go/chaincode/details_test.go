@@ -0,0 +1,106 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const detailsJSON = `{"id":"person1","passport":"0510 228148","name":"Ivan","surname":"Petrov","city":"Moscow","address":"Lenina 1","phone":"88005553535","married":false,"reference":"crm-42","gender":"F","birthdate":"1990-05-17"}`
+
+func TestCreatePersonWithDetailsStoresOptionalDetails(t *testing.T) {
+	stub := newTestStub(t)
+
+	stub.mustInvoke(t, "CreatePersonWithDetails", detailsJSON)
+
+	person := storedPerson(t, stub, "person1")
+	require.Equal(t, "crm-42", person.Reference)
+	require.Equal(t, "F", person.Gender)
+	require.Equal(t, "1990-05-17", person.Birthdate)
+	require.Equal(t, "88005553535", person.Phone)
+}
+
+func TestCreatePersonWithDetailsRejectsFieldsManagedElsewhere(t *testing.T) {
+	stub := newTestStub(t)
+
+	message := stub.invokeError(t, "CreatePersonWithDetails", `{"id":"person1","spouseId":"person2"}`)
+	require.Contains(t, message, CodeValidation+": ")
+	require.Contains(t, message, "spouseId")
+	require.Nil(t, stub.State["person1"])
+}
+
+func TestUpdatePersonCarriesOptionalDetailsOver(t *testing.T) {
+	stub := newTestStub(t)
+	stub.mustInvoke(t, "CreatePersonWithDetails", detailsJSON)
+	args := personArgs("person1")
+	args[argCity] = "Kazan"
+
+	stub.mustInvoke(t, "UpdatePerson", args...)
+	stub.mustInvoke(t, "UpdatePersonsBulk", `[{"id":"person1","passport":"0510 228148","name":"Ivan","surname":"Petrov","city":"Omsk","address":"Lenina 1","phone":"88005553535"}]`)
+
+	person := storedPerson(t, stub, "person1")
+	require.Equal(t, "Omsk", person.City)
+	require.Equal(t, "crm-42", person.Reference)
+	require.Equal(t, "F", person.Gender)
+	require.Equal(t, "1990-05-17", person.Birthdate)
+}
+
+// optionalDetail returns the optional detail of a person stored under the given JSON key.
+func optionalDetail(person Person, key string) string {
+	return map[string]string{
+		"reference": person.Reference,
+		"gender":    person.Gender,
+		"birthdate": person.Birthdate,
+	}[key]
+}
+
+func TestUpdatePersonWithDetailsOptionalFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		key    string
+		update string
+		want   string
+	}{
+		{"absent reference carried over", "reference", ``, "crm-42"},
+		{"empty reference removed", "reference", `,"reference":""`, ""},
+		{"new reference replaced", "reference", `,"reference":"crm-43"`, "crm-43"},
+		{"absent gender carried over", "gender", ``, "F"},
+		{"empty gender removed", "gender", `,"gender":""`, ""},
+		{"new gender replaced", "gender", `,"gender":"X"`, "X"},
+		{"absent birthdate carried over", "birthdate", ``, "1990-05-17"},
+		{"empty birthdate removed", "birthdate", `,"birthdate":""`, ""},
+		{"new birthdate replaced", "birthdate", `,"birthdate":"1991-06-18"`, "1991-06-18"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stub := newTestStub(t)
+			stub.mustInvoke(t, "CreatePersonWithDetails", detailsJSON)
+			created := storedPerson(t, stub, "person1")
+
+			update := `{"id":"person1","passport":"0510 228148","name":"Ivan","surname":"Petrov","city":"Kazan","address":"Lenina 1","phone":"88005553535"` + test.update + `}`
+			stub.mustInvoke(t, "UpdatePersonWithDetails", update, readETag(t, stub, "person1"))
+
+			person := storedPerson(t, stub, "person1")
+			require.Equal(t, "Kazan", person.City)
+			require.Equal(t, test.want, optionalDetail(person, test.key))
+			for _, other := range []string{"reference", "gender", "birthdate"} {
+				if other != test.key {
+					require.Equal(t, optionalDetail(created, other), optionalDetail(person, other), "the %s is carried over", other)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdatePersonWithDetailsRejectsStaleETag(t *testing.T) {
+	stub := newTestStub(t)
+	stub.mustInvoke(t, "CreatePersonWithDetails", detailsJSON)
+	etag := readETag(t, stub, "person1")
+	args := personArgs("person1")
+	args[argCity] = "Kazan"
+	stub.mustInvoke(t, "UpdatePerson", args...)
+
+	message := stub.invokeError(t, "UpdatePersonWithDetails", detailsJSON, etag)
+	require.Contains(t, message, CodeConflict+": ")
+	require.Equal(t, "Kazan", storedPerson(t, stub, "person1").City)
+}
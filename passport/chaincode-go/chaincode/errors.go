@@ -0,0 +1,20 @@
+package chaincode
+
+import (
+	"fmt"
+)
+
+// Codes prefixed to the messages of the errors clients may want to tell apart, such as
+// "NOT_FOUND: the person person7 does not exist". The rest of the message is meant for humans and stays as it was.
+const (
+	CodeNotFound   = "NOT_FOUND"
+	CodeValidation = "VALIDATION"
+	CodeConflict   = "CONFLICT"
+	// CodeHistoryUnavailable marks the failure of a history function on a peer without a history database.
+	CodeHistoryUnavailable = "HISTORY_UNAVAILABLE"
+)
+
+// codedError prefixes the message of err with code.
+func codedError(code string, err error) error {
+	return fmt.Errorf("%s: %w", code, err)
+}
@@ -0,0 +1,74 @@
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
+)
+
+// VersionedPerson is a person together with the ETag of its stored representation.
+// Clients read it with ReadPersonWithETag and pass the ETag back to UpdatePersonIfMatch to detect concurrent modifications.
+type VersionedPerson struct {
+	Person
+	ETag string `json:"etag"`
+}
+
+// computeETag returns the hex-encoded SHA-256 hash of a stored person.
+func computeETag(personJSON []byte) string {
+	hash := sha256.Sum256(personJSON)
+	return hex.EncodeToString(hash[:])
+}
+
+// ReadPersonWithETag returns the person stored in the world state with given id, together with its ETag.
+func (s *SmartContract) ReadPersonWithETag(ctx contractapi.TransactionContextInterface, id string) (*VersionedPerson, error) {
+	id = validation.Normalize(id)
+	personJSON, err := getPersonJSON(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var person Person
+	err = json.Unmarshal(personJSON, &person)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VersionedPerson{Person: person, ETag: computeETag(personJSON)}, nil
+}
+
+// UpdatePersonIfMatch updates an existing person only if its stored representation still matches the given ETag,
+// preventing lost updates when two clients edit the same person concurrently.
+func (s *SmartContract) UpdatePersonIfMatch(ctx contractapi.TransactionContextInterface,
+	id string,
+	etag string,
+	serial string,
+	name string,
+	surname string,
+	city string,
+	address string,
+	phone string,
+	married bool) error {
+	err := checkETag(ctx, id, etag)
+	if err != nil {
+		return err
+	}
+
+	return s.UpdatePerson(ctx, id, serial, name, surname, city, address, phone, married)
+}
+
+// checkETag returns a conflict error unless the stored representation of the person with given id matches etag.
+func checkETag(ctx contractapi.TransactionContextInterface, id string, etag string) error {
+	id = validation.Normalize(id)
+	personJSON, err := getPersonJSON(ctx, id)
+	if err != nil {
+		return err
+	}
+	if current := computeETag(personJSON); current != etag {
+		return fmt.Errorf("%s: the person %s has been modified since it was read: etag %s does not match current %s", CodeConflict, id, etag, current)
+	}
+	return nil
+}
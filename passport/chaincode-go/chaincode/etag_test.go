@@ -0,0 +1,58 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// readETag returns the ETag ReadPersonWithETag reports for the person with given id.
+func readETag(t *testing.T, stub *testStub, id string) string {
+	t.Helper()
+	var person VersionedPerson
+	require.NoError(t, json.Unmarshal(stub.mustInvoke(t, "ReadPersonWithETag", id), &person))
+	require.NotEmpty(t, person.ETag)
+	return person.ETag
+}
+
+// updateIfMatchArgs returns the arguments of UpdatePersonIfMatch changing the city of the person created with
+// personArgs(id).
+func updateIfMatchArgs(id string, etag string, city string) []string {
+	args := personArgs(id)
+	args[argCity] = city
+	return append([]string{id, etag}, args[argSerial:]...)
+}
+
+func TestReadPersonReturnsPersonWithoutETag(t *testing.T) {
+	stub := newTestStub(t)
+	stub.mustInvoke(t, "CreatePerson", personArgs("person1")...)
+
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(stub.mustInvoke(t, "ReadPerson", "person1"), &fields))
+	require.Equal(t, "person1", fields["id"])
+	require.NotContains(t, fields, "etag")
+}
+
+func TestUpdatePersonIfMatchAppliesMatchingETag(t *testing.T) {
+	stub := newTestStub(t)
+	stub.mustInvoke(t, "CreatePerson", personArgs("person1")...)
+	etag := readETag(t, stub, "person1")
+
+	stub.mustInvoke(t, "UpdatePersonIfMatch", updateIfMatchArgs("person1", etag, "Kazan")...)
+
+	require.Equal(t, "Kazan", storedPerson(t, stub, "person1").City)
+	require.NotEqual(t, etag, readETag(t, stub, "person1"), "an update changes the ETag")
+}
+
+func TestUpdatePersonIfMatchRejectsStaleETag(t *testing.T) {
+	stub := newTestStub(t)
+	stub.mustInvoke(t, "CreatePerson", personArgs("person1")...)
+	etag := readETag(t, stub, "person1")
+	stub.mustInvoke(t, "UpdatePersonIfMatch", updateIfMatchArgs("person1", etag, "Kazan")...)
+
+	message := stub.invokeError(t, "UpdatePersonIfMatch", updateIfMatchArgs("person1", etag, "Omsk")...)
+	require.Contains(t, message, CodeConflict+": ")
+	require.Contains(t, message, "has been modified since it was read")
+	require.Equal(t, "Kazan", storedPerson(t, stub, "person1").City)
+}
@@ -0,0 +1,82 @@
+package chaincode
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Names of the chaincode events emitted when persons are written or deleted. The payload of the first two is the
+// person as stored, that of PersonDeleted a PersonDeletedEvent, which decodes into a Person carrying only its id. The
+// bulk transactions emit a single event for the whole batch: PersonsCreated carries a PersonsCreatedEvent,
+// PersonsUpdated a PersonsUpdatedEvent and PersonsDeleted a PersonsDeletedEvent.
+const (
+	eventPersonCreated  = "PersonCreated"
+	eventPersonUpdated  = "PersonUpdated"
+	eventPersonDeleted  = "PersonDeleted"
+	eventPersonsCreated = "PersonsCreated"
+	eventPersonsUpdated = "PersonsUpdated"
+	eventPersonsDeleted = "PersonsDeleted"
+)
+
+// PersonDeletedEvent is the payload of the PersonDeleted event.
+type PersonDeletedEvent struct {
+	ID string `json:"id"`
+}
+
+// PersonsCreatedEvent is the payload of the PersonsCreated event of CreatePersonsBulk: the persons created, as stored.
+type PersonsCreatedEvent struct {
+	Persons []*Person `json:"persons"`
+}
+
+// PersonsUpdatedEvent is the payload of the PersonsUpdated event of UpdatePersonsBulk: the persons updated, as stored.
+type PersonsUpdatedEvent struct {
+	Persons []*Person `json:"persons"`
+}
+
+// PersonsDeletedEvent is the payload of the PersonsDeleted event of DeletePersonsBulk: the ids of the persons deleted.
+type PersonsDeletedEvent struct {
+	IDs []string `json:"ids"`
+}
+
+// setPersonEvent emits a chaincode event carrying the stored representation of a person. A transaction carries a
+// single event, the last one set, which is how the event of a bulk transaction replaces those of its single writes.
+func setPersonEvent(ctx contractapi.TransactionContextInterface, name string, personJSON []byte) error {
+	return ctx.GetStub().SetEvent(name, personJSON)
+}
+
+// setPersonDeletedEvent emits the PersonDeleted event for the person with the given id.
+func setPersonDeletedEvent(ctx contractapi.TransactionContextInterface, id string) error {
+	payload, err := json.Marshal(PersonDeletedEvent{ID: id})
+	if err != nil {
+		return err
+	}
+	return setPersonEvent(ctx, eventPersonDeleted, payload)
+}
+
+// setPersonsCreatedEvent emits the PersonsCreated event, replacing the event of every single creation before it.
+func setPersonsCreatedEvent(ctx contractapi.TransactionContextInterface, event PersonsCreatedEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return setPersonEvent(ctx, eventPersonsCreated, payload)
+}
+
+// setPersonsUpdatedEvent emits the PersonsUpdated event, replacing the event of every single update before it.
+func setPersonsUpdatedEvent(ctx contractapi.TransactionContextInterface, event PersonsUpdatedEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return setPersonEvent(ctx, eventPersonsUpdated, payload)
+}
+
+// setPersonsDeletedEvent emits the PersonsDeleted event, replacing the event of every single deletion before it.
+func setPersonsDeletedEvent(ctx contractapi.TransactionContextInterface, event PersonsDeletedEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return setPersonEvent(ctx, eventPersonsDeleted, payload)
+}
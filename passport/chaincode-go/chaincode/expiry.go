@@ -0,0 +1,78 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
+)
+
+// parseExpiry accepts a plain date or an RFC3339 timestamp and returns it as an RFC3339 timestamp in UTC. Storing
+// every expiry in this one fixed-width form keeps lexicographic order equal to chronological order, which the
+// CouchDB range selector of GetExpiredPersons relies on. A plain date refers to the start of that day in UTC.
+func parseExpiry(value string) (string, error) {
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		day, dayErr := time.Parse("2006-01-02", value)
+		if dayErr != nil {
+			return "", fmt.Errorf("invalid expiry %q, expected YYYY-MM-DD or RFC3339", value)
+		}
+		at = day
+	}
+	return at.UTC().Format(time.RFC3339), nil
+}
+
+// SetPassportExpiry records when the passport of the person with given id expires.
+func (s *SmartContract) SetPassportExpiry(ctx contractapi.TransactionContextInterface, id string, expiry string) error {
+	id = validation.Normalize(id)
+	normalized, err := parseExpiry(expiry)
+	if err != nil {
+		return err
+	}
+
+	person, err := s.readPerson(ctx, id)
+	if err != nil {
+		return err
+	}
+	person.Expiry = normalized
+
+	personJSON, err := json.Marshal(person)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(id, personJSON)
+}
+
+// GetExpiredPersons returns the persons whose passport expired before the given RFC3339 timestamp. Persons without
+// a recorded expiry are never returned. The selector is served by the indexExpiry index
+// (META-INF/statedb/couchdb/indexes/indexExpiry.json), so it requires CouchDB.
+//
+// An empty asOf defaults to the transaction timestamp rather than the peer's clock: every endorsing peer must
+// compute the same result, and the wall clock differs between peers while the timestamp proposed by the client
+// does not.
+func (s *SmartContract) GetExpiredPersons(ctx contractapi.TransactionContextInterface, asOf string) ([]*Person, error) {
+	if asOf == "" {
+		now, err := txTime(ctx)
+		if err != nil {
+			return nil, err
+		}
+		asOf = now.Format(time.RFC3339)
+	}
+
+	normalized, err := parseExpiry(asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	query := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"expiry": map[string]interface{}{"$lt": normalized},
+		},
+		"use_index": []string{"_design/indexExpiryDoc", "indexExpiry"},
+	}
+
+	return getQueryResultForQueryString(ctx, query)
+}
@@ -0,0 +1,236 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
+)
+
+// ErrHistoryUnavailable is returned by the history functions when the peer runs with its history database disabled
+// (core.ledger.history.enableHistoryDatabase set to false).
+var ErrHistoryUnavailable = errors.New(CodeHistoryUnavailable + ": history is not enabled on this network")
+
+// getHistoryForKey wraps GetHistoryForKey, translating the peer's "history database is not enabled" failure
+// into ErrHistoryUnavailable so clients can tell it apart from other errors.
+func getHistoryForKey(ctx contractapi.TransactionContextInterface, key string) (shim.HistoryQueryIteratorInterface, error) {
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(key)
+	if err != nil {
+		if isHistoryDisabledError(err) {
+			return nil, ErrHistoryUnavailable
+		}
+		return nil, err
+	}
+	return resultsIterator, nil
+}
+
+func isHistoryDisabledError(err error) bool {
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "history database") && strings.Contains(message, "not enabled")
+}
+
+// maxHistoryPayload bounds the JSON size of the updates returned by one history call, leaving headroom below the
+// 4 MiB default gRPC message limit of the peer and client for the response envelope.
+const maxHistoryPayload = 3 << 20
+
+// historyTruncatedSuggestion is attached to a history that did not fit in one response.
+const historyTruncatedSuggestion = "the history is too large to return at once, use GetPersonHistoryPage to read the older updates"
+
+// PersonHistory is a list of updates of one person, most recent first. Truncated is set when older updates were
+// left out, either to stay within the response size limit or because they belong to a later page.
+type PersonHistory struct {
+	Updates    []Update `json:"updates"`
+	Truncated  bool     `json:"truncated"`
+	Suggestion string   `json:"suggestion,omitempty" metadata:"suggestion,optional"`
+}
+
+// newUpdate decodes a history entry.
+func newUpdate(response *queryresult.KeyModification) (Update, error) {
+	timestamp, err := ptypes.Timestamp(response.Timestamp)
+	if err != nil {
+		return Update{}, err
+	}
+	update := Update{
+		Tx:        response.TxId,
+		Timestamp: timestamp,
+		IsDelete:  response.IsDelete,
+	}
+
+	// a deletion has no value to decode
+	if !response.IsDelete {
+		var person Person
+		if err := json.Unmarshal(response.Value, &person); err != nil {
+			return Update{}, err
+		}
+		update.Data = &person
+	}
+	return update, nil
+}
+
+// personUpdates reads the whole history of the given key, sorted most recent first.
+func personUpdates(ctx contractapi.TransactionContextInterface, id string) ([]Update, error) {
+	resultsIterator, err := getHistoryForKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var updates []Update
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		update, err := newUpdate(response)
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, update)
+	}
+
+	// the peer's history order is not relied upon
+	sort.SliceStable(updates, func(i, j int) bool {
+		return updates[i].Timestamp.After(updates[j].Timestamp)
+	})
+	return updates, nil
+}
+
+// GetPersonHistoryBetween returns the updates of the person with given id made from fromRFC3339 to toRFC3339, both
+// included, most recent first. Deletions within the range are included as updates with IsDelete set. The person need
+// not exist any more.
+func (s *SmartContract) GetPersonHistoryBetween(ctx contractapi.TransactionContextInterface, id string, fromRFC3339 string, toRFC3339 string) ([]Update, error) {
+	id = validation.Normalize(id)
+	from, err := time.Parse(time.RFC3339, fromRFC3339)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from %q, expected RFC3339: %v", fromRFC3339, err)
+	}
+	to, err := time.Parse(time.RFC3339, toRFC3339)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to %q, expected RFC3339: %v", toRFC3339, err)
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("from %s must not be after to %s", fromRFC3339, toRFC3339)
+	}
+
+	updates, err := personUpdates(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	between := []Update{}
+	for _, update := range updates {
+		if !update.Timestamp.Before(from) && !update.Timestamp.After(to) {
+			between = append(between, update)
+		}
+	}
+	return between, nil
+}
+
+// limitHistory keeps the leading updates whose combined JSON size stays within maxHistoryPayload. more tells
+// whether further updates exist beyond the given ones.
+func limitHistory(updates []Update, more bool) (*PersonHistory, error) {
+	history := &PersonHistory{Updates: []Update{}, Truncated: more}
+
+	size := 0
+	for _, update := range updates {
+		updateJSON, err := json.Marshal(update)
+		if err != nil {
+			return nil, err
+		}
+		size += len(updateJSON)
+		if size > maxHistoryPayload {
+			history.Truncated = true
+			break
+		}
+		history.Updates = append(history.Updates, update)
+	}
+
+	if history.Truncated {
+		history.Suggestion = historyTruncatedSuggestion
+	}
+	return history, nil
+}
+
+// GetRecentPersonHistory returns the most recent updates of the person with given id that fit in a single response,
+// most recent first. The result is marked as truncated when older updates were left out.
+func (s *SmartContract) GetRecentPersonHistory(ctx contractapi.TransactionContextInterface, id string) (*PersonHistory, error) {
+	updates, err := s.GetPersonHistory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return limitHistory(updates, false)
+}
+
+// GetPersonHistoryPage returns up to limit updates of the person with given id, most recent first, after skipping
+// the skip most recent ones. Truncated reports that older updates remain, the next page starts at skip plus the
+// number of updates returned. A page may hold fewer than limit updates when they would exceed the response size limit.
+func (s *SmartContract) GetPersonHistoryPage(ctx contractapi.TransactionContextInterface, id string, skip int, limit int) (*PersonHistory, error) {
+	id = validation.Normalize(id)
+	if skip < 0 {
+		return nil, fmt.Errorf("skip must not be negative, got %d", skip)
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive, got %d", limit)
+	}
+
+	updates, more, err := personUpdatesPage(ctx, id, skip, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := limitHistory(updates, more)
+	if err != nil {
+		return nil, err
+	}
+	if more && len(history.Updates) == len(updates) {
+		// a regular page boundary, not a size cut-off
+		history.Suggestion = ""
+	}
+	return history, nil
+}
+
+// personUpdatesPage reads up to limit updates of the given key after skipping the skip most recent ones, and whether
+// older updates remain. Unlike personUpdates it relies on the peer returning the history most recent first, as
+// Fabric 2 does, so that it can stop reading as soon as the page is full.
+func personUpdatesPage(ctx contractapi.TransactionContextInterface, id string, skip int, limit int) ([]Update, bool, error) {
+	resultsIterator, err := getHistoryForKey(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resultsIterator.Close()
+
+	var updates []Update
+	read := 0
+	for resultsIterator.HasNext() {
+		response, err := resultsIterator.Next()
+		if err != nil {
+			return nil, false, err
+		}
+		read++
+		if read <= skip {
+			continue
+		}
+		if len(updates) == limit {
+			return updates, true, nil
+		}
+
+		update, err := newUpdate(response)
+		if err != nil {
+			return nil, false, err
+		}
+		updates = append(updates, update)
+	}
+
+	if read == 0 {
+		return nil, false, fmt.Errorf("the person %s has no history", id)
+	}
+	return updates, false, nil
+}
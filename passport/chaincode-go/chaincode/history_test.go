@@ -0,0 +1,141 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryFunctionsReportDisabledHistoryDatabase(t *testing.T) {
+	calls := []struct {
+		function string
+		args     []string
+	}{
+		{"GetPersonHistory", []string{"person1"}},
+		{"GetPersonHistoryPage", []string{"person1", "0", "10"}},
+		{"GetPersonAtTime", []string{"person1", "2024-03-01T12:00:00Z"}},
+		{"GetPersonHistoryBetween", []string{"person1", "2024-03-01T00:00:00Z", "2024-03-02T00:00:00Z"}},
+	}
+	for _, call := range calls {
+		t.Run(call.function, func(t *testing.T) {
+			stub := newTestStub(t)
+			stub.mustInvoke(t, "CreatePerson", personArgs("person1")...)
+			stub.historyErr = errors.New("history database not enabled")
+
+			message := stub.invokeError(t, call.function, call.args...)
+			require.Contains(t, message, CodeHistoryUnavailable+": ")
+		})
+	}
+}
+
+func TestHistoryFunctionsPassOtherHistoryFailuresThrough(t *testing.T) {
+	stub := newTestStub(t)
+	stub.mustInvoke(t, "CreatePerson", personArgs("person1")...)
+	stub.historyErr = errors.New("ledger is closing")
+
+	message := stub.invokeError(t, "GetPersonHistory", "person1")
+	require.Contains(t, message, "ledger is closing")
+	require.NotContains(t, message, CodeHistoryUnavailable)
+}
+
+// createWithUpdates creates the person with given id and updates its city once per given city, one transaction each.
+func createWithUpdates(t *testing.T, stub *testStub, id string, cities ...string) {
+	t.Helper()
+	stub.mustInvoke(t, "CreatePerson", personArgs(id)...)
+	for _, city := range cities {
+		args := personArgs(id)
+		args[argCity] = city
+		stub.mustInvoke(t, "UpdatePerson", args...)
+	}
+}
+
+func TestGetPersonHistoryReturnsEveryUpdateMostRecentFirst(t *testing.T) {
+	stub := newTestStub(t)
+	createWithUpdates(t, stub, "person1", "Kazan", "Omsk")
+
+	var updates []Update
+	require.NoError(t, json.Unmarshal(stub.mustInvoke(t, "GetPersonHistory", "person1"), &updates))
+	require.Len(t, updates, 3)
+	require.Equal(t, "Omsk", updates[0].Data.City)
+	require.Equal(t, "Moscow", updates[2].Data.City)
+}
+
+func TestGetRecentPersonHistoryReturnsHistoryThatFits(t *testing.T) {
+	stub := newTestStub(t)
+	createWithUpdates(t, stub, "person1", "Kazan")
+
+	var history PersonHistory
+	require.NoError(t, json.Unmarshal(stub.mustInvoke(t, "GetRecentPersonHistory", "person1"), &history))
+	require.Len(t, history.Updates, 2)
+	require.False(t, history.Truncated)
+	require.Empty(t, history.Suggestion)
+}
+
+// largeUpdates returns count updates of about a MiB of JSON each.
+func largeUpdates(count int) []Update {
+	updates := make([]Update, count)
+	for i := range updates {
+		updates[i] = Update{Data: &Person{Address: strings.Repeat("a", 1<<20)}}
+	}
+	return updates
+}
+
+func TestLimitHistoryKeepsUpdatesWithinPayloadLimit(t *testing.T) {
+	history, err := limitHistory(largeUpdates(4), false)
+	require.NoError(t, err)
+	require.Len(t, history.Updates, 2)
+	require.True(t, history.Truncated)
+	require.Equal(t, historyTruncatedSuggestion, history.Suggestion)
+}
+
+func TestLimitHistoryLeavesOutOversizedFirstUpdate(t *testing.T) {
+	updates := []Update{{Data: &Person{Address: strings.Repeat("a", maxHistoryPayload)}}}
+
+	history, err := limitHistory(updates, false)
+	require.NoError(t, err)
+	require.Empty(t, history.Updates)
+	require.True(t, history.Truncated)
+}
+
+func TestGetPersonHistoryPage(t *testing.T) {
+	stub := newTestStub(t)
+	createWithUpdates(t, stub, "person1", "Kazan", "Omsk", "Tver", "Perm")
+
+	tests := []struct {
+		name      string
+		skip      string
+		limit     string
+		cities    []string
+		truncated bool
+	}{
+		{"first page", "0", "2", []string{"Perm", "Tver"}, true},
+		{"middle page", "2", "2", []string{"Omsk", "Kazan"}, true},
+		{"last page", "4", "2", []string{"Moscow"}, false},
+		{"exact last page", "3", "2", []string{"Kazan", "Moscow"}, false},
+		{"past the end", "9", "2", nil, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var history PersonHistory
+			require.NoError(t, json.Unmarshal(stub.mustInvoke(t, "GetPersonHistoryPage", "person1", test.skip, test.limit), &history))
+
+			var cities []string
+			for _, update := range history.Updates {
+				cities = append(cities, update.Data.City)
+			}
+			require.Equal(t, test.cities, cities)
+			require.Equal(t, test.truncated, history.Truncated)
+			require.Empty(t, history.Suggestion, "a page boundary is not a size cut-off")
+		})
+	}
+}
+
+func TestGetPersonHistoryPageRejectsUnknownPerson(t *testing.T) {
+	stub := newTestStub(t)
+
+	message := stub.invokeError(t, "GetPersonHistoryPage", "person1", "0", "10")
+	require.Contains(t, message, "the person person1 has no history")
+}
@@ -0,0 +1,231 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
+)
+
+// personIndex describes a composite-key secondary index of the form "<attribute>~id".
+type personIndex struct {
+	name      string
+	attribute func(person *Person) string
+}
+
+// cityIndex is the composite-key index of persons by city, which GetPersonsByCityIndex reads.
+const cityIndex = "city~id"
+
+// personIndexes lists every composite-key index maintained alongside the primary person records.
+var personIndexes = []personIndex{
+	{name: "surname~id", attribute: func(person *Person) string { return person.Surname }},
+	{name: cityIndex, attribute: func(person *Person) string { return person.City }},
+}
+
+// IndexEntry identifies a single composite-key index entry.
+type IndexEntry struct {
+	Index     string `json:"index"`
+	Attribute string `json:"attribute"`
+	ID        string `json:"id"`
+}
+
+// IndexReport lists the discrepancies found between the composite-key indexes and the primary records.
+// Orphaned entries point at a person that does not exist or no longer has the indexed attribute value,
+// missing entries are the ones a person should have but does not.
+type IndexReport struct {
+	Orphaned []IndexEntry `json:"orphaned"`
+	Missing  []IndexEntry `json:"missing"`
+}
+
+// putPersonIndexes writes every index entry for the given person.
+func putPersonIndexes(ctx contractapi.TransactionContextInterface, person *Person) error {
+	for _, index := range personIndexes {
+		key, err := ctx.GetStub().CreateCompositeKey(index.name, []string{index.attribute(person), person.ID})
+		if err != nil {
+			return fmt.Errorf("failed to create %s index key: %v", index.name, err)
+		}
+		// the value is irrelevant, the composite key alone carries the mapping
+		if err := ctx.GetStub().PutState(key, []byte{0x00}); err != nil {
+			return fmt.Errorf("failed to put %s index entry: %v", index.name, err)
+		}
+	}
+	return nil
+}
+
+// deletePersonIndexes removes every index entry for the given person.
+func deletePersonIndexes(ctx contractapi.TransactionContextInterface, person *Person) error {
+	for _, index := range personIndexes {
+		key, err := ctx.GetStub().CreateCompositeKey(index.name, []string{index.attribute(person), person.ID})
+		if err != nil {
+			return fmt.Errorf("failed to create %s index key: %v", index.name, err)
+		}
+		if err := ctx.GetStub().DelState(key); err != nil {
+			return fmt.Errorf("failed to delete %s index entry: %v", index.name, err)
+		}
+	}
+	return nil
+}
+
+// VerifyIndexes cross-checks every composite-key index entry against the primary person records
+// and reports orphaned and missing entries. It does not modify the world state.
+func (s *SmartContract) VerifyIndexes(ctx contractapi.TransactionContextInterface) (*IndexReport, error) {
+	persons, err := s.getAllPersons(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &IndexReport{Orphaned: []IndexEntry{}, Missing: []IndexEntry{}}
+	for _, index := range personIndexes {
+		if err := verifyIndex(ctx, index, persons, report); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// GetPersonsByCityIndex returns every person living in the given city, looked up through the city~id composite-key
+// index rather than a rich query, so it works on LevelDB as well as CouchDB. Persons written before the index existed
+// are only found once RebuildIndexes has run.
+func (s *SmartContract) GetPersonsByCityIndex(ctx contractapi.TransactionContextInterface, city string) ([]*Person, error) {
+	city = validation.Normalize(city)
+	if err := validation.Required(validation.FieldCity, city); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(cityIndex, []string{city})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var persons []*Person
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		if len(attributes) != 2 {
+			return nil, fmt.Errorf("malformed %s index key %q", cityIndex, queryResponse.Key)
+		}
+
+		personJSON, err := ctx.GetStub().GetState(attributes[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read from world state: %v", err)
+		}
+		// an orphaned entry, as VerifyIndexes would report it, is skipped rather than failing the lookup
+		if personJSON == nil {
+			continue
+		}
+		var person Person
+		if err := json.Unmarshal(personJSON, &person); err != nil {
+			return nil, err
+		}
+		if person.City != city {
+			continue
+		}
+		persons = append(persons, &person)
+	}
+
+	persons, err = excludeExpired(ctx, persons)
+	if err != nil {
+		return nil, err
+	}
+	if persons == nil {
+		persons = []*Person{}
+	}
+	return persons, nil
+}
+
+func verifyIndex(ctx contractapi.TransactionContextInterface, index personIndex, persons []*Person, report *IndexReport) error {
+	personsByID := make(map[string]*Person, len(persons))
+	for _, person := range persons {
+		personsByID[person.ID] = person
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(index.name, []string{})
+	if err != nil {
+		return err
+	}
+	defer resultsIterator.Close()
+
+	indexed := make(map[IndexEntry]bool)
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return err
+		}
+		if len(attributes) != 2 {
+			return fmt.Errorf("malformed %s index key %q", index.name, queryResponse.Key)
+		}
+
+		entry := IndexEntry{Index: index.name, Attribute: attributes[0], ID: attributes[1]}
+		indexed[entry] = true
+
+		person, ok := personsByID[entry.ID]
+		if !ok || index.attribute(person) != entry.Attribute {
+			report.Orphaned = append(report.Orphaned, entry)
+		}
+	}
+
+	for _, person := range persons {
+		entry := IndexEntry{Index: index.name, Attribute: index.attribute(person), ID: person.ID}
+		if !indexed[entry] {
+			report.Missing = append(report.Missing, entry)
+		}
+	}
+
+	return nil
+}
+
+// RebuildIndexes drops every composite-key index entry and recreates them from the primary person records.
+func (s *SmartContract) RebuildIndexes(ctx contractapi.TransactionContextInterface) error {
+	for _, index := range personIndexes {
+		if err := dropIndex(ctx, index); err != nil {
+			return err
+		}
+	}
+
+	persons, err := s.getAllPersons(ctx)
+	if err != nil {
+		return err
+	}
+	for _, person := range persons {
+		if err := putPersonIndexes(ctx, person); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func dropIndex(ctx contractapi.TransactionContextInterface, index personIndex) error {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(index.name, []string{})
+	if err != nil {
+		return err
+	}
+	defer resultsIterator.Close()
+
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return err
+		}
+		if err := ctx.GetStub().DelState(queryResponse.Key); err != nil {
+			return fmt.Errorf("failed to delete %s index entry: %v", index.name, err)
+		}
+	}
+
+	return nil
+}
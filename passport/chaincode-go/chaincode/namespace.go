@@ -0,0 +1,144 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
+)
+
+// namespaceIndex is the object type of the composite keys holding the persons of tenants sharing the chaincode, keyed
+// by namespace and id, so that two tenants can use the same id. Composite keys are excluded from range scans: the
+// persons of a namespace never show up in GetAllPersons and the other un-namespaced listings, nor those listings'
+// persons in a namespace.
+//
+// Namespaced persons are kept out of the composite-key indexes, the audit trail and the person events, which all
+// identify a person by its bare id. Every transaction trims the namespace like an id, so " tenantA" and "tenantA" are
+// the same namespace.
+const namespaceIndex = "ns~id"
+
+// namespaceKey returns the world state key of the person with given id in namespace ns.
+func namespaceKey(ctx contractapi.TransactionContextInterface, ns string, id string) (string, error) {
+	if err := validation.Required("namespace", ns); err != nil {
+		return "", err
+	}
+	key, err := ctx.GetStub().CreateCompositeKey(namespaceIndex, []string{ns, id})
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s key: %v", namespaceIndex, err)
+	}
+	return key, nil
+}
+
+// CreatePersonNS issues a new person to the namespace ns with given details, under the same rules as CreatePerson.
+func (s *SmartContract) CreatePersonNS(ctx contractapi.TransactionContextInterface,
+	ns string,
+	id string,
+	serial string,
+	name string,
+	surname string,
+	city string,
+	address string,
+	phone string,
+	married bool) error {
+
+	person, err := checkPersonDetails(ctx, Person{
+		ID:      id,
+		Serial:  serial,
+		Name:    name,
+		Surname: surname,
+		City:    city,
+		Address: address,
+		Phone:   phone,
+		Married: married,
+	})
+	if err != nil {
+		return err
+	}
+
+	ns = validation.Normalize(ns)
+	key, err := namespaceKey(ctx, ns, person.ID)
+	if err != nil {
+		return err
+	}
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("%s: the person %s already exists in namespace %s", CodeConflict, person.ID, ns)
+	}
+
+	personJSON, err := json.Marshal(person)
+	if err != nil {
+		return err
+	}
+
+	err = ctx.GetStub().PutState(key, personJSON)
+	if err != nil {
+		return err
+	}
+	return addOpCount(ctx, 1)
+}
+
+// ReadPersonNS returns the person with given id in namespace ns.
+func (s *SmartContract) ReadPersonNS(ctx contractapi.TransactionContextInterface, ns string, id string) (*Person, error) {
+	normalize(&ns, &id)
+	key, err := namespaceKey(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	personJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if personJSON == nil {
+		return nil, fmt.Errorf("the person %s does not exist in namespace %s", id, ns)
+	}
+
+	var person Person
+	if err := json.Unmarshal(personJSON, &person); err != nil {
+		return nil, err
+	}
+	return &person, nil
+}
+
+// PersonExistsNS returns true when the person with given id exists in namespace ns.
+func (s *SmartContract) PersonExistsNS(ctx contractapi.TransactionContextInterface, ns string, id string) (bool, error) {
+	normalize(&ns, &id)
+	key, err := namespaceKey(ctx, ns, id)
+	if err != nil {
+		return false, err
+	}
+	personJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	return personJSON != nil, nil
+}
+
+// GetAllPersonsNS returns every person of namespace ns in id order, but for expired provisional ones.
+func (s *SmartContract) GetAllPersonsNS(ctx contractapi.TransactionContextInterface, ns string) ([]*Person, error) {
+	ns = validation.Normalize(ns)
+	if err := validation.Required("namespace", ns); err != nil {
+		return nil, err
+	}
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(namespaceIndex, []string{ns})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	persons, err := personsFromIterator(resultsIterator)
+	if err != nil {
+		return nil, err
+	}
+	persons, err = excludeExpired(ctx, persons)
+	if err != nil {
+		return nil, err
+	}
+	if persons == nil {
+		persons = []*Person{}
+	}
+	return persons, nil
+}
@@ -0,0 +1,97 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// createPersonNS creates a valid person with given id and name in namespace ns.
+func createPersonNS(t *testing.T, stub *testStub, ns string, id string, name string) {
+	t.Helper()
+	args := personArgs(id)
+	args[argName] = name
+	stub.mustInvoke(t, "CreatePersonNS", append([]string{ns}, args...)...)
+}
+
+// personsNS returns the persons GetAllPersonsNS lists for namespace ns.
+func personsNS(t *testing.T, stub *testStub, ns string) []*Person {
+	t.Helper()
+	var persons []*Person
+	require.NoError(t, json.Unmarshal(stub.mustInvoke(t, "GetAllPersonsNS", ns), &persons))
+	return persons
+}
+
+func TestNamespacesHoldTheSameIDApart(t *testing.T) {
+	stub := newTestStub(t)
+	createPersonNS(t, stub, "tenantA", "person1", "Ivan")
+	createPersonNS(t, stub, "tenantB", "person1", "Anna")
+
+	var person Person
+	require.NoError(t, json.Unmarshal(stub.mustInvoke(t, "ReadPersonNS", "tenantA", "person1"), &person))
+	require.Equal(t, "Ivan", person.Name)
+	require.NoError(t, json.Unmarshal(stub.mustInvoke(t, "ReadPersonNS", "tenantB", "person1"), &person))
+	require.Equal(t, "Anna", person.Name)
+
+	require.Equal(t, "false", string(stub.mustInvoke(t, "PersonExistsNS", "tenantC", "person1")))
+	require.Equal(t, "false", string(stub.mustInvoke(t, "PersonExists", "person1")))
+}
+
+func TestGetAllPersonsNSListsOnlyItsNamespace(t *testing.T) {
+	stub := newTestStub(t)
+	createPersonNS(t, stub, "tenantA", "person1", "Ivan")
+	createPersonNS(t, stub, "tenantA", "person2", "Oleg")
+	createPersonNS(t, stub, "tenantB", "person3", "Anna")
+	// a namespace that is a prefix of another must not pick up its persons
+	createPersonNS(t, stub, "tenant", "person4", "Olga")
+	stub.mustInvoke(t, "CreatePerson", personArgs("person5")...)
+
+	var ids []string
+	for _, person := range personsNS(t, stub, "tenantA") {
+		ids = append(ids, person.ID)
+	}
+	require.Equal(t, []string{"person1", "person2"}, ids)
+	require.Len(t, personsNS(t, stub, "tenantB"), 1)
+	require.Len(t, personsNS(t, stub, "tenant"), 1)
+	require.Empty(t, personsNS(t, stub, "tenantC"))
+
+	var persons []*Person
+	require.NoError(t, json.Unmarshal(stub.mustInvoke(t, "GetAllPersons"), &persons))
+	require.Len(t, persons, 1, "namespaced persons stay out of GetAllPersons")
+}
+
+func TestCreatePersonNSAppliesTheCreatePersonRules(t *testing.T) {
+	stub := newTestStub(t)
+	createPersonNS(t, stub, "tenantA", "person1", "Ivan")
+
+	message := stub.invokeError(t, "CreatePersonNS", append([]string{"tenantA"}, personArgs("person1")...)...)
+	require.Contains(t, message, CodeConflict+": ")
+
+	args := personArgs(" person2 ")
+	args[argSerial] = "0510"
+	args[argPhone] = "12"
+	message = stub.invokeError(t, "CreatePersonNS", append([]string{"tenantA"}, args...)...)
+	require.Contains(t, message, CodeValidation+": ")
+	require.Contains(t, message, "2 validation errors")
+
+	args = personArgs(" person2 ")
+	stub.mustInvoke(t, "CreatePersonNS", append([]string{"tenantA"}, args...)...)
+	var person Person
+	require.NoError(t, json.Unmarshal(stub.mustInvoke(t, "ReadPersonNS", "tenantA", "person2"), &person))
+	require.Equal(t, "person2", person.ID)
+	require.Equal(t, "88005553535", person.Phone)
+}
+
+func TestNamespacesAreTrimmedByEveryTransaction(t *testing.T) {
+	stub := newTestStub(t)
+	createPersonNS(t, stub, " tenantA ", "person1", "Ivan")
+
+	var person Person
+	require.NoError(t, json.Unmarshal(stub.mustInvoke(t, "ReadPersonNS", " tenantA ", "person1"), &person))
+	require.Equal(t, "Ivan", person.Name)
+	require.NoError(t, json.Unmarshal(stub.mustInvoke(t, "ReadPersonNS", "tenantA", "person1"), &person))
+	require.Equal(t, "true", string(stub.mustInvoke(t, "PersonExistsNS", "\ttenantA", "person1")))
+	require.Len(t, personsNS(t, stub, "tenantA "), 1)
+	require.Len(t, personsNS(t, stub, "tenantA"), 1)
+}
@@ -0,0 +1,115 @@
+package chaincode
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// opCountIndex is the object type of the composite keys counting the persons each client identity has created,
+// keyed by MSP ID and the identity's unique id as returned by the client identity library.
+//
+// The counter of an identity is read and rewritten by every create it submits, so two creates by the same identity
+// in flight at once conflict: the second one to commit fails with an MVCC read conflict and must be resubmitted.
+// Creates by different identities do not interfere.
+const opCountIndex = "opcount~mspid~certid"
+
+// IdentityOpCount is the number of persons created by one client identity.
+type IdentityOpCount struct {
+	MSPID  string `json:"mspId"`
+	CertID string `json:"certId"`
+	Count  int    `json:"count"`
+}
+
+// addOpCount adds n to the creation counter of the submitting identity.
+func addOpCount(ctx contractapi.TransactionContextInterface, n int) error {
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to read the client MSP ID: %v", err)
+	}
+	certID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to read the client identity: %v", err)
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey(opCountIndex, []string{mspID, certID})
+	if err != nil {
+		return err
+	}
+	count, err := getOpCount(ctx, key)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, []byte(strconv.Itoa(count+n)))
+}
+
+func getOpCount(ctx contractapi.TransactionContextInterface, key string) (int, error) {
+	value, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if value == nil {
+		return 0, nil
+	}
+	count, err := strconv.Atoi(string(value))
+	if err != nil {
+		return 0, fmt.Errorf("malformed operation counter %q: %v", value, err)
+	}
+	return count, nil
+}
+
+// GetIdentityOpCount returns how many persons the identity with given MSP ID and unique id has created, zero for an
+// identity that never created any.
+func (s *SmartContract) GetIdentityOpCount(ctx contractapi.TransactionContextInterface, mspid string, certid string) (int, error) {
+	key, err := ctx.GetStub().CreateCompositeKey(opCountIndex, []string{mspid, certid})
+	if err != nil {
+		return 0, err
+	}
+	return getOpCount(ctx, key)
+}
+
+// GetTopCreators returns the identities that created the most persons, at most limit of them, busiest first. Only
+// administrators may list them, as the list tells who is working on the ledger and how much.
+func (s *SmartContract) GetTopCreators(ctx contractapi.TransactionContextInterface, limit int) ([]IdentityOpCount, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive, got %d", limit)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(opCountIndex, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	counts := []IdentityOpCount{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, err
+		}
+		if len(attributes) != 2 {
+			return nil, fmt.Errorf("malformed %s key %q", opCountIndex, queryResponse.Key)
+		}
+		count, err := strconv.Atoi(string(queryResponse.Value))
+		if err != nil {
+			return nil, fmt.Errorf("malformed operation counter %q: %v", queryResponse.Value, err)
+		}
+		counts = append(counts, IdentityOpCount{MSPID: attributes[0], CertID: attributes[1], Count: count})
+	}
+
+	// keys come in MSP ID and id order, the stable sort keeps it among identities with equal counts
+	sort.SliceStable(counts, func(i, j int) bool { return counts[i].Count > counts[j].Count })
+	if len(counts) > limit {
+		counts = counts[:limit]
+	}
+	return counts, nil
+}
@@ -0,0 +1,55 @@
+package chaincode
+
+import (
+	"encoding/json"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/personpb"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
+)
+
+// PersonProto is a marshaled personpb.Person message. It is sent as a JSON array of its byte values: the contract API
+// describes a byte slice result as an array of integers and rejects the base64 string encoding/json makes of it.
+type PersonProto []byte
+
+// MarshalJSON encodes the message as an array of byte values, which encoding/json decodes back into a []byte.
+func (p PersonProto) MarshalJSON() ([]byte, error) {
+	values := make([]int, len(p))
+	for i, b := range p {
+		values[i] = int(b)
+	}
+	return json.Marshal(values)
+}
+
+// ReadPersonProto returns the person with given id as a marshaled personpb.Person message (see personpb/person.proto)
+// instead of JSON, for integrations that prefer a compact, schema-checked encoding.
+func (s *SmartContract) ReadPersonProto(ctx contractapi.TransactionContextInterface, id string) (PersonProto, error) {
+	id = validation.Normalize(id)
+	person, err := s.readPerson(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	personProto, err := proto.Marshal(&personpb.Person{
+		Id:        person.ID,
+		Serial:    person.Serial,
+		Name:      person.Name,
+		Surname:   person.Surname,
+		City:      person.City,
+		Address:   person.Address,
+		Phone:     person.Phone,
+		Married:   person.Married,
+		Expiry:    person.Expiry,
+		Tags:      person.Tags,
+		Reference: person.Reference,
+		SpouseId:  person.SpouseID,
+		Gender:    person.Gender,
+		ExpiresAt: person.ExpiresAt,
+		Birthdate: person.Birthdate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return personProto, nil
+}
@@ -0,0 +1,24 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/personpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadPersonProtoReturnsEncodedMessage(t *testing.T) {
+	stub := newTestStub(t)
+	stub.mustInvoke(t, "CreatePerson", personArgs("person1")...)
+
+	var personProto []byte
+	require.NoError(t, json.Unmarshal(stub.mustInvoke(t, "ReadPersonProto", "person1"), &personProto))
+
+	var person personpb.Person
+	require.NoError(t, proto.Unmarshal(personProto, &person))
+	require.Equal(t, "person1", person.Id)
+	require.Equal(t, "Lenina 1", person.Address)
+	require.Equal(t, "88005553535", person.Phone)
+}
@@ -0,0 +1,121 @@
+package chaincode
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
+)
+
+// Provisional persons carry an ExpiresAt timestamp. Once it has passed they are left out of GetAllPersons, the
+// paginated and limited listings and every rich query, yet stay in the world state, readable by id, until PurgeExpired
+// deletes them: exclusion happens when a query runs, nothing is deleted on its own since chaincode only ever acts
+// within a transaction someone submits.
+//
+// "Now" is the transaction timestamp, not the peer's clock. Every endorsing peer must compute the same result for
+// the endorsements to match, and the clocks of the peers differ while the timestamp proposed by the client does not.
+
+// txTime returns the timestamp of the current transaction.
+func txTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ptypes.Timestamp(timestamp)
+}
+
+// isExpired reports whether a provisional person expired before now. Expiry timestamps are stored in the fixed-width
+// form of parseExpiry, so comparing the strings compares the times.
+func isExpired(person *Person, now time.Time) bool {
+	return person.ExpiresAt != "" && person.ExpiresAt < now.UTC().Format(time.RFC3339)
+}
+
+// excludeExpired returns the persons that have not expired as of the transaction timestamp.
+func excludeExpired(ctx contractapi.TransactionContextInterface, persons []*Person) ([]*Person, error) {
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var live []*Person
+	for _, person := range persons {
+		if !isExpired(person, now) {
+			live = append(live, person)
+		}
+	}
+	return live, nil
+}
+
+// SetPersonExpiresAt makes the person with given id provisional until expiresAt, a plain date or an RFC3339
+// timestamp. An empty expiresAt makes the person permanent again.
+func (s *SmartContract) SetPersonExpiresAt(ctx contractapi.TransactionContextInterface, id string, expiresAt string) error {
+	id = validation.Normalize(id)
+	normalized := ""
+	if expiresAt != "" {
+		var err error
+		normalized, err = parseExpiry(expiresAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	person, err := s.readPerson(ctx, id)
+	if err != nil {
+		return err
+	}
+	person.ExpiresAt = normalized
+	return putPersons(ctx, person)
+}
+
+// GetExpiredProvisionalPersons returns the provisional persons whose ExpiresAt is before the transaction timestamp,
+// the ones every listing leaves out and PurgeExpired deletes.
+func (s *SmartContract) GetExpiredProvisionalPersons(ctx contractapi.TransactionContextInterface) ([]*Person, error) {
+	persons, err := s.getAllPersons(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	expired := []*Person{}
+	for _, person := range persons {
+		if isExpired(person, now) {
+			expired = append(expired, person)
+		}
+	}
+	return expired, nil
+}
+
+// PurgeExpired deletes the persons GetExpiredProvisionalPersons returns, unlinking their spouses, and returns how many
+// were deleted. Only administrators may purge.
+func (s *SmartContract) PurgeExpired(ctx contractapi.TransactionContextInterface) (int, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return 0, err
+	}
+
+	expired, err := s.GetExpiredProvisionalPersons(ctx)
+	if err != nil {
+		return 0, err
+	}
+	purged := make(map[string]bool, len(expired))
+	for _, person := range expired {
+		purged[person.ID] = true
+	}
+
+	for _, person := range expired {
+		// a spouse purged as well must not be unlinked: the write would bring the deleted record back
+		if person.SpouseID != "" && !purged[person.SpouseID] {
+			if err := s.unlinkDeletedSpouse(ctx, person); err != nil {
+				return 0, fmt.Errorf("failed to purge %s: %v", person.ID, err)
+			}
+		}
+		if err := deletePerson(ctx, person); err != nil {
+			return 0, fmt.Errorf("failed to purge %s: %v", person.ID, err)
+		}
+	}
+	return len(expired), nil
+}
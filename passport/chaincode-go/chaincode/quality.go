@@ -0,0 +1,81 @@
+package chaincode
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
+)
+
+// personFields returns the text attributes of a person in the form the validation package checks.
+func personFields(person *Person) validation.PersonFields {
+	return validation.PersonFields{
+		ID:        person.ID,
+		Serial:    person.Serial,
+		Name:      person.Name,
+		Surname:   person.Surname,
+		City:      person.City,
+		Address:   person.Address,
+		Phone:     person.Phone,
+		Reference: person.Reference,
+		Gender:    person.Gender,
+		Birthdate: person.Birthdate,
+	}
+}
+
+// FindIncompletePersons returns the persons lacking any of validation.RequiredFields, such as records written before
+// a field became required or imported bypassing CreatePerson. It does not modify the world state.
+func (s *SmartContract) FindIncompletePersons(ctx contractapi.TransactionContextInterface) ([]*Person, error) {
+	persons, err := s.getAllPersons(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	incomplete := []*Person{}
+	for _, person := range persons {
+		if len(validation.MissingFields(personFields(person))) > 0 {
+			incomplete = append(incomplete, person)
+		}
+	}
+
+	return incomplete, nil
+}
+
+// ValidationIssue is a problem a stored person has under the current validation rules.
+type ValidationIssue struct {
+	ID      string `json:"id"`
+	Field   string `json:"field"`
+	Problem string `json:"problem"`
+}
+
+// ValidateAllPersons checks every person against the current validation rules and the allowed cities, reporting each
+// failing field of each person. Records stored before a rule was tightened keep working, this is how operators find
+// them. It does not modify the world state.
+func (s *SmartContract) ValidateAllPersons(ctx contractapi.TransactionContextInterface) ([]ValidationIssue, error) {
+	persons, err := s.getAllPersons(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cities, err := s.GetAllowedCities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	allowedCities := make(map[string]bool, len(cities))
+	for _, city := range cities {
+		allowedCities[city] = true
+	}
+
+	issues := []ValidationIssue{}
+	for _, person := range persons {
+		for _, issue := range validation.PersonIssues(personFields(person)) {
+			issues = append(issues, ValidationIssue{ID: person.ID, Field: issue.Field, Problem: issue.Problem})
+		}
+		if len(allowedCities) > 0 && person.City != "" && !allowedCities[person.City] {
+			issues = append(issues, ValidationIssue{
+				ID:      person.ID,
+				Field:   validation.FieldCity,
+				Problem: "city is not in the allowed set",
+			})
+		}
+	}
+
+	return issues, nil
+}
@@ -0,0 +1,225 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
+)
+
+// getQueryResultForQueryString runs a CouchDB rich query and returns the matching persons, but for expired provisional
+// ones. Rich queries are only supported when the peers use CouchDB as their state database.
+func getQueryResultForQueryString(ctx contractapi.TransactionContextInterface, query interface{}) ([]*Person, error) {
+	queryString, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetQueryResult(string(queryString))
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	persons, err := personsFromIterator(resultsIterator)
+	if err != nil {
+		return nil, err
+	}
+	return excludeExpired(ctx, persons)
+}
+
+// QueryMarriedInCity returns the persons living in the given city with the given marital status.
+// The selector is served by the indexMarriedCity index (META-INF/statedb/couchdb/indexes/indexMarriedCity.json),
+// which covers both fields so CouchDB does not fall back to a full scan.
+func (s *SmartContract) QueryMarriedInCity(ctx contractapi.TransactionContextInterface, city string, married bool) ([]*Person, error) {
+	query := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"city":    city,
+			"married": married,
+		},
+		"use_index": []string{"_design/indexMarriedCityDoc", "indexMarriedCity"},
+	}
+
+	return getQueryResultForQueryString(ctx, query)
+}
+
+// QueryPersonsByCity returns every person living in the given city. City is the leading field of the indexMarriedCity
+// index, which therefore serves this selector as well.
+func (s *SmartContract) QueryPersonsByCity(ctx contractapi.TransactionContextInterface, city string) ([]*Person, error) {
+	city = validation.Normalize(city)
+	if err := validation.Required(validation.FieldCity, city); err != nil {
+		return nil, err
+	}
+
+	query := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"city": city,
+		},
+		"use_index": []string{"_design/indexMarriedCityDoc", "indexMarriedCity"},
+	}
+
+	persons, err := getQueryResultForQueryString(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if persons == nil {
+		persons = []*Person{}
+	}
+	return persons, nil
+}
+
+// maxSearchResults caps the number of persons SearchPersonsByName returns.
+const maxSearchResults = 100
+
+// SearchPersonsByName returns the persons whose name or surname contains the given text, ignoring case, at most
+// maxSearchResults of them in no particular order. No index can serve a $regex selector, so CouchDB scans every
+// document: expect the search to slow down as the ledger grows, and keep it for interactive lookups.
+func (s *SmartContract) SearchPersonsByName(ctx contractapi.TransactionContextInterface, substring string) ([]*Person, error) {
+	substring = validation.Normalize(substring)
+	if substring == "" {
+		return nil, fmt.Errorf("%s: the search text must not be empty", CodeValidation)
+	}
+
+	pattern := "(?i)" + regexp.QuoteMeta(substring)
+	query := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"$or": []interface{}{
+				map[string]interface{}{"name": map[string]interface{}{"$regex": pattern}},
+				map[string]interface{}{"surname": map[string]interface{}{"$regex": pattern}},
+			},
+		},
+	}
+	queryString, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetQueryResult(string(queryString))
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	persons := []*Person{}
+	for len(persons) < maxSearchResults && resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var person Person
+		if err := json.Unmarshal(queryResponse.Value, &person); err != nil {
+			return nil, err
+		}
+		if isExpired(&person, now) {
+			continue
+		}
+		persons = append(persons, &person)
+	}
+
+	return persons, nil
+}
+
+// ReadPersonByPhone returns every person with the given phone number, which is not guaranteed to be unique. The
+// number is reduced to the digits stored by validation.NormalizePhone, so "+7 800 555-35-35" matches "78005553535".
+// Persons stored before phones were restricted to digits only match when queried with the number as stored. The
+// selector is served by the indexPhone index (META-INF/statedb/couchdb/indexes/indexPhone.json).
+func (s *SmartContract) ReadPersonByPhone(ctx contractapi.TransactionContextInterface, phone string) ([]*Person, error) {
+	phone = validation.Normalize(phone)
+	if err := validation.Phone(validation.NormalizePhone(phone)); err != nil {
+		return nil, err
+	}
+
+	query := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"phone": map[string]interface{}{
+				"$in": uniqueStrings(validation.NormalizePhone(phone), phone),
+			},
+		},
+		"use_index": []string{"_design/indexPhoneDoc", "indexPhone"},
+	}
+
+	persons, err := getQueryResultForQueryString(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if persons == nil {
+		persons = []*Person{}
+	}
+	return persons, nil
+}
+
+// GetPersonsByReference returns every person carrying the given external reference. The selector is served by the
+// indexReference index (META-INF/statedb/couchdb/indexes/indexReference.json).
+func (s *SmartContract) GetPersonsByReference(ctx contractapi.TransactionContextInterface, ref string) ([]*Person, error) {
+	ref = validation.Normalize(ref)
+	if err := validation.Required(validation.FieldReference, ref); err != nil {
+		return nil, err
+	}
+	if err := validation.Reference(ref); err != nil {
+		return nil, err
+	}
+
+	query := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"reference": ref,
+		},
+		"use_index": []string{"_design/indexReferenceDoc", "indexReference"},
+	}
+
+	persons, err := getQueryResultForQueryString(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if persons == nil {
+		persons = []*Person{}
+	}
+	return persons, nil
+}
+
+// GetPersonsByGender returns every person with the given gender, one of validation.Genders. The selector is served by
+// the indexGender index (META-INF/statedb/couchdb/indexes/indexGender.json).
+func (s *SmartContract) GetPersonsByGender(ctx contractapi.TransactionContextInterface, g string) ([]*Person, error) {
+	g = validation.Normalize(g)
+	if err := validation.Required(validation.FieldGender, g); err != nil {
+		return nil, err
+	}
+	if err := validation.Gender(g); err != nil {
+		return nil, err
+	}
+
+	query := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"gender": g,
+		},
+		"use_index": []string{"_design/indexGenderDoc", "indexGender"},
+	}
+
+	persons, err := getQueryResultForQueryString(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if persons == nil {
+		persons = []*Person{}
+	}
+	return persons, nil
+}
+
+// uniqueStrings returns the given values without repetitions, in their original order.
+func uniqueStrings(values ...string) []string {
+	unique := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,76 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
+)
+
+// redactableFields lists the JSON names of the person attributes ReadPersonRedacted can disclose.
+var redactableFields = []string{"id", "passport", "name", "surname", "city", "address", "phone", "married", "expiry", "tags", "reference", "gender", "expiresAt", "birthdate"}
+
+// defaultRedactedFields is what ReadPersonRedacted discloses when no fields are asked for: enough to tell who the
+// record is about, nothing that identifies a document, a place or a contact.
+var defaultRedactedFields = []string{"id", "name"}
+
+// ReadPersonRedacted returns the person with given id stripped of every attribute but the ones named in fieldsCSV, a
+// comma-separated list of JSON field names such as "name,city". It is meant for sharing records with parties that
+// only need some of the details. An empty list discloses the id and name only. Optional attributes the person does
+// not have are left out even when asked for.
+func (s *SmartContract) ReadPersonRedacted(ctx contractapi.TransactionContextInterface, id string, fieldsCSV string) (map[string]interface{}, error) {
+	id = validation.Normalize(id)
+	fields, err := parseRedactedFields(fieldsCSV)
+	if err != nil {
+		return nil, err
+	}
+
+	personJSON, err := getPersonJSON(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var person map[string]interface{}
+	err = json.Unmarshal(personJSON, &person)
+	if err != nil {
+		return nil, err
+	}
+
+	redacted := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if value, ok := person[field]; ok {
+			redacted[field] = value
+		}
+	}
+	return redacted, nil
+}
+
+// parseRedactedFields splits a comma-separated list of field names, rejecting names that are not redactableFields.
+func parseRedactedFields(fieldsCSV string) ([]string, error) {
+	var fields []string
+	for _, field := range strings.Split(fieldsCSV, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !isRedactableField(field) {
+			return nil, fmt.Errorf("unknown field %q, expected any of %s", field, strings.Join(redactableFields, ", "))
+		}
+		fields = append(fields, field)
+	}
+
+	if len(fields) == 0 {
+		return defaultRedactedFields, nil
+	}
+	return fields, nil
+}
+
+func isRedactableField(field string) bool {
+	for _, redactable := range redactableFields {
+		if field == redactable {
+			return true
+		}
+	}
+	return false
+}
@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
 	"time"
 )
 
@@ -17,46 +20,137 @@ type SmartContract struct {
 // Insert struct field in alphabetic order => to achieve determinism across languages
 // golang keeps the order when marshal to json but doesn't order automatically
 type Person struct {
-	ID      string `json:"id"`
-	Serial  string `json:"passport"`
-	Name    string `json:"name"`
-	Surname string `json:"surname"`
-	City    string `json:"city"`
-	Address string `json:"address"`
-	Phone   string `json:"phone"`
-	Married bool   `json:"married"`
+	ID        string   `json:"id"`
+	Serial    string   `json:"passport"`
+	Name      string   `json:"name"`
+	Surname   string   `json:"surname"`
+	City      string   `json:"city"`
+	Address   string   `json:"address"`
+	Phone     string   `json:"phone"`
+	Married   bool     `json:"married"`
+	Expiry    string   `json:"expiry,omitempty" metadata:"expiry,optional"`
+	Tags      []string `json:"tags,omitempty" metadata:"tags,optional"`
+	Reference string   `json:"reference,omitempty" metadata:"reference,optional"`
+	SpouseID  string   `json:"spouseId,omitempty" metadata:"spouseId,optional"`
+	Gender    string   `json:"gender,omitempty" metadata:"gender,optional"`
+	ExpiresAt string   `json:"expiresAt,omitempty" metadata:"expiresAt,optional"`
+	Birthdate string   `json:"birthdate,omitempty" metadata:"birthdate,optional"`
 }
 
+// Update is one entry of the history of a person. A deletion is reported with IsDelete set and no data.
 type Update struct {
 	Tx        string    `json:"tx"`
 	Timestamp time.Time `json:"timestamp"`
-	Data      Person    `json:"data"`
+	Data      *Person   `json:"data,omitempty" metadata:"data,optional"`
+	IsDelete  bool      `json:"isDelete,omitempty" metadata:"isDelete,optional"`
 }
 
-// InitLedger adds a base set of persons to the ledger
-func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
+// initializedMarker is the object type of the composite key InitLedger and ReseedLedger write once they have seeded
+// the ledger. Being a composite key it never shows up in the range scans over persons.
+const initializedMarker = "ledger~initialized"
+
+// InitResult reports what InitLedger or ReseedLedger did with each seed person.
+type InitResult struct {
+	Created     []string `json:"created"`
+	Overwritten []string `json:"overwritten"`
+	Skipped     []string `json:"skipped"`
+}
+
+// InitLedger adds a base set of persons to the ledger. Seed ids that are already taken are left alone and reported as
+// skipped, so running it again on a seeded ledger changes nothing and reports every seed id as skipped. ReseedLedger
+// writes the seed persons again instead.
+func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) (*InitResult, error) {
+	return s.seedLedger(ctx, false)
+}
+
+// ReseedLedger adds the base set of persons of InitLedger whether or not the ledger was seeded before. Seed ids that
+// are already taken are written again over their current state and reported as overwritten.
+func (s *SmartContract) ReseedLedger(ctx contractapi.TransactionContextInterface) (*InitResult, error) {
+	return s.seedLedger(ctx, true)
+}
+
+// seedLedger writes the seed persons and marks the ledger initialized. Seed ids that are already taken are
+// overwritten if overwrite is set and skipped otherwise.
+func (s *SmartContract) seedLedger(ctx contractapi.TransactionContextInterface, overwrite bool) (*InitResult, error) {
 
 	persons := []Person{
-		{"person0", "0510 228148", "Igor", "Nikolaev", "Moscow", "Likhachevsky proezd 2", "88005553535", true},
-		{"person1", "1020 123654", "Matvei", "Stepanov", "Dolgoprudny", "Universitetskaya 11", "88005553535", false},
+		{ID: "person0", Serial: "0510 228148", Name: "Igor", Surname: "Nikolaev", City: "Moscow", Address: "Likhachevsky proezd 2", Phone: "88005553535", Married: true, Birthdate: "1955-01-17"},
+		{ID: "person1", Serial: "1020 123654", Name: "Matvei", Surname: "Stepanov", City: "Dolgoprudny", Address: "Universitetskaya 11", Phone: "88005553535", Married: false, Birthdate: "1999-08-03"},
 	}
 
+	result := &InitResult{Created: []string{}, Overwritten: []string{}, Skipped: []string{}}
 	for _, person := range persons {
+		exists, err := s.PersonExists(ctx, person.ID)
+		if err != nil {
+			return nil, err
+		}
+		if exists && !overwrite {
+			result.Skipped = append(result.Skipped, person.ID)
+			continue
+		}
+		if exists {
+			// the seed person replaces one that may have changed since, so its old index entries go first
+			current, err := s.readPerson(ctx, person.ID)
+			if err != nil {
+				return nil, err
+			}
+			err = deletePersonIndexes(ctx, current)
+			if err != nil {
+				return nil, err
+			}
+			result.Overwritten = append(result.Overwritten, person.ID)
+		} else {
+			result.Created = append(result.Created, person.ID)
+			err = putCreationAudit(ctx, person.ID)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		personJSON, err := json.Marshal(person)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		err = ctx.GetStub().PutState(person.ID, personJSON)
 		if err != nil {
-			return fmt.Errorf("failed to put to world state. %v", err)
+			return nil, fmt.Errorf("failed to put to world state. %v", err)
+		}
+
+		err = putPersonIndexes(ctx, &person)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
+	markerKey, err := ctx.GetStub().CreateCompositeKey(initializedMarker, []string{})
+	if err != nil {
+		return nil, err
+	}
+	err = ctx.GetStub().PutState(markerKey, []byte{0x00})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
-// CreatePerson issues a new person to the world state with given details.
+// IsLedgerInitialized reports whether InitLedger has already seeded the ledger.
+func (s *SmartContract) IsLedgerInitialized(ctx contractapi.TransactionContextInterface) (bool, error) {
+	markerKey, err := ctx.GetStub().CreateCompositeKey(initializedMarker, []string{})
+	if err != nil {
+		return false, err
+	}
+
+	marker, err := ctx.GetStub().GetState(markerKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to read from world state: %v", err)
+	}
+
+	return marker != nil, nil
+}
+
+// CreatePerson issues a new person to the world state with given details. CreatePersonWithDetails also sets the
+// optional details, the reference, the gender and the birthdate.
 func (s *SmartContract) CreatePerson(ctx contractapi.TransactionContextInterface,
 	id string,
 	serial string,
@@ -66,16 +160,67 @@ func (s *SmartContract) CreatePerson(ctx contractapi.TransactionContextInterface
 	address string,
 	phone string,
 	married bool) error {
-
-	exists, err := s.PersonExists(ctx, id)
+	_, err := s.createPerson(ctx, Person{
+		ID:      id,
+		Serial:  serial,
+		Name:    name,
+		Surname: surname,
+		City:    city,
+		Address: address,
+		Phone:   phone,
+		Married: married,
+	})
 	if err != nil {
 		return err
 	}
-	if exists {
-		return fmt.Errorf("the person %s already exists", id)
+	return addOpCount(ctx, 1)
+}
+
+// createPerson does the work of CreatePerson but for counting the creation against the submitting identity, which
+// callers creating several persons in one transaction do once for all of them, and returns the person as stored.
+func (s *SmartContract) createPerson(ctx contractapi.TransactionContextInterface, details Person) (*Person, error) {
+	person, err := s.newPerson(ctx, details)
+	if err != nil {
+		return nil, err
 	}
 
-	person := Person{
+	personJSON, err := json.Marshal(person)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ctx.GetStub().PutState(person.ID, personJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	err = putPersonIndexes(ctx, person)
+	if err != nil {
+		return nil, err
+	}
+	err = putCreationAudit(ctx, person.ID)
+	if err != nil {
+		return nil, err
+	}
+	err = setPersonEvent(ctx, eventPersonCreated, personJSON)
+	if err != nil {
+		return nil, err
+	}
+	return person, nil
+}
+
+// EvaluateCreatePerson runs every check CreatePerson would, the existence of the id included, without writing
+// anything, so clients can validate a form by evaluating it before submitting CreatePerson.
+func (s *SmartContract) EvaluateCreatePerson(ctx contractapi.TransactionContextInterface,
+	id string,
+	serial string,
+	name string,
+	surname string,
+	city string,
+	address string,
+	phone string,
+	married bool) error {
+	_, err := s.newPerson(ctx, Person{
 		ID:      id,
 		Serial:  serial,
 		Name:    name,
@@ -84,23 +229,100 @@ func (s *SmartContract) CreatePerson(ctx contractapi.TransactionContextInterface
 		Address: address,
 		Phone:   phone,
 		Married: married,
+	})
+	return err
+}
+
+// newPerson normalizes and validates the details of a person about to be created and returns the person to store.
+// Only the fields set on creation are taken from details, the ones managed by their own transactions are left empty.
+func (s *SmartContract) newPerson(ctx contractapi.TransactionContextInterface, details Person) (*Person, error) {
+	person, err := checkPersonDetails(ctx, details)
+	if err != nil {
+		return nil, err
 	}
-	personJSON, err := json.Marshal(person)
+
+	exists, err := s.PersonExists(ctx, person.ID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("%s: the person %s already exists", CodeConflict, person.ID)
+	}
+	// an archived person keeps its id, so that RestoreArchivedPerson can bring it back
+	archived, err := isArchived(ctx, person.ID)
+	if err != nil {
+		return nil, err
+	}
+	if archived {
+		return nil, fmt.Errorf("%s: the person %s is archived, restore it instead of creating it again", CodeConflict, person.ID)
+	}
+
+	return &person, nil
+}
+
+// checkPersonDetails normalizes and validates the fields of details a client sets and returns them as a person. The
+// fields managed by their own transactions are left empty.
+func checkPersonDetails(ctx contractapi.TransactionContextInterface, details Person) (Person, error) {
+	// normalization happens first, so the existence check and the stored record both use the trimmed values
+	person := Person{
+		ID:        details.ID,
+		Serial:    details.Serial,
+		Name:      details.Name,
+		Surname:   details.Surname,
+		City:      details.City,
+		Address:   details.Address,
+		Phone:     details.Phone,
+		Married:   details.Married,
+		Reference: details.Reference,
+		Gender:    details.Gender,
+		Birthdate: details.Birthdate,
+	}
+	normalize(&person.ID, &person.Serial, &person.Name, &person.Surname, &person.City, &person.Address, &person.Phone,
+		&person.Reference, &person.Gender, &person.Birthdate)
+	err := validation.PersonAll(validation.PersonFields{
+		ID:        person.ID,
+		Serial:    person.Serial,
+		Name:      person.Name,
+		Surname:   person.Surname,
+		City:      person.City,
+		Address:   person.Address,
+		Phone:     person.Phone,
+		Reference: person.Reference,
+		Gender:    person.Gender,
+		Birthdate: person.Birthdate,
+	})
+	if err != nil {
+		return Person{}, codedError(CodeValidation, err)
+	}
+	err = checkBirthdateNotFuture(ctx, person.Birthdate)
+	if err != nil {
+		return Person{}, err
+	}
+	err = checkAllowedCity(ctx, person.City)
+	if err != nil {
+		return Person{}, err
 	}
+	return person, nil
+}
 
-	return ctx.GetStub().PutState(id, personJSON)
+// normalize trims surrounding whitespace from each of the given values in place.
+func normalize(values ...*string) {
+	for _, value := range values {
+		*value = validation.Normalize(*value)
+	}
 }
 
 // ReadPerson returns the person stored in the world state with given id.
 func (s *SmartContract) ReadPerson(ctx contractapi.TransactionContextInterface, id string) (*Person, error) {
-	personJSON, err := ctx.GetStub().GetState(id)
+	id = validation.Normalize(id)
+	return s.readPerson(ctx, id)
+}
+
+// readPerson returns the person stored in the world state with given id.
+func (s *SmartContract) readPerson(ctx contractapi.TransactionContextInterface, id string) (*Person, error) {
+	personJSON, err := getPersonJSON(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read from world state: %v", err)
-	}
-	if personJSON == nil {
-		return nil, fmt.Errorf("the person %s does not exist", id)
+		return nil, err
 	}
 
 	var person Person
@@ -112,7 +334,21 @@ func (s *SmartContract) ReadPerson(ctx contractapi.TransactionContextInterface,
 	return &person, nil
 }
 
-// UpdatePerson updates an existing person in the world state with provided parameters.
+// getPersonJSON returns the stored representation of the person with given id.
+func getPersonJSON(ctx contractapi.TransactionContextInterface, id string) ([]byte, error) {
+	personJSON, err := ctx.GetStub().GetState(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if personJSON == nil {
+		return nil, fmt.Errorf("%s: the person %s does not exist", CodeNotFound, id)
+	}
+
+	return personJSON, nil
+}
+
+// UpdatePerson updates an existing person in the world state with provided parameters. The reference, the gender and
+// the birthdate are carried over from the stored person, UpdatePersonWithDetails changes them as well.
 func (s *SmartContract) UpdatePerson(ctx contractapi.TransactionContextInterface,
 	id string,
 	serial string,
@@ -122,16 +358,7 @@ func (s *SmartContract) UpdatePerson(ctx contractapi.TransactionContextInterface
 	address string,
 	phone string,
 	married bool) error {
-	exists, err := s.PersonExists(ctx, id)
-	if err != nil {
-		return err
-	}
-	if !exists {
-		return fmt.Errorf("the person %s does not exist", id)
-	}
-
-	// overwriting original person with new person
-	person := Person{
+	_, err := s.updatePerson(ctx, Person{
 		ID:      id,
 		Serial:  serial,
 		Name:    name,
@@ -140,29 +367,125 @@ func (s *SmartContract) UpdatePerson(ctx contractapi.TransactionContextInterface
 		Address: address,
 		Phone:   phone,
 		Married: married,
+	}, carryAllOptional)
+	return err
+}
+
+// carriedOver tells which optional fields an update keeps from the stored person instead of taking them from the
+// update.
+type carriedOver struct {
+	reference bool
+	gender    bool
+	birthdate bool
+}
+
+// carryAllOptional keeps every optional field, for the updates that do not take them.
+var carryAllOptional = carriedOver{reference: true, gender: true, birthdate: true}
+
+// updatePerson replaces the stored person with the fields of details a client sets, but for the optional ones keep
+// tells to carry over, and returns the person as stored.
+func (s *SmartContract) updatePerson(ctx contractapi.TransactionContextInterface, details Person, keep carriedOver) (*Person, error) {
+	person, err := checkPersonDetails(ctx, details)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.readPerson(ctx, person.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !person.Married && current.SpouseID != "" {
+		return nil, fmt.Errorf("%s: the person %s is linked to the spouse %s, unlink them before marking the person unmarried", CodeConflict, person.ID, current.SpouseID)
+	}
+
+	// overwriting original person with new person, expiry, tags, the spouse link and the provisional record expiry are
+	// managed separately and carried over
+	person.Expiry = current.Expiry
+	person.Tags = current.Tags
+	person.SpouseID = current.SpouseID
+	person.ExpiresAt = current.ExpiresAt
+	if keep.reference {
+		person.Reference = current.Reference
+	}
+	if keep.gender {
+		person.Gender = current.Gender
+	}
+	if keep.birthdate {
+		person.Birthdate = current.Birthdate
 	}
 	personJSON, err := json.Marshal(person)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	err = ctx.GetStub().PutState(person.ID, personJSON)
+	if err != nil {
+		return nil, err
 	}
 
-	return ctx.GetStub().PutState(id, personJSON)
+	err = deletePersonIndexes(ctx, current)
+	if err != nil {
+		return nil, err
+	}
+	err = putPersonIndexes(ctx, &person)
+	if err != nil {
+		return nil, err
+	}
+	err = setPersonEvent(ctx, eventPersonUpdated, personJSON)
+	if err != nil {
+		return nil, err
+	}
+	return &person, nil
 }
 
-// DeletePerson deletes an given person from the world state.
+// DeletePerson deletes an given person from the world state. A person linked to a spouse is not deleted, so no record is
+// left pointing at a person that no longer exists: unlink them first or use DeletePersonForce.
 func (s *SmartContract) DeletePerson(ctx contractapi.TransactionContextInterface, id string) error {
-	exists, err := s.PersonExists(ctx, id)
+	return s.deletePersonByID(ctx, id, false)
+}
+
+// DeletePersonForce deletes an given person from the world state like DeletePerson, unlinking its spouse first if it
+// has one.
+func (s *SmartContract) DeletePersonForce(ctx contractapi.TransactionContextInterface, id string) error {
+	return s.deletePersonByID(ctx, id, true)
+}
+
+// deletePersonByID deletes the person with given id, unlinking its spouse first with force and refusing to delete a
+// person linked to a spouse otherwise.
+func (s *SmartContract) deletePersonByID(ctx contractapi.TransactionContextInterface, id string, force bool) error {
+	id = validation.Normalize(id)
+	person, err := s.readPerson(ctx, id)
 	if err != nil {
 		return err
 	}
-	if !exists {
-		return fmt.Errorf("the person %s does not exist", id)
+
+	if person.SpouseID != "" {
+		if !force {
+			return fmt.Errorf("%s: the person %s is linked to the spouse %s, unlink them first or delete it with DeletePersonForce", CodeConflict, id, person.SpouseID)
+		}
+		if err := s.unlinkDeletedSpouse(ctx, person); err != nil {
+			return err
+		}
+	}
+
+	if err := deletePerson(ctx, person); err != nil {
+		return err
+	}
+	return setPersonDeletedEvent(ctx, id)
+}
+
+// deletePerson removes a person and its index entries from the world state.
+func deletePerson(ctx contractapi.TransactionContextInterface, person *Person) error {
+	err := deletePersonIndexes(ctx, person)
+	if err != nil {
+		return err
 	}
-	return ctx.GetStub().DelState(id)
+	return ctx.GetStub().DelState(person.ID)
 }
 
 // PersonExists returns true when person with given ID exists in world state
 func (s *SmartContract) PersonExists(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
+	id = validation.Normalize(id)
 	personJSON, err := ctx.GetStub().GetState(id)
 	if err != nil {
 		return false, fmt.Errorf("failed to read from world state: %v", err)
@@ -171,8 +494,18 @@ func (s *SmartContract) PersonExists(ctx contractapi.TransactionContextInterface
 	return personJSON != nil, nil
 }
 
-// GetAllPersons returns all persons found in world state
+// GetAllPersons returns all persons found in world state, but for provisional persons past their ExpiresAt.
 func (s *SmartContract) GetAllPersons(ctx contractapi.TransactionContextInterface) ([]*Person, error) {
+	persons, err := s.getAllPersons(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return excludeExpired(ctx, persons)
+}
+
+// getAllPersons returns every person record, expired provisional ones included, for the maintenance transactions that
+// must see every record.
+func (s *SmartContract) getAllPersons(ctx contractapi.TransactionContextInterface) ([]*Person, error) {
 	// range query with empty string for startKey and endKey does an
 	// open-ended query of all persons in the chaincode namespace.
 	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
@@ -181,6 +514,92 @@ func (s *SmartContract) GetAllPersons(ctx contractapi.TransactionContextInterfac
 	}
 	defer resultsIterator.Close()
 
+	return personsFromIterator(resultsIterator)
+}
+
+// GetPersonsLimited returns the first persons in key order, at most limit of them, leaving out expired provisional
+// persons. Unlike the paginated listing it offers no way to continue, it is meant for a quick look at a sample of the
+// ledger.
+func (s *SmartContract) GetPersonsLimited(ctx contractapi.TransactionContextInterface, limit int) ([]*Person, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive, got %d", limit)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	persons := []*Person{}
+	for len(persons) < limit && resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var person Person
+		err = json.Unmarshal(queryResponse.Value, &person)
+		if err != nil {
+			return nil, err
+		}
+		if isExpired(&person, now) {
+			continue
+		}
+		persons = append(persons, &person)
+	}
+
+	return persons, nil
+}
+
+// PersonsPage is one page of a paginated listing of persons. Bookmark is passed back to fetch the next page and is
+// empty once the listing is exhausted.
+type PersonsPage struct {
+	Persons      []*Person `json:"persons"`
+	Bookmark     string    `json:"bookmark"`
+	FetchedCount int32     `json:"fetchedCount"`
+}
+
+// GetAllPersonsWithPagination returns at most pageSize persons in key order, starting from the given bookmark (empty for
+// the first page). Paginated queries are only allowed in evaluated transactions, never in submitted ones. Expired
+// provisional persons are left out after fetching, so a page may hold fewer persons than FetchedCount.
+func (s *SmartContract) GetAllPersonsWithPagination(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (*PersonsPage, error) {
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("page size must be positive, got %d", pageSize)
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByRangeWithPagination("", "", pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	persons, err := personsFromIterator(resultsIterator)
+	if err != nil {
+		return nil, err
+	}
+	persons, err = excludeExpired(ctx, persons)
+	if err != nil {
+		return nil, err
+	}
+	if persons == nil {
+		persons = []*Person{}
+	}
+
+	page := &PersonsPage{Persons: persons, FetchedCount: metadata.FetchedRecordsCount}
+	// a short page is the last one, whatever bookmark the state database hands back
+	if metadata.FetchedRecordsCount == pageSize {
+		page.Bookmark = metadata.Bookmark
+	}
+	return page, nil
+}
+
+// personsFromIterator drains a state query iterator, unmarshalling every value into a Person.
+func personsFromIterator(resultsIterator shim.StateQueryIteratorInterface) ([]*Person, error) {
 	var persons []*Person
 	for resultsIterator.HasNext() {
 		queryResponse, err := resultsIterator.Next()
@@ -199,7 +618,10 @@ func (s *SmartContract) GetAllPersons(ctx contractapi.TransactionContextInterfac
 	return persons, nil
 }
 
+// GetPersonHistory returns every update of the person with given id, most recent first. A long history may not fit in
+// a single response, GetRecentPersonHistory returns as many of the most recent updates as do.
 func (s *SmartContract) GetPersonHistory(ctx contractapi.TransactionContextInterface, id string) ([]Update, error) {
+	id = validation.Normalize(id)
 	exists, err := s.PersonExists(ctx, id)
 	if err != nil {
 		return nil, err
@@ -208,39 +630,59 @@ func (s *SmartContract) GetPersonHistory(ctx contractapi.TransactionContextInter
 		return nil, fmt.Errorf("the person %s does not exist", id)
 	}
 
-	resultsIterator, err := ctx.GetStub().GetHistoryForKey(id)
+	return personUpdates(ctx, id)
+}
+
+// GetPersonAtTime returns the version of the person that was current at the given RFC3339 timestamp,
+// i.e. the latest write at or before that time.
+func (s *SmartContract) GetPersonAtTime(ctx contractapi.TransactionContextInterface, id string, rfc3339 string) (*Person, error) {
+	id = validation.Normalize(id)
+	at, err := time.Parse(time.RFC3339, rfc3339)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp %q, expected RFC3339: %v", rfc3339, err)
+	}
+
+	resultsIterator, err := getHistoryForKey(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	defer resultsIterator.Close()
 
-	var updatesHistory []Update
-
+	// history order is not relied upon, the latest entry not after the requested time wins
+	var current *queryresult.KeyModification
+	var currentTimestamp time.Time
 	for resultsIterator.HasNext() {
 		response, err := resultsIterator.Next()
 		if err != nil {
 			return nil, err
 		}
 
-		var person Person
-		err = json.Unmarshal(response.Value, &person)
-		if err != nil {
-			return nil, err
-		}
-
 		timestamp, err := ptypes.Timestamp(response.Timestamp)
 		if err != nil {
 			return nil, err
 		}
-
-		update := Update{
-			Tx:        response.TxId,
-			Timestamp: timestamp,
-			Data:      person,
+		if timestamp.After(at) {
+			continue
+		}
+		if current == nil || !timestamp.Before(currentTimestamp) {
+			current = response
+			currentTimestamp = timestamp
 		}
+	}
+
+	if current == nil {
+		return nil, fmt.Errorf("the person %s did not exist at %s", id, rfc3339)
+	}
+	if current.IsDelete {
+		// the deletion is dated by its own transaction, not by the time asked for
+		return nil, fmt.Errorf("the person %s was deleted at %s, as of %s", id, currentTimestamp.Format(time.RFC3339), rfc3339)
+	}
 
-		updatesHistory = append(updatesHistory, update)
+	var person Person
+	err = json.Unmarshal(current.Value, &person)
+	if err != nil {
+		return nil, err
 	}
 
-	return updatesHistory, nil
+	return &person, nil
 }
@@ -0,0 +1,173 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Positions of the fields in the arguments of CreatePerson and UpdatePerson.
+const (
+	argID = iota
+	argSerial
+	argName
+	argSurname
+	argCity
+	argAddress
+	argPhone
+	argMarried
+)
+
+// personArgs returns the arguments of CreatePerson for a valid, unmarried person with given id.
+func personArgs(id string) []string {
+	return []string{id, "0510 228148", "Ivan", "Petrov", "Moscow", "Lenina 1", "88005553535", "false"}
+}
+
+// storedPerson returns the person with given id as it is stored in the world state.
+func storedPerson(t *testing.T, stub *testStub, id string) Person {
+	t.Helper()
+	personJSON := stub.State[id]
+	require.NotNil(t, personJSON, "person %s is not stored", id)
+
+	var person Person
+	require.NoError(t, json.Unmarshal(personJSON, &person))
+	return person
+}
+
+func TestCreatePersonStoresValidPerson(t *testing.T) {
+	stub := newTestStub(t)
+
+	stub.mustInvoke(t, "CreatePerson", personArgs("person1")...)
+
+	person := storedPerson(t, stub, "person1")
+	require.Equal(t, "Ivan", person.Name)
+	require.Equal(t, "88005553535", person.Phone)
+}
+
+func TestCreatePersonAppliesSharedValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     int
+		value   string
+		problem string
+	}{
+		{"serial format", argSerial, "12345", "serial must be four digits"},
+		{"name characters", argName, "Ivan2", "name may only contain letters"},
+		{"required field", argCity, "", "city is a required field"},
+		{"phone length", argPhone, "1234", "phone must consist of digits only"},
+		{"phone separators", argPhone, "+7 800 555-35-35", "phone must consist of digits only"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stub := newTestStub(t)
+			args := personArgs("person1")
+			args[test.arg] = test.value
+
+			message := stub.invokeError(t, "CreatePerson", args...)
+			require.Contains(t, message, CodeValidation+": ")
+			require.Contains(t, message, test.problem)
+			require.Nil(t, stub.State["person1"])
+		})
+	}
+}
+
+func TestEntryPointsNormalizeIDs(t *testing.T) {
+	stub := newTestStub(t)
+	stub.mustInvoke(t, "CreatePerson", personArgs("person1")...)
+
+	for _, id := range []string{"person1 ", "\tperson1", "person1\n", " \tperson1\r\n"} {
+		t.Run(fmt.Sprintf("%q", id), func(t *testing.T) {
+			stub.mustInvoke(t, "ReadPerson", id)
+			stub.mustInvoke(t, "ReadPersonWithETag", id)
+			stub.mustInvoke(t, "ReadPersonRedacted", id, "address")
+			stub.mustInvoke(t, "GetPersonHistory", id)
+			require.Equal(t, "true", string(stub.mustInvoke(t, "PersonExists", id)))
+
+			stub.mustInvoke(t, "AddPersonTag", id, "vip")
+			stub.mustInvoke(t, "RemovePersonTag", id, "vip")
+			stub.mustInvoke(t, "SetPassportExpiry", id, "2030-01-01")
+			stub.mustInvoke(t, "SetPersonExpiresAt", id, "")
+		})
+	}
+	require.Equal(t, "2030-01-01T00:00:00Z", storedPerson(t, stub, "person1").Expiry)
+
+	stub.mustInvoke(t, "DeletePerson", "\tperson1\n")
+	require.Nil(t, stub.State["person1"])
+}
+
+func TestInitLedgerSkipsSeedPersonsOnSeededLedger(t *testing.T) {
+	stub := newTestStub(t)
+
+	var result InitResult
+	require.NoError(t, json.Unmarshal(stub.mustInvoke(t, "InitLedger"), &result))
+	require.Equal(t, []string{"person0", "person1"}, result.Created)
+	require.Equal(t, "true", string(stub.mustInvoke(t, "IsLedgerInitialized")))
+
+	require.NoError(t, json.Unmarshal(stub.mustInvoke(t, "InitLedger"), &result))
+	require.Empty(t, result.Created)
+	require.Empty(t, result.Overwritten)
+	require.Equal(t, []string{"person0", "person1"}, result.Skipped)
+}
+
+func TestReseedLedgerOverwritesSeedPersons(t *testing.T) {
+	stub := newTestStub(t)
+	stub.mustInvoke(t, "InitLedger")
+	args := personArgs("person0")
+	args[argCity] = "Kazan"
+	stub.mustInvoke(t, "UpdatePerson", args...)
+
+	var result InitResult
+	require.NoError(t, json.Unmarshal(stub.mustInvoke(t, "ReseedLedger"), &result))
+	require.Empty(t, result.Created)
+	require.Equal(t, []string{"person0", "person1"}, result.Overwritten)
+	require.Equal(t, "Moscow", storedPerson(t, stub, "person0").City)
+}
+
+func TestCreatePersonReportsEveryValidationProblem(t *testing.T) {
+	stub := newTestStub(t)
+	args := personArgs("person1")
+	args[argName] = "Ivan2"
+	args[argCity] = ""
+	args[argPhone] = "12-34"
+
+	message := stub.invokeError(t, "CreatePerson", args...)
+	require.Contains(t, message, CodeValidation+": 3 validation errors")
+	require.Contains(t, message, "name may only contain letters")
+	require.Contains(t, message, "city is a required field")
+	require.Contains(t, message, "phone must consist of digits only")
+}
+
+func TestDeletePersonRefusesPersonLinkedToSpouse(t *testing.T) {
+	stub := newTestStub(t)
+	stub.mustInvoke(t, "CreatePerson", personArgs("person1")...)
+	stub.mustInvoke(t, "CreatePerson", personArgs("person2")...)
+	stub.mustInvoke(t, "MarryPersons", "person1", "person2")
+
+	message := stub.invokeError(t, "DeletePerson", "person1")
+	require.Contains(t, message, CodeConflict+": ")
+	require.NotNil(t, stub.State["person1"])
+	require.Equal(t, "person1", storedPerson(t, stub, "person2").SpouseID)
+}
+
+func TestDeletePersonForceUnlinksSpouse(t *testing.T) {
+	stub := newTestStub(t)
+	stub.mustInvoke(t, "CreatePerson", personArgs("person1")...)
+	stub.mustInvoke(t, "CreatePerson", personArgs("person2")...)
+	stub.mustInvoke(t, "MarryPersons", "person1", "person2")
+
+	stub.mustInvoke(t, "DeletePersonForce", " person1")
+
+	require.Nil(t, stub.State["person1"])
+	require.Empty(t, storedPerson(t, stub, "person2").SpouseID)
+}
+
+func TestDeletePersonDeletesUnlinkedPerson(t *testing.T) {
+	stub := newTestStub(t)
+	stub.mustInvoke(t, "CreatePerson", personArgs("person1")...)
+
+	stub.mustInvoke(t, "DeletePerson", "person1")
+
+	require.Nil(t, stub.State["person1"])
+}
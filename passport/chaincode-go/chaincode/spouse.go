@@ -0,0 +1,203 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
+)
+
+// Names of the chaincode events emitted when spouses are linked or unlinked.
+const (
+	eventSpouseLinked   = "SpouseLinked"
+	eventSpouseUnlinked = "SpouseUnlinked"
+)
+
+// SpouseLinkEvent is the payload of the SpouseLinked and SpouseUnlinked events.
+type SpouseLinkEvent struct {
+	ID1 string `json:"id1"`
+	ID2 string `json:"id2"`
+}
+
+// CreateSpouseLink records two existing persons as each other's spouse. Both must be married and neither may already
+// be linked to anyone.
+func (s *SmartContract) CreateSpouseLink(ctx contractapi.TransactionContextInterface, id1 string, id2 string) error {
+	id1 = validation.Normalize(id1)
+	id2 = validation.Normalize(id2)
+	if id1 == id2 {
+		return fmt.Errorf("the person %s cannot be linked to themselves", id1)
+	}
+
+	person1, err := s.readPerson(ctx, id1)
+	if err != nil {
+		return err
+	}
+	person2, err := s.readPerson(ctx, id2)
+	if err != nil {
+		return err
+	}
+
+	for _, person := range []*Person{person1, person2} {
+		if !person.Married {
+			return fmt.Errorf("the person %s is not married", person.ID)
+		}
+		if person.SpouseID != "" {
+			return fmt.Errorf("the person %s is already linked to %s", person.ID, person.SpouseID)
+		}
+	}
+
+	person1.SpouseID = id2
+	person2.SpouseID = id1
+	if err := putPersons(ctx, person1, person2); err != nil {
+		return err
+	}
+
+	return setSpouseEvent(ctx, eventSpouseLinked, id1, id2)
+}
+
+// UnlinkSpouse removes the link between the person with given id and their spouse, on both records.
+func (s *SmartContract) UnlinkSpouse(ctx contractapi.TransactionContextInterface, id string) error {
+	id = validation.Normalize(id)
+	person, err := s.readPerson(ctx, id)
+	if err != nil {
+		return err
+	}
+	if person.SpouseID == "" {
+		return fmt.Errorf("the person %s is not linked to a spouse", person.ID)
+	}
+
+	persons := []*Person{person}
+	spouseID := person.SpouseID
+	exists, err := s.PersonExists(ctx, spouseID)
+	if err != nil {
+		return err
+	}
+	// the spouse may have been deleted, or linked to someone else by editing the records by hand, in which case only
+	// this side of the link is removed
+	if exists {
+		spouse, err := s.readPerson(ctx, spouseID)
+		if err != nil {
+			return err
+		}
+		if spouse.SpouseID == person.ID {
+			persons = append(persons, spouse)
+		}
+	}
+
+	for _, linked := range persons {
+		linked.SpouseID = ""
+	}
+	if err := putPersons(ctx, persons...); err != nil {
+		return err
+	}
+
+	return setSpouseEvent(ctx, eventSpouseUnlinked, person.ID, spouseID)
+}
+
+// MarryPersons marks two existing persons as married to each other, setting Married and linking them as spouses on
+// both records at once. Unlike CreateSpouseLink they need not be married yet, but neither may already be linked to
+// someone else. Marrying a couple linked to each other already changes nothing.
+func (s *SmartContract) MarryPersons(ctx contractapi.TransactionContextInterface, idA string, idB string) error {
+	idA = validation.Normalize(idA)
+	idB = validation.Normalize(idB)
+	if idA == idB {
+		return fmt.Errorf("%s: the person %s cannot marry themselves", CodeValidation, idA)
+	}
+
+	personA, err := s.readPerson(ctx, idA)
+	if err != nil {
+		return err
+	}
+	personB, err := s.readPerson(ctx, idB)
+	if err != nil {
+		return err
+	}
+	if personA.SpouseID == idB && personB.SpouseID == idA && personA.Married && personB.Married {
+		return nil
+	}
+
+	for _, person := range []*Person{personA, personB} {
+		if person.SpouseID != "" && person.SpouseID != idA && person.SpouseID != idB {
+			return fmt.Errorf("%s: the person %s is already married to %s", CodeConflict, person.ID, person.SpouseID)
+		}
+	}
+
+	personA.Married, personA.SpouseID = true, idB
+	personB.Married, personB.SpouseID = true, idA
+	if err := putPersons(ctx, personA, personB); err != nil {
+		return err
+	}
+
+	return setSpouseEvent(ctx, eventSpouseLinked, idA, idB)
+}
+
+// DivorcePersons undoes MarryPersons: it clears the spouse link on both records and marks both persons unmarried. The
+// two persons must be linked to each other.
+func (s *SmartContract) DivorcePersons(ctx contractapi.TransactionContextInterface, idA string, idB string) error {
+	idA = validation.Normalize(idA)
+	idB = validation.Normalize(idB)
+
+	personA, err := s.readPerson(ctx, idA)
+	if err != nil {
+		return err
+	}
+	personB, err := s.readPerson(ctx, idB)
+	if err != nil {
+		return err
+	}
+	if personA.SpouseID != idB || personB.SpouseID != idA {
+		return fmt.Errorf("%s: the persons %s and %s are not married to each other", CodeConflict, idA, idB)
+	}
+
+	for _, person := range []*Person{personA, personB} {
+		person.Married = false
+		person.SpouseID = ""
+	}
+	if err := putPersons(ctx, personA, personB); err != nil {
+		return err
+	}
+
+	return setSpouseEvent(ctx, eventSpouseUnlinked, idA, idB)
+}
+
+// unlinkDeletedSpouse removes the link to a person about to be deleted from the record of their spouse, if the spouse
+// still exists and is linked back.
+func (s *SmartContract) unlinkDeletedSpouse(ctx contractapi.TransactionContextInterface, person *Person) error {
+	exists, err := s.PersonExists(ctx, person.SpouseID)
+	if err != nil || !exists {
+		return err
+	}
+	spouse, err := s.readPerson(ctx, person.SpouseID)
+	if err != nil {
+		return err
+	}
+	if spouse.SpouseID != person.ID {
+		return nil
+	}
+
+	spouse.SpouseID = ""
+	return putPersons(ctx, spouse)
+}
+
+// putPersons writes the given persons to the world state. Their indexed attributes must be unchanged.
+func putPersons(ctx contractapi.TransactionContextInterface, persons ...*Person) error {
+	for _, person := range persons {
+		personJSON, err := json.Marshal(person)
+		if err != nil {
+			return err
+		}
+		if err := ctx.GetStub().PutState(person.ID, personJSON); err != nil {
+			return fmt.Errorf("failed to put to world state. %v", err)
+		}
+	}
+	return nil
+}
+
+func setSpouseEvent(ctx contractapi.TransactionContextInterface, name string, id1 string, id2 string) error {
+	payload, err := json.Marshal(SpouseLinkEvent{ID1: id1, ID2: id2})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().SetEvent(name, payload)
+}
@@ -0,0 +1,60 @@
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ComputeStateDigest returns a hex digest of every person in the world state, so that organizations can compare the
+// state their peers hold without exchanging it. Equal digests mean equal persons.
+//
+// The digest has to come out the same on every peer holding the same state, which is why it is a hash chain over the
+// persons in key order, the order range queries return on any peer and state database, and over their canonical JSON:
+// each record is decoded and encoded again, so the digest depends on the person only, not on how its JSON was
+// written. Each link hashes the previous digest, then the length-prefixed key and JSON of the next person. Expired
+// provisional persons are included, they are part of the state.
+func (s *SmartContract) ComputeStateDigest(ctx contractapi.TransactionContextInterface) (string, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return "", err
+	}
+	defer resultsIterator.Close()
+
+	digest := make([]byte, sha256.Size)
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return "", err
+		}
+
+		var person Person
+		if err := json.Unmarshal(queryResponse.Value, &person); err != nil {
+			return "", err
+		}
+		canonical, err := json.Marshal(person)
+		if err != nil {
+			return "", err
+		}
+
+		hash := sha256.New()
+		hash.Write(digest)
+		writeLengthPrefixed(hash, []byte(queryResponse.Key))
+		writeLengthPrefixed(hash, canonical)
+		digest = hash.Sum(nil)
+	}
+
+	return hex.EncodeToString(digest), nil
+}
+
+// writeLengthPrefixed writes value preceded by its length, so that no two key and value pairs hash alike.
+func writeLengthPrefixed(h hash.Hash, value []byte) {
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(value)))
+	h.Write(length[:])
+	h.Write(value)
+}
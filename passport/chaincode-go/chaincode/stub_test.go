@@ -0,0 +1,230 @@
+package chaincode
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/stretchr/testify/require"
+)
+
+// testStub is a MockStub that also keeps the history of every key, dates transactions by a clock the test controls and
+// records the event of each transaction. The contract relies on all three, which MockStub lacks.
+type testStub struct {
+	*shimtest.MockStub
+	chaincode *contractapi.ContractChaincode
+	args      [][]byte
+	txs       int
+
+	// now is the timestamp of the next transaction. Every transaction moves it a minute forward.
+	now     time.Time
+	history map[string][]*queryresult.KeyModification
+	// historyErr, when set, is returned by GetHistoryForKey instead of the history.
+	historyErr error
+	// event is the event of the last transaction, nil when it set none.
+	event *peer.ChaincodeEvent
+}
+
+func newTestStub(t *testing.T) *testStub {
+	t.Helper()
+	chaincode, err := contractapi.NewChaincode(&SmartContract{})
+	require.NoError(t, err)
+
+	stub := &testStub{
+		MockStub:  shimtest.NewMockStub("passport", chaincode),
+		chaincode: chaincode,
+		now:       time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC),
+		history:   map[string][]*queryresult.KeyModification{},
+	}
+	stub.setCreator(t, "Org1MSP", "client")
+	return stub
+}
+
+// setCreator makes the following transactions submitted by a client of mspID with a certificate in the organizational
+// unit ou. The "admin" unit passes requireAdmin.
+func (stub *testStub) setCreator(t *testing.T, mspID string, ou string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:       big.NewInt(1),
+		Subject:            pkix.Name{CommonName: "user-" + ou, OrganizationalUnit: []string{ou}},
+		NotBefore:          time.Now().Add(-time.Hour),
+		NotAfter:           time.Now().Add(time.Hour),
+		SignatureAlgorithm: x509.ECDSAWithSHA256,
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	creator, err := proto.Marshal(&msp.SerializedIdentity{
+		Mspid:   mspID,
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate}),
+	})
+	require.NoError(t, err)
+	stub.Creator = creator
+}
+
+// invoke runs a transaction of the contract.
+func (stub *testStub) invoke(function string, args ...string) peer.Response {
+	stub.txs++
+	txID := fmt.Sprintf("tx%d", stub.txs)
+
+	stub.args = [][]byte{[]byte(function)}
+	for _, arg := range args {
+		stub.args = append(stub.args, []byte(arg))
+	}
+	stub.event = nil
+	stub.MockTransactionStart(txID)
+	stub.TxTimestamp, _ = ptypes.TimestampProto(stub.now)
+	response := stub.chaincode.Invoke(stub)
+	stub.MockTransactionEnd(txID)
+	stub.now = stub.now.Add(time.Minute)
+	return response
+}
+
+// mustInvoke runs a transaction that has to succeed and returns its payload.
+func (stub *testStub) mustInvoke(t *testing.T, function string, args ...string) []byte {
+	t.Helper()
+	response := stub.invoke(function, args...)
+	require.Equal(t, int32(shim.OK), response.Status, "%s failed: %s", function, response.Message)
+	return response.Payload
+}
+
+// invokeError runs a transaction that has to fail and returns its error message.
+func (stub *testStub) invokeError(t *testing.T, function string, args ...string) string {
+	t.Helper()
+	response := stub.invoke(function, args...)
+	require.NotEqual(t, int32(shim.OK), response.Status, "%s succeeded with %s", function, response.Payload)
+	return response.Message
+}
+
+func (stub *testStub) GetArgs() [][]byte {
+	return stub.args
+}
+
+func (stub *testStub) GetStringArgs() []string {
+	args := make([]string, len(stub.args))
+	for i, arg := range stub.args {
+		args[i] = string(arg)
+	}
+	return args
+}
+
+func (stub *testStub) GetFunctionAndParameters() (string, []string) {
+	args := stub.GetStringArgs()
+	if len(args) == 0 {
+		return "", []string{}
+	}
+	return args[0], args[1:]
+}
+
+func (stub *testStub) PutState(key string, value []byte) error {
+	if err := stub.MockStub.PutState(key, value); err != nil {
+		return err
+	}
+	stub.record(key, value, false)
+	return nil
+}
+
+func (stub *testStub) DelState(key string) error {
+	if err := stub.MockStub.DelState(key); err != nil {
+		return err
+	}
+	stub.record(key, nil, true)
+	return nil
+}
+
+func (stub *testStub) record(key string, value []byte, isDelete bool) {
+	timestamp, _ := ptypes.TimestampProto(stub.now)
+	stub.history[key] = append(stub.history[key], &queryresult.KeyModification{
+		TxId:      stub.TxID,
+		Value:     value,
+		Timestamp: timestamp,
+		IsDelete:  isDelete,
+	})
+}
+
+// GetHistoryForKey returns the modifications of key most recent first, as peers do.
+func (stub *testStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	if stub.historyErr != nil {
+		return nil, stub.historyErr
+	}
+	modifications := stub.history[key]
+	reversed := make([]*queryresult.KeyModification, len(modifications))
+	for i, modification := range modifications {
+		reversed[len(modifications)-1-i] = modification
+	}
+	return &historyIterator{modifications: reversed}, nil
+}
+
+// GetStateByRange returns the simple keys from startKey to endKey, leaving composite keys out as peers do. Unlike
+// MockStub it treats an empty endKey as unbounded.
+func (stub *testStub) GetStateByRange(startKey string, endKey string) (shim.StateQueryIteratorInterface, error) {
+	var kvs []*queryresult.KV
+	for element := stub.Keys.Front(); element != nil; element = element.Next() {
+		key := element.Value.(string)
+		if strings.HasPrefix(key, "\x00") || key < startKey || (endKey != "" && key >= endKey) {
+			continue
+		}
+		kvs = append(kvs, &queryresult.KV{Key: key, Value: stub.State[key]})
+	}
+	return &stateIterator{kvs: kvs}, nil
+}
+
+// SetEvent keeps the event of the transaction. As on a peer, a later call replaces an earlier one.
+func (stub *testStub) SetEvent(name string, payload []byte) error {
+	stub.event = &peer.ChaincodeEvent{EventName: name, Payload: payload}
+	return nil
+}
+
+type stateIterator struct {
+	kvs []*queryresult.KV
+}
+
+func (iterator *stateIterator) HasNext() bool {
+	return len(iterator.kvs) > 0
+}
+
+func (iterator *stateIterator) Next() (*queryresult.KV, error) {
+	kv := iterator.kvs[0]
+	iterator.kvs = iterator.kvs[1:]
+	return kv, nil
+}
+
+func (iterator *stateIterator) Close() error {
+	return nil
+}
+
+type historyIterator struct {
+	modifications []*queryresult.KeyModification
+}
+
+func (iterator *historyIterator) HasNext() bool {
+	return len(iterator.modifications) > 0
+}
+
+func (iterator *historyIterator) Next() (*queryresult.KeyModification, error) {
+	modification := iterator.modifications[0]
+	iterator.modifications = iterator.modifications[1:]
+	return modification, nil
+}
+
+func (iterator *historyIterator) Close() error {
+	return nil
+}
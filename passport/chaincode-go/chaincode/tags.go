@@ -0,0 +1,117 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-samples/passport/chaincode-go/validation"
+)
+
+const (
+	maxTagLength     = 32
+	maxTagsPerPerson = 16
+)
+
+// normalizeTag lowercases a tag, so that "VIP" and "vip" are the same label, and checks that it is short and without
+// whitespace.
+func normalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(tag)
+	if len(tag) == 0 {
+		return "", fmt.Errorf("tag must not be empty")
+	}
+	if len(tag) > maxTagLength {
+		return "", fmt.Errorf("tag %q is longer than %d characters", tag, maxTagLength)
+	}
+	for _, r := range tag {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return "", fmt.Errorf("tag %q must not contain whitespace", tag)
+		}
+	}
+	return tag, nil
+}
+
+// AddPersonTag attaches a label to the person with given id. Tags are stored lowercase, and adding a tag the person
+// already carries is a no-op.
+func (s *SmartContract) AddPersonTag(ctx contractapi.TransactionContextInterface, id string, tag string) error {
+	id = validation.Normalize(id)
+	tag, err := normalizeTag(tag)
+	if err != nil {
+		return err
+	}
+
+	person, err := s.readPerson(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range person.Tags {
+		if existing == tag {
+			return nil
+		}
+	}
+	if len(person.Tags) >= maxTagsPerPerson {
+		return fmt.Errorf("the person %s already has the maximum of %d tags", id, maxTagsPerPerson)
+	}
+	person.Tags = append(person.Tags, tag)
+
+	personJSON, err := json.Marshal(person)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(id, personJSON)
+}
+
+// RemovePersonTag detaches a label from the person with given id.
+func (s *SmartContract) RemovePersonTag(ctx contractapi.TransactionContextInterface, id string, tag string) error {
+	id = validation.Normalize(id)
+	tag, err := normalizeTag(tag)
+	if err != nil {
+		return err
+	}
+
+	person, err := s.readPerson(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	tags := make([]string, 0, len(person.Tags))
+	for _, existing := range person.Tags {
+		if existing != tag {
+			tags = append(tags, existing)
+		}
+	}
+	if len(tags) == len(person.Tags) {
+		return fmt.Errorf("the person %s has no tag %q", id, tag)
+	}
+	person.Tags = tags
+
+	personJSON, err := json.Marshal(person)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(id, personJSON)
+}
+
+// GetPersonsByTag returns all persons carrying the given tag. It relies on a CouchDB rich query
+// with an array selector and is therefore unavailable on LevelDB.
+func (s *SmartContract) GetPersonsByTag(ctx contractapi.TransactionContextInterface, tag string) ([]*Person, error) {
+	tag, err := normalizeTag(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	query := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"tags": map[string]interface{}{
+				"$elemMatch": map[string]interface{}{"$eq": tag},
+			},
+		},
+	}
+
+	return getQueryResultForQueryString(ctx, query)
+}
@@ -0,0 +1,7 @@
+// Package personpb holds the protobuf form of a person, shared by the chaincode and its clients.
+//
+// person.pb.go is generated from person.proto by protoc-gen-go v1.3.2, the release matching the golang/protobuf
+// runtime this module requires. Clients in other languages generate their code from person.proto.
+package personpb
+
+//go:generate protoc --go_out=paths=source_relative:. person.proto
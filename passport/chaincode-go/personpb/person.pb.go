@@ -0,0 +1,209 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: person.proto
+
+package personpb
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// Person is the binary form of a person returned by the ReadPersonProto transaction.
+type Person struct {
+	Id      string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Serial  string `protobuf:"bytes,2,opt,name=serial,proto3" json:"serial,omitempty"`
+	Name    string `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Surname string `protobuf:"bytes,4,opt,name=surname,proto3" json:"surname,omitempty"`
+	City    string `protobuf:"bytes,5,opt,name=city,proto3" json:"city,omitempty"`
+	Address string `protobuf:"bytes,6,opt,name=address,proto3" json:"address,omitempty"`
+	Phone   string `protobuf:"bytes,7,opt,name=phone,proto3" json:"phone,omitempty"`
+	Married bool   `protobuf:"varint,8,opt,name=married,proto3" json:"married,omitempty"`
+	// RFC3339 timestamp in UTC, empty when no expiry is recorded.
+	Expiry string   `protobuf:"bytes,9,opt,name=expiry,proto3" json:"expiry,omitempty"`
+	Tags   []string `protobuf:"bytes,10,rep,name=tags,proto3" json:"tags,omitempty"`
+	// External reference such as a case number, empty when none is recorded.
+	Reference string `protobuf:"bytes,11,opt,name=reference,proto3" json:"reference,omitempty"`
+	// Id of the person linked as spouse by CreateSpouseLink, empty when none is.
+	SpouseId string `protobuf:"bytes,12,opt,name=spouse_id,json=spouseId,proto3" json:"spouse_id,omitempty"`
+	// M, F or X, empty when not recorded.
+	Gender string `protobuf:"bytes,13,opt,name=gender,proto3" json:"gender,omitempty"`
+	// RFC3339 timestamp in UTC after which a provisional person is left out of listings, empty for a permanent one.
+	ExpiresAt string `protobuf:"bytes,14,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// Date of birth in the form 2006-01-02, empty when not recorded.
+	Birthdate            string   `protobuf:"bytes,15,opt,name=birthdate,proto3" json:"birthdate,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Person) Reset()         { *m = Person{} }
+func (m *Person) String() string { return proto.CompactTextString(m) }
+func (*Person) ProtoMessage()    {}
+func (*Person) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4c9e10cf24b1156d, []int{0}
+}
+
+func (m *Person) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Person.Unmarshal(m, b)
+}
+func (m *Person) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Person.Marshal(b, m, deterministic)
+}
+func (m *Person) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Person.Merge(m, src)
+}
+func (m *Person) XXX_Size() int {
+	return xxx_messageInfo_Person.Size(m)
+}
+func (m *Person) XXX_DiscardUnknown() {
+	xxx_messageInfo_Person.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Person proto.InternalMessageInfo
+
+func (m *Person) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Person) GetSerial() string {
+	if m != nil {
+		return m.Serial
+	}
+	return ""
+}
+
+func (m *Person) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Person) GetSurname() string {
+	if m != nil {
+		return m.Surname
+	}
+	return ""
+}
+
+func (m *Person) GetCity() string {
+	if m != nil {
+		return m.City
+	}
+	return ""
+}
+
+func (m *Person) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *Person) GetPhone() string {
+	if m != nil {
+		return m.Phone
+	}
+	return ""
+}
+
+func (m *Person) GetMarried() bool {
+	if m != nil {
+		return m.Married
+	}
+	return false
+}
+
+func (m *Person) GetExpiry() string {
+	if m != nil {
+		return m.Expiry
+	}
+	return ""
+}
+
+func (m *Person) GetTags() []string {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+func (m *Person) GetReference() string {
+	if m != nil {
+		return m.Reference
+	}
+	return ""
+}
+
+func (m *Person) GetSpouseId() string {
+	if m != nil {
+		return m.SpouseId
+	}
+	return ""
+}
+
+func (m *Person) GetGender() string {
+	if m != nil {
+		return m.Gender
+	}
+	return ""
+}
+
+func (m *Person) GetExpiresAt() string {
+	if m != nil {
+		return m.ExpiresAt
+	}
+	return ""
+}
+
+func (m *Person) GetBirthdate() string {
+	if m != nil {
+		return m.Birthdate
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*Person)(nil), "personpb.Person")
+}
+
+func init() { proto.RegisterFile("person.proto", fileDescriptor_4c9e10cf24b1156d) }
+
+var fileDescriptor_4c9e10cf24b1156d = []byte{
+	// 310 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x44, 0x91, 0xc1, 0x4a, 0x03, 0x31,
+	0x10, 0x86, 0xe9, 0xb6, 0xdd, 0xee, 0xc6, 0x5a, 0x21, 0x88, 0x0c, 0xa8, 0x50, 0x3c, 0xf5, 0xd2,
+	0xee, 0xc1, 0x27, 0x50, 0x44, 0xf0, 0x26, 0x3d, 0x7a, 0x29, 0xd9, 0xcd, 0x74, 0x37, 0xd0, 0xdd,
+	0x84, 0x99, 0x14, 0xec, 0x9b, 0x7b, 0x94, 0x24, 0xad, 0xde, 0xe6, 0xfb, 0xf2, 0x93, 0x7f, 0x60,
+	0xc4, 0xdc, 0x21, 0xb1, 0x1d, 0x36, 0x8e, 0xac, 0xb7, 0xb2, 0x48, 0xe4, 0xea, 0xa7, 0x9f, 0x4c,
+	0xe4, 0x9f, 0x11, 0xe4, 0x42, 0x64, 0x46, 0xc3, 0x68, 0x39, 0x5a, 0x95, 0xdb, 0xcc, 0x68, 0x79,
+	0x27, 0x72, 0x46, 0x32, 0xea, 0x00, 0x59, 0x74, 0x67, 0x92, 0x52, 0x4c, 0x06, 0xd5, 0x23, 0x8c,
+	0xa3, 0x8d, 0xb3, 0x04, 0x31, 0xe3, 0x23, 0x45, 0x3d, 0x89, 0xfa, 0x82, 0x21, 0xdd, 0x18, 0x7f,
+	0x82, 0x69, 0x4a, 0x87, 0x39, 0xa4, 0x95, 0xd6, 0x84, 0xcc, 0x90, 0xa7, 0xf4, 0x19, 0xe5, 0xad,
+	0x98, 0xba, 0xce, 0x0e, 0x08, 0xb3, 0xe8, 0x13, 0x84, 0x7c, 0xaf, 0x88, 0x0c, 0x6a, 0x28, 0x96,
+	0xa3, 0x55, 0xb1, 0xbd, 0x60, 0xd8, 0x11, 0xbf, 0x9d, 0xa1, 0x13, 0x94, 0x69, 0xc7, 0x44, 0xa1,
+	0xd5, 0xab, 0x96, 0x41, 0x2c, 0xc7, 0xa1, 0x35, 0xcc, 0xf2, 0x41, 0x94, 0x84, 0x7b, 0x24, 0x1c,
+	0x1a, 0x84, 0xab, 0x18, 0xff, 0x17, 0xf2, 0x5e, 0x94, 0xec, 0xec, 0x91, 0x71, 0x67, 0x34, 0xcc,
+	0xe3, 0x6b, 0x91, 0xc4, 0x47, 0xac, 0x69, 0x71, 0xd0, 0x48, 0x70, 0x9d, 0x6a, 0x12, 0xc9, 0x47,
+	0x21, 0x62, 0x21, 0xf2, 0x4e, 0x79, 0x58, 0xa4, 0x3f, 0xcf, 0xe6, 0xc5, 0x87, 0xc6, 0xda, 0x90,
+	0xef, 0xb4, 0xf2, 0x08, 0x37, 0xe9, 0xf5, 0x4f, 0xbc, 0xbe, 0x7f, 0xbd, 0xb5, 0xc6, 0x77, 0xc7,
+	0x7a, 0xd3, 0xd8, 0xbe, 0xea, 0x4e, 0x0e, 0xe9, 0x80, 0xba, 0x45, 0xaa, 0xf6, 0xaa, 0x26, 0xd3,
+	0xac, 0x59, 0xf5, 0xee, 0x80, 0x5c, 0x39, 0xc5, 0xec, 0x2c, 0xf9, 0xaa, 0xe9, 0x94, 0x19, 0x1a,
+	0xab, 0x71, 0xdd, 0xda, 0xea, 0x72, 0xc2, 0x3a, 0x8f, 0x37, 0x7d, 0xfe, 0x1d, 0x00, 0x8f, 0x86,
+	0xeb, 0x12, 0xe3, 0x01, 0x00, 0x00,
+}
@@ -0,0 +1,326 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package validation holds the person input rules shared by the chaincode and the client application,
+// so that the prompts reject exactly what the contract would reject.
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxLength is the maximum number of characters accepted in any text field.
+const MaxLength = 128
+
+// MaxReferenceLength is the maximum number of characters of an external reference, such as a case number.
+const MaxReferenceLength = 64
+
+// Field names used in validation errors and accepted by Field.
+const (
+	FieldID        = "id"
+	FieldSerial    = "serial"
+	FieldName      = "name"
+	FieldSurname   = "surname"
+	FieldCity      = "city"
+	FieldAddress   = "address"
+	FieldPhone     = "phone"
+	FieldReference = "reference"
+	FieldGender    = "gender"
+	FieldBirthdate = "birthdate"
+)
+
+// BirthdateLayout is the format of birthdates, the RFC 3339 full-date.
+const BirthdateLayout = "2006-01-02"
+
+// Genders lists the values accepted in the optional gender field: male, female and unspecified, as printed in
+// machine-readable passports.
+var Genders = []string{"M", "F", "X"}
+
+const (
+	minPhoneDigits = 7
+	maxPhoneDigits = 15
+)
+
+// PersonFields carries the user-supplied text attributes of a person.
+type PersonFields struct {
+	ID        string
+	Serial    string
+	Name      string
+	Surname   string
+	City      string
+	Address   string
+	Phone     string
+	Reference string
+	Gender    string
+	Birthdate string
+}
+
+// RequiredFields lists the fields every stored person must have, in display order. The id is left out since no record
+// can be stored without one.
+var RequiredFields = []string{FieldSerial, FieldName, FieldSurname, FieldCity, FieldAddress, FieldPhone}
+
+// MissingFields returns the required fields of a person that are empty or blank, in the order of RequiredFields.
+func MissingFields(fields PersonFields) []string {
+	values := map[string]string{
+		FieldSerial:  fields.Serial,
+		FieldName:    fields.Name,
+		FieldSurname: fields.Surname,
+		FieldCity:    fields.City,
+		FieldAddress: fields.Address,
+		FieldPhone:   fields.Phone,
+	}
+
+	var missing []string
+	for _, field := range RequiredFields {
+		if Normalize(values[field]) == "" {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
+
+// Person validates every field of a person, returning the first problem found.
+func Person(fields PersonFields) error {
+	for _, check := range personChecks(fields) {
+		if err := Field(check.field, check.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PersonAll validates every field of a person and reports all problems found at once as Errors, so they can be
+// fixed in a single pass. It returns nil when the person is valid.
+func PersonAll(fields PersonFields) error {
+	var errs Errors
+	for _, check := range personChecks(fields) {
+		if err := Field(check.field, check.value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Issue is a validation problem found in a single field.
+type Issue struct {
+	Field   string
+	Problem string
+}
+
+// PersonIssues validates every field of a person and returns an Issue for each failing field, in field order. Unlike
+// PersonAll it keeps the field apart from the problem, for reports that group records by what is wrong with them.
+func PersonIssues(fields PersonFields) []Issue {
+	var issues []Issue
+	for _, check := range personChecks(fields) {
+		if err := Field(check.field, check.value); err != nil {
+			issues = append(issues, Issue{Field: check.field, Problem: err.Error()})
+		}
+	}
+	return issues
+}
+
+type fieldCheck struct {
+	field string
+	value string
+}
+
+func personChecks(fields PersonFields) []fieldCheck {
+	return []fieldCheck{
+		{FieldID, fields.ID},
+		{FieldSerial, fields.Serial},
+		{FieldName, fields.Name},
+		{FieldSurname, fields.Surname},
+		{FieldCity, fields.City},
+		{FieldAddress, fields.Address},
+		{FieldPhone, fields.Phone},
+		{FieldReference, fields.Reference},
+		{FieldGender, fields.Gender},
+		{FieldBirthdate, fields.Birthdate},
+	}
+}
+
+// Errors is a list of validation problems reported together.
+type Errors []error
+
+func (errs Errors) Error() string {
+	if len(errs) == 1 {
+		return errs[0].Error()
+	}
+
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d validation errors: %s", len(errs), strings.Join(messages, "; "))
+}
+
+// Field validates a single named field with the rules that apply to it.
+func Field(field string, value string) error {
+	switch field {
+	case FieldName, FieldSurname:
+		return Name(field, value)
+	case FieldSerial:
+		return Serial(value)
+	case FieldPhone:
+		return Phone(value)
+	case FieldReference:
+		return Reference(value)
+	case FieldGender:
+		return Gender(value)
+	case FieldBirthdate:
+		return Birthdate(value)
+	default:
+		return Required(field, value)
+	}
+}
+
+// Normalize trims surrounding whitespace from a field value. Values are normalized before they are validated,
+// checked for uniqueness or stored, so " person1" and "person1" refer to the same person.
+func Normalize(value string) string {
+	return strings.TrimSpace(value)
+}
+
+// Required checks that a field is present, not longer than MaxLength and free of control characters.
+func Required(field string, value string) error {
+	if len(value) == 0 {
+		return fmt.Errorf("%s is a required field", field)
+	}
+	if utf8.RuneCountInString(value) > MaxLength {
+		return fmt.Errorf("%s must not be longer than %d characters", field, MaxLength)
+	}
+	for _, r := range value {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("%s must not contain control characters such as tabs or newlines", field)
+		}
+	}
+	return nil
+}
+
+// Name checks a personal name: letters, spaces, hyphens and apostrophes only.
+func Name(field string, value string) error {
+	if err := Required(field, value); err != nil {
+		return err
+	}
+	for _, r := range value {
+		if !unicode.IsLetter(r) && r != ' ' && r != '-' && r != '\'' {
+			return fmt.Errorf("%s may only contain letters, spaces, hyphens and apostrophes", field)
+		}
+	}
+	return nil
+}
+
+// Serial checks a passport serial: a four digit series, a space and a six digit number, such as "0510 228148".
+func Serial(value string) error {
+	if err := Required(FieldSerial, value); err != nil {
+		return err
+	}
+	if len(value) != 11 || value[4] != ' ' || !isDigits(value[:4]) || !isDigits(value[5:]) {
+		return fmt.Errorf("%s must be four digits, a space and six digits, such as 0510 228148, got %q", FieldSerial, value)
+	}
+	return nil
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Phone checks a phone number: between 7 and 15 digits, without a leading '+' or any grouping by spaces, dashes or
+// parentheses.
+func Phone(value string) error {
+	if err := Required(FieldPhone, value); err != nil {
+		return err
+	}
+
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return phoneFormatError(value)
+		}
+	}
+	if len(value) < minPhoneDigits || len(value) > maxPhoneDigits {
+		return phoneFormatError(value)
+	}
+	return nil
+}
+
+// phoneFormatError describes the phone format Phone accepts.
+func phoneFormatError(value string) error {
+	return fmt.Errorf("%s must consist of digits only, between %d and %d of them, such as 88005553535, got %q", FieldPhone, minPhoneDigits, maxPhoneDigits, value)
+}
+
+// Reference checks an optional external reference: empty, or at most MaxReferenceLength characters without control
+// characters.
+func Reference(value string) error {
+	if len(value) == 0 {
+		return nil
+	}
+	if utf8.RuneCountInString(value) > MaxReferenceLength {
+		return fmt.Errorf("%s must not be longer than %d characters", FieldReference, MaxReferenceLength)
+	}
+	for _, r := range value {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("%s must not contain control characters such as tabs or newlines", FieldReference)
+		}
+	}
+	return nil
+}
+
+// Gender checks an optional gender: empty, or one of Genders.
+func Gender(value string) error {
+	if len(value) == 0 {
+		return nil
+	}
+	for _, gender := range Genders {
+		if value == gender {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must be one of %s, got %q", FieldGender, strings.Join(Genders, ", "), value)
+}
+
+// Birthdate checks an optional birthdate: empty, or a date in BirthdateLayout. Whether it lies in the future depends on
+// the current date, which BirthdateNotAfter checks separately.
+func Birthdate(value string) error {
+	if len(value) == 0 {
+		return nil
+	}
+	if _, err := time.Parse(BirthdateLayout, value); err != nil {
+		return fmt.Errorf("%s must be a date such as 1990-05-17, got %q", FieldBirthdate, value)
+	}
+	return nil
+}
+
+// BirthdateNotAfter checks that an optional birthdate accepted by Birthdate is not later than the date of now.
+func BirthdateNotAfter(value string, now time.Time) error {
+	if len(value) == 0 {
+		return nil
+	}
+	if value > now.Format(BirthdateLayout) {
+		return fmt.Errorf("%s %s is in the future", FieldBirthdate, value)
+	}
+	return nil
+}
+
+// NormalizePhone reduces a phone number to its digits, the form Phone accepts, so that a number typed with a leading
+// '+' or grouped by spaces, dashes or parentheses can still be looked up.
+func NormalizePhone(value string) string {
+	var normalized strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			normalized.WriteRune(r)
+		}
+	}
+	return normalized.String()
+}
@@ -0,0 +1,171 @@
+/*
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// validFields is a person every rule accepts, which tests change one field of at a time.
+func validFields() PersonFields {
+	return PersonFields{
+		ID:      "person1",
+		Serial:  "0510 228148",
+		Name:    "Ivan",
+		Surname: "Petrov",
+		City:    "Moscow",
+		Address: "Lenina 1",
+		Phone:   "88005553535",
+	}
+}
+
+// requireValid checks the outcome of validating value against whether it should have been accepted.
+func requireValid(t *testing.T, valid bool, err error, value string) {
+	t.Helper()
+	if valid {
+		require.NoError(t, err, "%q should be accepted", value)
+	} else {
+		require.Error(t, err, "%q should be rejected", value)
+	}
+}
+
+func TestRequired(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		valid bool
+	}{
+		{"present", "Moscow", true},
+		{"empty", "", false},
+		{"at max length", strings.Repeat("a", MaxLength), true},
+		{"over max length", strings.Repeat("a", MaxLength+1), false},
+		{"max length counted in characters", strings.Repeat("я", MaxLength), true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requireValid(t, test.valid, Required(FieldCity, test.value), test.value)
+		})
+	}
+}
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{"Ivan", true},
+		{"Anna-Maria", true},
+		{"O'Brien", true},
+		{"Mary Ann", true},
+		{"Иван", true},
+		{"", false},
+		{"R2D2", false},
+		{"Ivan!", false},
+	}
+	for _, test := range tests {
+		requireValid(t, test.valid, Name(FieldName, test.value), test.value)
+	}
+}
+
+func TestSerial(t *testing.T) {
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{"0510 228148", true},
+		{"0000 000000", true},
+		{"", false},
+		{"0510228148", false},
+		{"0510  228148", false},
+		{"051 0228148", false},
+		{"0510 22814", false},
+		{"0510 2281480", false},
+		{"05a0 228148", false},
+		{"0510-228148", false},
+		{"٠٥١٠ ٢٢٨١٤٨", false},
+	}
+	for _, test := range tests {
+		requireValid(t, test.valid, Serial(test.value), test.value)
+	}
+}
+
+func TestPhone(t *testing.T) {
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{"88005553535", true},
+		{"1234567", true},
+		{"123456789012345", true},
+		{"", false},
+		{"123456", false},
+		{"1234567890123456", false},
+		{"+78005553535", false},
+		{"8 800 5553535", false},
+		{"8-800-555-35-35", false},
+		{"8(800)5553535", false},
+		{"7+8005553535", false},
+		{"800.555.3535", false},
+		{"８８００５５５３５３５", false},
+	}
+	for _, test := range tests {
+		requireValid(t, test.valid, Phone(test.value), test.value)
+	}
+}
+
+func TestNormalizePhone(t *testing.T) {
+	tests := map[string]string{
+		"+7 800 555-35-35": "78005553535",
+		"(495) 123-4567":   "4951234567",
+		" 8 800 5553535 ":  "88005553535",
+		"88005553535":      "88005553535",
+	}
+	for value, want := range tests {
+		require.Equal(t, want, NormalizePhone(value), "NormalizePhone(%q)", value)
+	}
+}
+
+func TestPersonAcceptsValidPerson(t *testing.T) {
+	require.NoError(t, Person(validFields()))
+}
+
+func TestPersonReportsFirstProblem(t *testing.T) {
+	fields := validFields()
+	fields.Name = "Ivan1"
+	fields.Phone = "not a phone"
+
+	err := Person(fields)
+	require.Error(t, err)
+	require.True(t, strings.HasPrefix(err.Error(), FieldName+" "), "%q should be the name problem", err)
+}
+
+func TestMissingFields(t *testing.T) {
+	fields := validFields()
+	fields.Surname = "  "
+	fields.Phone = ""
+
+	require.Equal(t, []string{FieldSurname, FieldPhone}, MissingFields(fields))
+}
+
+func TestFieldAppliesTheRulesOfTheField(t *testing.T) {
+	tests := []struct {
+		field string
+		value string
+		valid bool
+	}{
+		{FieldSurname, "Petrov", true},
+		{FieldSurname, "Petrov2", false},
+		{FieldPhone, "abc", false},
+		{FieldReference, "", true},
+		{FieldAddress, "", false},
+		{FieldAddress, "Lenina 1, apt. 2", true},
+	}
+	for _, test := range tests {
+		requireValid(t, test.valid, Field(test.field, test.value), test.value)
+	}
+}